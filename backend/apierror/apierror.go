@@ -0,0 +1,145 @@
+// Package apierror defines the errors the API answers with. Each wraps one
+// of the kinds below, which fixes its status code, and carries a code
+// naming the condition for clients. Errors are rendered as
+// {"error": {"code": ..., "message": ...}}.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/i18n"
+)
+
+// Kinds of error, matched with errors.Is.
+var (
+	ErrValidation    = errors.New("validation failed")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrGone          = errors.New("gone")
+	ErrUnprocessable = errors.New("unprocessable")
+	ErrTooLarge      = errors.New("payload too large")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrUnavailable   = errors.New("unavailable")
+)
+
+// kinds gives the status code of each kind and the code of its errors that
+// do not name their own.
+var kinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{ErrForbidden, http.StatusForbidden, "forbidden"},
+	{ErrNotFound, http.StatusNotFound, "not_found"},
+	{ErrConflict, http.StatusConflict, "conflict"},
+	{ErrGone, http.StatusGone, "gone"},
+	{ErrUnprocessable, http.StatusUnprocessableEntity, "unprocessable"},
+	{ErrTooLarge, http.StatusRequestEntityTooLarge, "payload_too_large"},
+	{ErrRateLimited, http.StatusTooManyRequests, "rate_limited"},
+	{ErrUnavailable, http.StatusServiceUnavailable, "unavailable"},
+}
+
+// Error is an error of Kind that the client is shown. It satisfies gofr's
+// contracts for handler errors: StatusCode picks the response status and
+// Response adds its code, and any Details, to the rendered error object.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	// Details are further fields for the client to explain the error with.
+	Details map[string]interface{}
+
+	// format and args built Message, for Localize to build it again.
+	format string
+	args   []interface{}
+}
+
+// New returns an Error of kind with a formatted message.
+func New(kind error, code, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Code: code, Message: fmt.Sprintf(format, args...), format: format, args: args}
+}
+
+// Localize returns a copy of e with its message in lang, where the i18n
+// catalog has it.
+func (e *Error) Localize(lang string) *Error {
+	out := *e
+	if e.format != "" {
+		out.Message = i18n.Sprintf(lang, e.format, e.args...)
+	}
+
+	return &out
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) Unwrap() error { return e.Kind }
+
+func (e *Error) StatusCode() int { return Status(e) }
+
+func (e *Error) Response() map[string]interface{} {
+	fields := map[string]interface{}{"code": Code(e)}
+	for k, v := range e.Details {
+		fields[k] = v
+	}
+
+	return fields
+}
+
+// Status maps err to the status code it is answered with: that of the kind
+// it wraps, or 500 for an error of no kind.
+func Status(err error) int {
+	for _, k := range kinds {
+		if errors.Is(err, k.kind) {
+			return k.status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Code returns the code err is answered with: its own if it is an *Error
+// that names one, otherwise its kind's, or "internal_error".
+func Code(err error) string {
+	var e *Error
+	if errors.As(err, &e) && e.Code != "" {
+		return e.Code
+	}
+
+	for _, k := range kinds {
+		if errors.Is(err, k.kind) {
+			return k.code
+		}
+	}
+
+	return "internal_error"
+}
+
+// Write renders err as a JSON error response, for handlers gofr does not
+// serve. The message of an error of no kind is withheld from the client.
+func Write(w http.ResponseWriter, err error) {
+	status, message := Status(err), err.Error()
+	if status == http.StatusInternalServerError {
+		message = "internal server error"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	fields := map[string]interface{}{"code": Code(err)}
+
+	var e *Error
+	if errors.As(err, &e) {
+		fields = e.Response()
+	}
+
+	fields["message"] = message
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": fields})
+}
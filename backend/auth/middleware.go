@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// Middleware parses a bearer token from the Authorization header and, when it
+// is valid, stores its claims in the request context for UserID and Role to
+// find.
+// Requests without a valid token pass through unauthenticated; handlers that
+// need a user reject them.
+func Middleware(tokens *Tokens) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+
+			raw, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			claims, err := tokens.Parse(strings.TrimSpace(raw))
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, claims)))
+		})
+	}
+}
+
+// UserID returns the authenticated user's ID stored by Middleware.
+func UserID(ctx context.Context) (int, bool) {
+	claims, ok := ctx.Value(contextKey{}).(Claims)
+	return claims.UserID, ok
+}
+
+// Role returns the authenticated user's role, or "" for unauthenticated
+// requests.
+func Role(ctx context.Context) string {
+	claims, _ := ctx.Value(contextKey{}).(Claims)
+	return claims.Role
+}
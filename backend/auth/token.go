@@ -0,0 +1,76 @@
+// Package auth issues and verifies the JWTs that identify API users.
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, expired or not
+// signed with the configured secret.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are what a token says about its bearer.
+type Claims struct {
+	UserID int
+	Role   string
+}
+
+// tokenClaims is the JWT payload: the user ID as subject plus a role claim.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role,omitempty"`
+}
+
+// Tokens signs and verifies HS256 tokens whose subject is a user ID.
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewTokens returns a Tokens that signs with secret and issues tokens valid for ttl.
+func NewTokens(secret string, ttl time.Duration) *Tokens {
+	return &Tokens{secret: []byte(secret), ttl: ttl}
+}
+
+// Issue returns a signed token for a user with the given role and the time
+// it expires.
+func (t *Tokens) Issue(userID int, role string) (string, time.Time, error) {
+	now := time.Now()
+	expires := now.Add(t.ttl)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+		Role: role,
+	})
+
+	signed, err := token.SignedString(t.secret)
+
+	return signed, expires, err
+}
+
+// Parse verifies token and returns the claims it was issued with.
+func (t *Tokens) Parse(token string) (Claims, error) {
+	var claims tokenClaims
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return t.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return Claims{UserID: userID, Role: claims.Role}, nil
+}
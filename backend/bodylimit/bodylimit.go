@@ -0,0 +1,57 @@
+// Package bodylimit caps the size of request bodies, so that no request can
+// make the service hold more than a bounded amount of it in memory.
+package bodylimit
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+)
+
+// DefaultMaxBytes is the body size allowed when none is configured.
+const DefaultMaxBytes = 1 << 20
+
+// Middleware answers any request whose body is longer than max bytes with a
+// 413, before it reaches the handler. A declared Content-Length is enough to
+// refuse a request; otherwise the body is read up to the limit, and the
+// handler is given what was read.
+func Middleware(max int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > max {
+				tooLarge(w, max)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, max+1))
+			r.Body.Close()
+
+			switch {
+			case err != nil:
+				apierror.Write(w, apierror.New(apierror.ErrValidation, "invalid_body", "reading request body: %v", err))
+				return
+			case int64(len(body)) > max:
+				tooLarge(w, max)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func tooLarge(w http.ResponseWriter, max int64) {
+	// The rest of the body is not read, so the connection cannot be reused.
+	w.Header().Set("Connection", "close")
+	apierror.Write(w, apierror.New(apierror.ErrTooLarge, "payload_too_large", "request body must be at most %d bytes", max))
+}
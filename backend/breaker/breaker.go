@@ -0,0 +1,175 @@
+// Package breaker stops calling a provider that keeps failing, so that
+// callers give up at once instead of waiting on it, and tries it again once
+// a cooldown has passed.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// States of a Breaker.
+const (
+	// Closed lets every call through.
+	Closed = "closed"
+	// Open refuses every call until the cooldown has passed.
+	Open = "open"
+	// HalfOpen lets one trial call through, whose outcome closes the
+	// breaker again or reopens it.
+	HalfOpen = "half_open"
+)
+
+// Defaults for a Breaker configured with zero values.
+const (
+	DefaultThreshold = 5
+	DefaultCooldown  = 30 * time.Second
+)
+
+// ErrOpen is returned, without calling through, while the breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Breaker trips open after Threshold consecutive failures and stays open
+// for Cooldown. It is safe for concurrent use.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	trial    bool
+	// generation counts changes of state, so that a call that outlives the
+	// state it was let through in is not counted against the next one.
+	generation uint64
+}
+
+// New returns a closed Breaker. A threshold or cooldown that is not
+// positive takes the default.
+func New(threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+
+	return &Breaker{threshold: threshold, cooldown: cooldown, state: Closed}
+}
+
+// Do calls fn unless the breaker is open, and counts its outcome. It
+// returns ErrOpen without calling fn while the breaker is open, or while
+// another caller's trial call is in flight.
+func (b *Breaker) Do(fn func() error) error {
+	gen, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	b.record(gen, err == nil)
+
+	return err
+}
+
+// allow reports whether a call may go through and, if so, the generation it
+// goes through in.
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.cooldown {
+			return 0, ErrOpen
+		}
+
+		b.setState(HalfOpen)
+	case HalfOpen:
+		if b.trial {
+			return 0, ErrOpen
+		}
+	default:
+		return b.generation, nil
+	}
+
+	b.trial = true
+
+	return b.generation, nil
+}
+
+// record counts the outcome of a call let through in generation gen. Only
+// the trial call decides a half-open breaker's next state; a call let
+// through before the state last changed is not counted at all.
+func (b *Breaker) record(gen uint64, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if gen != b.generation {
+		return
+	}
+
+	if b.state == HalfOpen {
+		b.trial = false
+
+		if ok {
+			b.failures = 0
+			b.setState(Closed)
+		} else {
+			b.failures++
+			b.openedAt = time.Now()
+			b.setState(Open)
+		}
+
+		return
+	}
+
+	if ok {
+		b.failures = 0
+		return
+	}
+
+	if b.failures++; b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(Open)
+	}
+}
+
+func (b *Breaker) setState(state string) {
+	b.state = state
+	b.generation++
+}
+
+// State returns Closed, Open or HalfOpen. An open breaker whose cooldown
+// has passed reports HalfOpen: the next call is a trial.
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open && time.Since(b.openedAt) >= b.cooldown {
+		return HalfOpen
+	}
+
+	return b.state
+}
+
+// Check reports an error while the breaker is open, for a health check;
+// it never calls the provider itself.
+func (b *Breaker) Check(context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != Open {
+		return nil
+	}
+
+	if left := b.cooldown - time.Since(b.openedAt); left > 0 {
+		return fmt.Errorf("%w after %d failures; retrying in %s", ErrOpen, b.failures, left.Round(time.Second))
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errFailed = errors.New("failed")
+
+func fail() error    { return errFailed }
+func succeed() error { return nil }
+
+func TestBreakerTripsAndRecovers(t *testing.T) {
+	b := New(2, time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		if err := b.Do(fail); !errors.Is(err, errFailed) {
+			t.Fatalf("call %d = %v, want %v", i, err, errFailed)
+		}
+	}
+
+	if err := b.Do(succeed); !errors.Is(err, ErrOpen) {
+		t.Fatalf("call while open = %v, want %v", err, ErrOpen)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	if got := b.State(); got != HalfOpen {
+		t.Fatalf("state after cooldown = %s, want %s", got, HalfOpen)
+	}
+
+	if err := b.Do(succeed); err != nil {
+		t.Fatalf("trial call = %v", err)
+	}
+
+	if got := b.State(); got != Closed {
+		t.Errorf("state after the trial succeeded = %s, want %s", got, Closed)
+	}
+}
+
+func TestBreakerIgnoresCallsFromAnEarlierState(t *testing.T) {
+	for _, ok := range []bool{true, false} {
+		b := New(1, time.Millisecond)
+
+		// A call let through while closed is still running when another
+		// trips the breaker, the cooldown passes and a trial starts.
+		slow, err := b.allow()
+		if err != nil {
+			t.Fatalf("allow() = %v", err)
+		}
+
+		b.Do(fail)
+		time.Sleep(2 * time.Millisecond)
+
+		trial, err := b.allow()
+		if err != nil {
+			t.Fatalf("trial allow() = %v", err)
+		}
+
+		b.record(slow, ok)
+
+		if got := b.State(); got != HalfOpen {
+			t.Errorf("slow call ok=%v: state = %s, want %s until the trial ends", ok, got, HalfOpen)
+		}
+
+		if err := b.Do(succeed); !errors.Is(err, ErrOpen) {
+			t.Errorf("slow call ok=%v: call during the trial = %v, want %v", ok, err, ErrOpen)
+		}
+
+		b.record(trial, false)
+
+		if got := b.State(); got != Open {
+			t.Errorf("slow call ok=%v: state after the trial failed = %s, want %s", ok, got, Open)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Package cache is a small in-memory LRU cache whose entries expire after a
+// fixed TTL.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU holds up to a fixed number of entries, evicting the least recently
+// used when full. It is safe for concurrent use, and a nil *LRU caches
+// nothing.
+type LRU struct {
+	size int
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+type entry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// New returns an LRU of size entries that each live for ttl.
+func New(size int, ttl time.Duration) *LRU {
+	return &LRU{size: size, ttl: ttl, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get returns the unexpired value stored under key.
+func (c *LRU) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if !time.Now().Before(e.expires) {
+		c.order.Remove(el)
+		delete(c.items, key)
+
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+
+	return e.value, true
+}
+
+// Set stores value under key for the cache's TTL.
+func (c *LRU) Set(key string, value interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).key)
+	}
+}
+
+// Purge empties the cache.
+func (c *LRU) Purge() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+}
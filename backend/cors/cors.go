@@ -0,0 +1,82 @@
+// Package cors lets browsers on other origins call the API.
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Wildcard, as an allowed origin, allows every origin. It is meant for
+// development.
+const Wildcard = "*"
+
+// preflightMaxAge is how long, in seconds, browsers may cache a preflight.
+const preflightMaxAge = 600
+
+const (
+	allowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	allowedHeaders = "Authorization, Content-Type, Idempotency-Key, If-None-Match, X-Correlation-ID"
+	exposedHeaders = "ETag, Retry-After, X-Correlation-ID"
+)
+
+// Middleware adds CORS headers to responses for requests from origins and
+// answers preflight requests itself. Requests from other origins are passed
+// on without CORS headers, so browsers block them.
+func Middleware(origins []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		allowed[strings.TrimRight(o, "/")] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			if !allowed[Wildcard] && !allowed[origin] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allowed[Wildcard] {
+				h.Set("Access-Control-Allow-Origin", Wildcard)
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				h.Set("Access-Control-Allow-Methods", allowedMethods)
+				h.Set("Access-Control-Allow-Headers", allowedHeaders)
+				h.Set("Access-Control-Max-Age", strconv.Itoa(preflightMaxAge))
+				w.WriteHeader(http.StatusNoContent)
+
+				return
+			}
+
+			h.Set("Access-Control-Expose-Headers", exposedHeaders)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ParseOrigins splits a comma-separated list of origins, dropping blanks.
+func ParseOrigins(list string) []string {
+	var origins []string
+
+	for _, o := range strings.Split(list, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+
+	return origins
+}
@@ -0,0 +1,100 @@
+// Package delay measures how late buses reach the stops on their routes.
+package delay
+
+import (
+	"math"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/schedule"
+)
+
+// MaxDeviation bounds how far an arrival may be from when a trip was due at
+// the stop and still count towards it. Anything further off belongs to no
+// trip, such as a bus parked at a stop between runs.
+const MaxDeviation = 3 * time.Hour
+
+// Arrival is a bus reaching Stop at Actual when it was due at Scheduled.
+type Arrival struct {
+	Stop      string
+	Scheduled time.Time
+	Actual    time.Time
+}
+
+// Delay is how late the arrival was; it is negative for an early one.
+func (a Arrival) Delay() time.Duration { return a.Actual.Sub(a.Scheduled) }
+
+// Summarize averages the delay of arrivals at each of stops, in the order
+// given, to the nearest tenth of a minute. A stop with fewer than minSamples
+// arrivals has no average, since a trip or two says little about how late
+// a route usually runs. Arrivals at other stops are ignored.
+func Summarize(stops []string, arrivals []Arrival, minSamples int) []models.StopDelay {
+	if minSamples < 1 {
+		minSamples = 1
+	}
+
+	total := make(map[string]time.Duration)
+	count := make(map[string]int)
+
+	for _, a := range arrivals {
+		total[a.Stop] += a.Delay()
+		count[a.Stop]++
+	}
+
+	out := make([]models.StopDelay, 0, len(stops))
+
+	for _, s := range stops {
+		d := models.StopDelay{Stop: s, Samples: count[s]}
+
+		if d.Samples >= minSamples {
+			avg := math.Round((total[s]/time.Duration(d.Samples)).Minutes()*10) / 10
+			d.AverageDelayMinutes = &avg
+		}
+
+		out = append(out, d)
+	}
+
+	return out
+}
+
+// Due returns when a bus running to sched was due at a stop offset into its
+// trip, picking the trip due closest to at. It reports false if no trip was
+// due within MaxDeviation of at.
+func Due(sched schedule.Schedule, at time.Time, offset time.Duration) (time.Time, bool) {
+	loc := sched.Loc
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	departed := at.Add(-offset).In(loc)
+
+	var (
+		best  time.Time
+		found bool
+	)
+
+	for _, days := range []int{-1, 0, 1} {
+		dep, ok := sched.Resolve(departed.AddDate(0, 0, days))
+		if !ok {
+			continue
+		}
+
+		if due := dep.Add(offset); !found || abs(at.Sub(due)) < abs(at.Sub(best)) {
+			best, found = due, true
+		}
+	}
+
+	if !found || abs(at.Sub(best)) > MaxDeviation {
+		return time.Time{}, false
+	}
+
+	return best, true
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
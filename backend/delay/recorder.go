@@ -0,0 +1,107 @@
+package delay
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/geofence"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/stopdist"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// Recorder stores the arrivals geofence events report at stops on each
+// bus's own route. A bus is due at a stop its departure time plus the time
+// it takes to get there along the route at the bus's average speed.
+type Recorder struct {
+	store        store.Store
+	distances    *stopdist.Matrix
+	logger       *slog.Logger
+	defaultSpeed float64
+}
+
+// NewRecorder returns a Recorder that measures routes with distances, logs
+// to logger and times buses without an average speed of their own at
+// defaultSpeedKmh.
+func NewRecorder(st store.Store, distances *stopdist.Matrix, logger *slog.Logger, defaultSpeedKmh float64) *Recorder {
+	return &Recorder{store: st, distances: distances, logger: logger, defaultSpeed: defaultSpeedKmh}
+}
+
+// Run records arrivals from events until ctx is done or events is closed.
+func (r *Recorder) Run(ctx context.Context, events <-chan geofence.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if err := r.record(ctx, e); err != nil && ctx.Err() == nil {
+				r.logger.ErrorContext(ctx, "recording stop arrival failed",
+					slog.Int("bus_id", e.BusID), slog.String("stop", e.Stop), slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// record stores e if its stop is on the bus's route and the bus was due
+// there around then. Stops the route cannot be timed to, for want of
+// coordinates, are skipped.
+func (r *Recorder) record(ctx context.Context, e geofence.Event) error {
+	bus, err := r.store.GetBusByID(ctx, e.BusID)
+	if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrBusDeleted) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	stops, err := r.store.GetRouteStops(ctx, bus.Route.ID)
+	if err != nil {
+		return err
+	}
+
+	var meters float64
+
+	for i, s := range stops {
+		if i > 0 {
+			d, err := r.distances.DistanceBetween(ctx, stops[i-1].StopID, s.StopID)
+			if errors.Is(err, stopdist.ErrNoCoordinates) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			meters += d
+		}
+
+		if s.Name != e.Stop {
+			continue
+		}
+
+		speed := r.defaultSpeed
+		if bus.AvgSpeedKmh != nil && *bus.AvgSpeedKmh > 0 {
+			speed = *bus.AvgSpeedKmh
+		}
+
+		offset := time.Duration(meters / 1000 / speed * float64(time.Hour))
+
+		due, ok := Due(bus.Timetable(), e.At, offset)
+		if !ok {
+			return nil
+		}
+
+		return r.store.RecordArrival(ctx, models.StopArrival{
+			BusID:       bus.ID,
+			RouteID:     bus.Route.ID,
+			StopID:      s.StopID,
+			ScheduledAt: due,
+			ArrivedAt:   e.At,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+// Package demand works out how quickly a departure's seats are booked.
+package demand
+
+import (
+	"errors"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+// Bucket sizes for Series.
+const (
+	Hour = "hour"
+	Day  = "day"
+)
+
+// ErrUnsupportedBucket is returned for a bucket size other than Hour or Day.
+var ErrUnsupportedBucket = errors.New("unsupported bucket size")
+
+// Series counts bookedAt, the times bookings were made, into consecutive
+// buckets of the given size from the one holding the first booking to the
+// one holding until, which is usually the departure or now if that is
+// sooner. Buckets start on the hour or at midnight in until's location, and
+// those without bookings are included so the series has no gaps. Bookings
+// after until are left out.
+func Series(bookedAt []time.Time, bucket string, until time.Time) ([]models.BookingBucket, error) {
+	if bucket != Hour && bucket != Day {
+		return nil, ErrUnsupportedBucket
+	}
+
+	series := []models.BookingBucket{}
+
+	var first time.Time
+	for _, t := range bookedAt {
+		if !t.After(until) && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+
+	if first.IsZero() {
+		return series, nil
+	}
+
+	loc := until.Location()
+	index := make(map[int64]int)
+
+	for start := floor(first.In(loc), bucket); !start.After(until); start = next(start, bucket) {
+		index[start.Unix()] = len(series)
+		series = append(series, models.BookingBucket{Start: start, End: next(start, bucket)})
+	}
+
+	for _, t := range bookedAt {
+		if t.After(until) {
+			continue
+		}
+
+		if i, ok := index[floor(t.In(loc), bucket).Unix()]; ok {
+			series[i].Bookings++
+		}
+	}
+
+	total := 0
+	for i := range series {
+		total += series[i].Bookings
+		series[i].Cumulative = total
+	}
+
+	return series, nil
+}
+
+// floor returns the start of the bucket holding t. Hours are found by
+// stepping back to the local hour rather than by time.Date, so the two hours
+// that share a local time when clocks go back stay apart.
+func floor(t time.Time, bucket string) time.Time {
+	if bucket == Day {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+
+	into := time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+
+	return t.Add(-into)
+}
+
+// next returns the start of the bucket after the one starting at start.
+func next(start time.Time, bucket string) time.Time {
+	if bucket == Day {
+		return start.AddDate(0, 0, 1)
+	}
+
+	return start.Add(time.Hour)
+}
@@ -0,0 +1,96 @@
+// Package eta estimates when a bus will reach a stop on its route.
+package eta
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/geo"
+)
+
+// ErrUnknownStop is returned when the requested stop is not on the route.
+var ErrUnknownStop = errors.New("stop is not on the route")
+
+// Stop is a stop on a route with its coordinates.
+type Stop struct {
+	Name  string
+	Point geo.Point
+	// ToNext is the distance in meters to the next stop along the route;
+	// zero works it out from the two points.
+	ToNext float64
+}
+
+// Estimate is the result of Calculate.
+type Estimate struct {
+	// Upcoming is false when the bus has already passed the stop on its
+	// current trip; the remaining fields are then zero.
+	Upcoming bool
+	// DistanceMeters is the distance left to travel along the route.
+	DistanceMeters float64
+	Duration       time.Duration
+}
+
+// NextStop returns the index of the first stop in stops that a bus at pos
+// has not yet reached. The bus is assumed to be on the route segment it is
+// closest to.
+func NextStop(pos geo.Point, stops []Stop) int {
+	if len(stops) < 2 {
+		return 0
+	}
+
+	best, bestDist := 0, math.Inf(1)
+
+	for i := 0; i < len(stops)-1; i++ {
+		along, dist := geo.SegmentPosition(pos, stops[i].Point, stops[i+1].Point)
+		if dist < bestDist {
+			best, bestDist = i+1, dist
+
+			// Short of the start of the route, the first stop is still ahead.
+			if i == 0 && along == 0 {
+				best = 0
+			}
+		}
+	}
+
+	return best
+}
+
+// Calculate estimates how long a bus at pos travelling at speedKmh takes to
+// reach the stop named target along stops.
+func Calculate(pos geo.Point, stops []Stop, target string, speedKmh float64) (Estimate, error) {
+	targetIdx := -1
+
+	for i, s := range stops {
+		if s.Name == target {
+			targetIdx = i
+			break
+		}
+	}
+
+	if targetIdx < 0 {
+		return Estimate{}, ErrUnknownStop
+	}
+
+	next := NextStop(pos, stops)
+	if targetIdx < next {
+		return Estimate{Upcoming: false}, nil
+	}
+
+	meters := geo.Distance(pos, stops[next].Point)
+	for i := next; i < targetIdx; i++ {
+		if leg := stops[i].ToNext; leg > 0 {
+			meters += leg
+		} else {
+			meters += geo.Distance(stops[i].Point, stops[i+1].Point)
+		}
+	}
+
+	hours := meters / 1000 / speedKmh
+
+	return Estimate{
+		Upcoming:       true,
+		DistanceMeters: meters,
+		Duration:       time.Duration(hours * float64(time.Hour)),
+	}, nil
+}
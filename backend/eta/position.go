@@ -0,0 +1,99 @@
+package eta
+
+import (
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/geo"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/schedule"
+)
+
+// PositionEstimator places a bus that is not reporting its position where
+// its timetable says it should be: along its route, as far as it would have
+// got at SpeedKmh since it last departed.
+type PositionEstimator struct {
+	SpeedKmh float64
+}
+
+// Position is where a PositionEstimator places a bus.
+type Position struct {
+	Point geo.Point
+	// Departed is when the trip the bus is on left its first stop.
+	Departed time.Time
+	// Progress is the share of the route covered, from 0 to 1.
+	Progress float64
+	// NextStop is the index of the first stop not yet reached.
+	NextStop int
+}
+
+// Estimate places a bus running to sched along stops at now. It reports
+// false when the timetable has no trip under way at now, either because
+// none has left yet or because the last one would already have reached the
+// end of the route, or when the route cannot be travelled.
+func (e PositionEstimator) Estimate(sched schedule.Schedule, stops []Stop, now time.Time) (Position, bool) {
+	if len(stops) < 2 || e.SpeedKmh <= 0 {
+		return Position{}, false
+	}
+
+	legs := make([]float64, len(stops)-1)
+
+	var total float64
+
+	for i := range legs {
+		if legs[i] = stops[i].ToNext; legs[i] <= 0 {
+			legs[i] = geo.Distance(stops[i].Point, stops[i+1].Point)
+		}
+
+		total += legs[i]
+	}
+
+	if total <= 0 {
+		return Position{}, false
+	}
+
+	trip := time.Duration(total / 1000 / e.SpeedKmh * float64(time.Hour))
+
+	// A trip that left late yesterday may still be under way; the latest
+	// departure at or before now is the one the bus is on.
+	var departed time.Time
+
+	for _, days := range []int{0, -1} {
+		if dep, ok := sched.Resolve(now.AddDate(0, 0, days)); ok && !dep.After(now) {
+			departed = dep
+			break
+		}
+	}
+
+	if departed.IsZero() || now.Sub(departed) > trip {
+		return Position{}, false
+	}
+
+	travelled := e.SpeedKmh * 1000 * now.Sub(departed).Hours()
+	pos := Position{Departed: departed, Progress: travelled / total}
+
+	for i, leg := range legs {
+		// Rounding may leave a sliver past the last stop; the bus is at it.
+		if travelled > leg && i < len(legs)-1 {
+			travelled -= leg
+			continue
+		}
+
+		if travelled > leg {
+			travelled = leg
+		}
+
+		// Over the few kilometres between stops a straight line between
+		// them is as good as the route's shape, which is not known.
+		f := 0.0
+		if leg > 0 {
+			f = travelled / leg
+		}
+
+		a, b := stops[i].Point, stops[i+1].Point
+		pos.Point = geo.Point{Lat: a.Lat + (b.Lat-a.Lat)*f, Lng: a.Lng + (b.Lng-a.Lng)*f}
+		pos.NextStop = i + 1
+
+		break
+	}
+
+	return pos, true
+}
@@ -0,0 +1,47 @@
+// Package geo has the distance helpers shared by the location features.
+package geo
+
+import "math"
+
+const earthRadiusMeters = 6371000.0
+
+// Point is a WGS84 coordinate in degrees.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Distance returns the great-circle distance between a and b in meters,
+// using the Haversine formula.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// SegmentPosition locates p relative to the segment from a to b. It returns
+// how far along the segment the closest point lies, from 0 (at a) to 1 (at
+// b), and the distance in meters from p to that point. Distances are
+// approximated on a local plane, which is accurate over the few kilometres
+// between neighbouring stops.
+func SegmentPosition(p, a, b Point) (along, distance float64) {
+	scale := math.Cos(radians((a.Lat + b.Lat) / 2))
+
+	// Planar coordinates in meters relative to a.
+	bx, by := radians(b.Lng-a.Lng)*scale*earthRadiusMeters, radians(b.Lat-a.Lat)*earthRadiusMeters
+	px, py := radians(p.Lng-a.Lng)*scale*earthRadiusMeters, radians(p.Lat-a.Lat)*earthRadiusMeters
+
+	if lenSq := bx*bx + by*by; lenSq > 0 {
+		along = math.Max(0, math.Min(1, (px*bx+py*by)/lenSq))
+	}
+
+	return along, math.Hypot(px-along*bx, py-along*by)
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
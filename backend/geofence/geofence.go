@@ -0,0 +1,136 @@
+// Package geofence raises an event when a bus comes within range of a stop.
+package geofence
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/geo"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+// DefaultRadiusMeters is used for stops that do not set their own radius.
+const DefaultRadiusMeters = 200.0
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before further events to it are dropped.
+const subscriberBuffer = 16
+
+// Stop is a stop to watch. A zero RadiusMeters means DefaultRadiusMeters.
+type Stop struct {
+	Name         string
+	Point        geo.Point
+	RadiusMeters float64
+}
+
+// Event reports that a bus has entered a stop's radius.
+type Event struct {
+	BusID          int       `json:"bus_id"`
+	Stop           string    `json:"stop"`
+	DistanceMeters float64   `json:"distance_meters"`
+	At             time.Time `json:"at"`
+}
+
+// Monitor turns location updates into Events. A bus raises one event per
+// stop each time it moves from outside the stop's radius to inside it. It is
+// safe for concurrent use.
+type Monitor struct {
+	stops []Stop
+
+	mu     sync.Mutex
+	inside map[int]map[string]bool
+	subs   map[chan Event]struct{}
+}
+
+// NewMonitor returns a Monitor watching stops.
+func NewMonitor(stops []Stop) *Monitor {
+	watched := make([]Stop, len(stops))
+	copy(watched, stops)
+
+	for i := range watched {
+		if watched[i].RadiusMeters <= 0 {
+			watched[i].RadiusMeters = DefaultRadiusMeters
+		}
+	}
+
+	return &Monitor{
+		stops:  watched,
+		inside: make(map[int]map[string]bool),
+		subs:   make(map[chan Event]struct{}),
+	}
+}
+
+// Subscribe returns a channel of future events and a function that ends the
+// subscription and closes the channel.
+func (m *Monitor) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	m.mu.Lock()
+	m.subs[ch] = struct{}{}
+	m.mu.Unlock()
+
+	var once sync.Once
+
+	return ch, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			delete(m.subs, ch)
+			close(ch)
+		})
+	}
+}
+
+// Observe checks u against every stop and notifies subscribers of any the
+// bus has just entered.
+func (m *Monitor) Observe(u models.LocationUpdate) {
+	pos := geo.Point{Lat: u.Lat, Lng: u.Lng}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.inside[u.BusID]
+	if state == nil {
+		state = make(map[string]bool)
+		m.inside[u.BusID] = state
+	}
+
+	for _, s := range m.stops {
+		dist := geo.Distance(pos, s.Point)
+		within := dist <= s.RadiusMeters
+
+		if within && !state[s.Name] {
+			m.publish(Event{BusID: u.BusID, Stop: s.Name, DistanceMeters: dist, At: u.Timestamp})
+		}
+
+		state[s.Name] = within
+	}
+}
+
+// publish must be called with m.mu held.
+func (m *Monitor) publish(e Event) {
+	for ch := range m.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Run observes updates until ctx is done or updates is closed.
+func (m *Monitor) Run(ctx context.Context, updates <-chan models.LocationUpdate) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case u, ok := <-updates:
+			if !ok {
+				return
+			}
+
+			m.Observe(u)
+		}
+	}
+}
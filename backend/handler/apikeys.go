@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// apiKeyPrefixLen is how much of a key is kept in the clear to tell keys
+// apart by.
+const apiKeyPrefixLen = 10
+
+// CreateAPIKey handles POST /api-keys, issuing a key for a server-to-server
+// client. The response carries the key itself, which is not shown again.
+func (h *Handler) CreateAPIKey(ctx *gofr.Context) (interface{}, error) {
+	var req models.NewAPIKey
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	key, err := auth.NewKey()
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := auth.UserID(ctx)
+
+	created, err := h.store.CreateAPIKey(ctx, models.APIKey{
+		Name: req.Name, Prefix: key[:apiKeyPrefixLen], Scopes: req.Scopes, CreatedBy: userID, Hash: auth.HashKey(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created.Key = key
+
+	return created, nil
+}
+
+// RevokeAPIKey handles DELETE /api-keys/{id}. Requests made with the key
+// are refused from then on.
+func (h *Handler) RevokeAPIKey(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.store.RevokeAPIKey(ctx, id); errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("api_key_not_found", "API key %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	setStatus(ctx, http.StatusNoContent)
+
+	return nil, nil
+}
+
+// LookupAPIKey is the auth.KeyLookup for auth.KeyMiddleware.
+func (h *Handler) LookupAPIKey(ctx context.Context, hash string) (auth.Key, error) {
+	k, err := h.store.GetAPIKeyByHash(ctx, hash)
+	if errors.Is(err, store.ErrNotFound) {
+		return auth.Key{}, auth.ErrInvalidKey
+	} else if err != nil {
+		return auth.Key{}, err
+	}
+
+	return auth.Key{ID: k.ID, Scopes: k.Scopes}, nil
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// GetAuditLog handles GET /audit?resource=ticket&id=..., the changes made to
+// one resource, or to every resource of the kind when id is omitted, newest
+// first.
+func (h *Handler) GetAuditLog(ctx *gofr.Context) (interface{}, error) {
+	filter := store.AuditFilter{Resource: ctx.Param("resource")}
+
+	known := false
+
+	for _, r := range models.AuditResources {
+		if r == filter.Resource {
+			known = true
+		}
+	}
+
+	if !known {
+		return nil, badRequest("invalid_parameter", "resource must be one of: %s",
+			strings.Join(models.AuditResources, ", "))
+	}
+
+	if v := ctx.Param("id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 1 {
+			return nil, badRequest("invalid_parameter", "id must be a positive integer")
+		}
+
+		filter.ResourceID = id
+	}
+
+	page, err := parsePage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, total, err := h.store.GetAuditLog(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return pageResponse{Data: entries, Total: total, Limit: page.Limit, Offset: page.Offset}, nil
+}
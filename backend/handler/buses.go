@@ -0,0 +1,575 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/pricing"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/requestlog"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/schedule"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// ListBuses handles GET /buses. Responses are cached per query for
+// Config.BusCacheTTL, and carry an ETag hashed from the page so clients can
+// revalidate with If-None-Match.
+func (h *Handler) ListBuses(ctx *gofr.Context) (interface{}, error) {
+	page, err := parsePage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := store.BusFilter{
+		DepartureAfter:  ctx.Param("departure_after"),
+		DepartureBefore: ctx.Param("departure_before"),
+		IncludeDeleted:  ctx.Param("include_deleted") == "true",
+	}
+
+	if filter.IncludeDeleted && auth.Role(ctx) != models.RoleAdmin {
+		return nil, forbidden("role_required", "include_deleted is only available to admins")
+	}
+
+	from, to := ctx.Param("from"), ctx.Param("to")
+	if (from == "") != (to == "") {
+		return nil, badRequest("invalid_parameter", "from and to must be given together")
+	}
+
+	if from != "" {
+		if filter.FromStop, err = h.stopID(ctx, from); err != nil {
+			return nil, err
+		}
+
+		if filter.ToStop, err = h.stopID(ctx, to); err != nil {
+			return nil, err
+		}
+
+		if filter.FromStop == filter.ToStop {
+			return nil, badRequest("invalid_parameter", "from and to must be different stops")
+		}
+	}
+
+	after, err := parseClock("departure_after", filter.DepartureAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := parseClock("departure_before", filter.DepartureBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.DepartureAfter != "" && filter.DepartureBefore != "" && after.After(before) {
+		return nil, badRequest("invalid_parameter", "departure_after must not be later than departure_before")
+	}
+
+	if filter.Amenities, err = parseAmenities(ctx.Param("amenities")); err != nil {
+		return nil, err
+	}
+
+	order, err := parseBusOrder(ctx.Param("sort"))
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%+v|%+v|%+v", filter, order, page)
+
+	cached, hit := h.buses.Get(key)
+	requestlog.Logger(ctx).DebugContext(ctx, "bus list cache", slog.Bool("hit", hit), slog.String("key", key))
+
+	list, _ := cached.(busList)
+
+	if !hit {
+		buses, total, err := h.store.GetBuses(ctx, filter, order, page)
+		if err != nil {
+			return nil, err
+		}
+
+		list.page = pageResponse{Data: buses, Total: total, Limit: page.Limit, Offset: page.Offset}
+		if list.etag, err = etagOf(list.page); err != nil {
+			return nil, err
+		}
+
+		h.buses.Set(key, list)
+	}
+
+	if notModified(ctx, list.etag) {
+		return nil, nil
+	}
+
+	return list.page, nil
+}
+
+// busList is a GET /buses response as cached, with its ETag.
+type busList struct {
+	page pageResponse
+	etag string
+}
+
+// stopID resolves a stop name given in the query to its stop, ignoring
+// case and spacing.
+func (h *Handler) stopID(ctx *gofr.Context, name string) (int, error) {
+	stop, err := h.store.FindStop(ctx, name)
+	if errors.Is(err, store.ErrNotFound) {
+		return 0, notFound("stop_not_found", "stop %q not found", name)
+	}
+
+	return stop.ID, err
+}
+
+// ListStops handles GET /stops, listing every stop buses call at.
+func (h *Handler) ListStops(ctx *gofr.Context) (interface{}, error) {
+	return h.store.GetStops(ctx)
+}
+
+// parseBusOrder parses the sort query parameter: one of
+// store.BusSortFields, prefixed with "-" for descending order.
+func parseBusOrder(sort string) (store.BusOrder, error) {
+	if sort == "" {
+		return store.BusOrder{Field: store.SortDeparture}, nil
+	}
+
+	order := store.BusOrder{Field: strings.TrimPrefix(sort, "-"), Desc: strings.HasPrefix(sort, "-")}
+
+	for _, f := range store.BusSortFields {
+		if order.Field == f {
+			return order, nil
+		}
+	}
+
+	return store.BusOrder{}, badRequest("invalid_parameter", "sort %q is not supported; use one of %s, optionally prefixed with - for descending",
+		sort, strings.Join(store.BusSortFields, ", "))
+}
+
+// parseAmenities parses a comma-separated list of amenities, which may be
+// empty, into the sorted set of them, rejecting any that are unknown.
+func parseAmenities(list string) ([]string, error) {
+	known := make(map[string]bool, len(models.Amenities))
+	for _, a := range models.Amenities {
+		known[a] = true
+	}
+
+	seen := make(map[string]bool)
+
+	var (
+		amenities []string
+		unknown   []string
+	)
+
+	for _, a := range strings.Split(list, ",") {
+		a = strings.ToLower(strings.TrimSpace(a))
+
+		switch {
+		case a == "" || seen[a]:
+			continue
+		case !known[a]:
+			unknown = append(unknown, a)
+		default:
+			amenities = append(amenities, a)
+		}
+
+		seen[a] = true
+	}
+
+	if len(unknown) > 0 {
+		err := apierror.New(apierror.ErrValidation, "unknown_amenity", "unknown amenities %s; use any of %s",
+			strings.Join(unknown, ", "), strings.Join(models.Amenities, ", "))
+		err.Details = map[string]interface{}{"unknown": unknown, "valid": models.Amenities}
+
+		return nil, err
+	}
+
+	sort.Strings(amenities)
+
+	return amenities, nil
+}
+
+// clockLayout is the "HH:MM" form departure times are given in.
+const clockLayout = "15:04"
+
+// parseClock parses the time-of-day query parameter name, which may be empty.
+func parseClock(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(clockLayout, value)
+	if err != nil {
+		return time.Time{}, badRequest("invalid_parameter", "%s %q must be a time of day as HH:MM", name, value)
+	}
+
+	return t, nil
+}
+
+// ListBusModels handles GET /bus-models, the seat layouts buses can be
+// built to.
+func (h *Handler) ListBusModels(ctx *gofr.Context) (interface{}, error) {
+	return h.store.GetBusModels(ctx)
+}
+
+// CreateBus handles POST /buses, adding a bus of an existing model to a
+// route.
+func (h *Handler) CreateBus(ctx *gofr.Context) (interface{}, error) {
+	var req models.NewBus
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	if req.Class == "" {
+		req.Class = pricing.ClassStandard
+	}
+
+	if req.RunDays == "" {
+		req.RunDays = schedule.Daily
+	} else {
+		days, _ := schedule.ParseDays(req.RunDays)
+		req.RunDays = days.String()
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+
+	bus, err := h.store.CreateBus(ctx, req)
+
+	switch {
+	case errors.Is(err, store.ErrBusModelNotFound):
+		return nil, badRequest("bus_model_not_found", "bus model %d does not exist", req.ModelID)
+	case errors.Is(err, store.ErrRouteNotFound):
+		return nil, badRequest("route_not_found", "route %d does not exist", req.RouteID)
+	case errors.Is(err, store.ErrBusExists):
+		return nil, conflict("bus_exists", "bus %d already exists", req.ID)
+	case err != nil:
+		return nil, err
+	}
+
+	h.buses.Purge()
+
+	return bus, nil
+}
+
+// UpdateSchedule handles PUT /buses/{id}/schedule. Bookings already made
+// are kept, but any the change may affect are listed in the response. A
+// change made against an outdated version of the bus gets a 409.
+func (h *Handler) UpdateSchedule(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.Schedule
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	if req.RunDays == "" {
+		req.RunDays = schedule.Daily
+	} else {
+		days, _ := schedule.ParseDays(req.RunDays)
+		req.RunDays = days.String()
+	}
+
+	bus, affected, err := h.store.UpdateSchedule(ctx, id, req)
+	if err == nil {
+		h.buses.Purge()
+	}
+
+	var stale *store.VersionConflictError
+
+	switch {
+	case errors.As(err, &stale):
+		return nil, conflict("version_conflict", "bus %d is at version %d, not %d; fetch it again and retry", id, stale.Current, req.Version)
+	case errors.Is(err, store.ErrRouteNotFound):
+		return nil, badRequest("route_not_found", "route %d does not exist", req.RouteID)
+	case errors.Is(err, store.ErrBusDeleted):
+		return nil, gone("bus_out_of_service", "bus %d is no longer in service", id)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	case err != nil:
+		return nil, err
+	}
+
+	change := models.ScheduleChange{Bus: bus, AffectedTicketIDs: affected}
+	if len(affected) > 0 {
+		change.Warning = fmt.Sprintf("%d upcoming booking(s) were made against the previous schedule", len(affected))
+	}
+
+	return change, nil
+}
+
+// DeleteBus handles DELETE /buses/{id}. The bus is only marked deleted, so
+// its tickets keep pointing at it.
+func (h *Handler) DeleteBus(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.store.DeleteBus(ctx, id); errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	h.buses.Purge()
+
+	setStatus(ctx, http.StatusNoContent)
+
+	return nil, nil
+}
+
+// dateLayout is the form calendar dates are given in.
+const dateLayout = "2006-01-02"
+
+// dayParam reads the date query parameter as the start of that day in loc,
+// or of today when it is omitted.
+func dayParam(ctx *gofr.Context, loc *time.Location) (time.Time, error) {
+	date := ctx.Param("date")
+	if date == "" {
+		y, m, d := time.Now().In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc), nil
+	}
+
+	day, err := time.ParseInLocation(dateLayout, date, loc)
+	if err != nil {
+		return time.Time{}, badRequest("invalid_parameter", "date %q must be a calendar date as YYYY-MM-DD", date)
+	}
+
+	return day, nil
+}
+
+// GetBus handles GET /buses/{id}?date=YYYY-MM-DD, reporting occupancy for
+// travel on date, or today when it is omitted, in the bus's time zone. A
+// request whose If-None-Match names the current ETag gets a 304.
+func (h *Handler) GetBus(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bus, err := h.store.GetBusByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	day, err := dayParam(ctx, bus.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	booked, err := h.store.CountBookedSeats(ctx, id, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	detail := models.BusDetail{Bus: bus, OccupancyDate: day.Format(dateLayout), BookedSeats: booked}
+	if departs, runs := bus.Timetable().Resolve(day); runs {
+		detail.Runs = true
+		detail.DepartsAt = &departs
+	}
+
+	detail.Occupancy = models.OccupancyPercent(booked, bus.Capacity)
+
+	// The tag covers the bus's version and its occupancy, which changes
+	// with every booking without touching the version.
+	tag, err := etagOf(detail)
+	if err != nil {
+		return nil, err
+	}
+
+	if notModified(ctx, tag) {
+		return nil, nil
+	}
+
+	return detail, nil
+}
+
+// GetSeatMap handles GET /buses/{id}/seats?date=..., where date is the
+// RFC3339 travel date used when booking.
+func (h *Handler) GetSeatMap(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	date := ctx.Param("date")
+	if date == "" {
+		return nil, badRequest("invalid_parameter", "date is required")
+	}
+
+	travel, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return nil, badRequest("invalid_parameter", "date %q is not a valid RFC3339 timestamp", date)
+	}
+
+	bus, err := h.store.GetBusByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := checkRuns(bus, travel); err != nil {
+		return nil, err
+	}
+
+	booked, err := h.store.GetBookedSeats(ctx, id, travel)
+	if err != nil {
+		return nil, err
+	}
+
+	seatMap := models.NewSeatMap(bus.Capacity, bus.Model, booked)
+	seatMap.BusID = id
+	seatMap.TravelDate = date
+
+	return seatMap, nil
+}
+
+// checkRuns returns a 400 unless bus runs on the day travel falls on in the
+// bus's time zone.
+func checkRuns(bus models.Bus, travel time.Time) error {
+	timetable := bus.Timetable()
+	if _, runs := timetable.Resolve(travel); runs {
+		return nil
+	}
+
+	day := travel.In(timetable.Loc)
+
+	return badRequest("bus_not_running", "bus %d does not run on %s %s; it runs %s",
+		bus.ID, day.Weekday(), day.Format(dateLayout), timetable.Days)
+}
+
+// checkCutoff returns a 422 once bookings have closed for the departure of
+// bus on the day travel falls on, its cutoff before it leaves. The error
+// gives the departure and the cutoff so clients can explain it.
+func (h *Handler) checkCutoff(bus models.Bus, travel time.Time) error {
+	departs, runs := bus.Timetable().Resolve(travel)
+	if !runs {
+		return nil
+	}
+
+	cutoff := h.cfg.BookingCutoff
+	if bus.BookingCutoffMinutes != nil {
+		cutoff = time.Duration(*bus.BookingCutoffMinutes) * time.Minute
+	}
+
+	if time.Until(departs) >= cutoff {
+		return nil
+	}
+
+	minutes := int(cutoff.Minutes())
+
+	err := apierror.New(apierror.ErrUnprocessable, "booking_closed",
+		"bookings for bus %d close %d minutes before it departs at %s", bus.ID, minutes, departs.Format(time.RFC3339))
+	err.Details = map[string]interface{}{"departs_at": departs, "cutoff_minutes": minutes}
+
+	return err
+}
+
+// GetFare handles GET /buses/{id}/fare?from=X&to=Y&date=YYYY-MM-DD, pricing
+// the journey by the distance along the route between the two stops and
+// raising it by the surge multiplier of the departure on date, today when it
+// is omitted, as bookings would be now.
+func (h *Handler) GetFare(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := ctx.Param("from"), ctx.Param("to")
+	if from == "" || to == "" {
+		return nil, badRequest("invalid_parameter", "from and to are required")
+	}
+
+	bus, err := h.store.GetBusByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	stops, err := h.store.GetRouteStops(ctx, bus.Route.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := stopIndex(stops, from), stopIndex(stops, to)
+
+	switch {
+	case start < 0:
+		return nil, notFound("stop_not_on_route", "stop %q is not on the route of bus %d", from, id)
+	case end < 0:
+		return nil, notFound("stop_not_on_route", "stop %q is not on the route of bus %d", to, id)
+	case start >= end:
+		return nil, badRequest("stops_out_of_order", "from must come before to on the route of bus %d", id)
+	}
+
+	path := make([]int, 0, end-start+1)
+
+	for _, rs := range stops[start : end+1] {
+		if rs.Lat == nil || rs.Lng == nil {
+			return nil, unprocessable("stop_without_coordinates",
+				"stop %q on route %s has no coordinates", rs.Name, bus.Route.Name)
+		}
+
+		path = append(path, rs.StopID)
+	}
+
+	meters, err := h.distances.Along(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	fare, err := h.fares.Calculate(bus.Class, meters)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := dayParam(ctx, bus.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	multiplier, err := h.surgeMultiplier(ctx, bus, day)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.FareQuote{
+		BusID:           id,
+		From:            stops[start].Name,
+		To:              stops[end].Name,
+		Class:           fare.Class,
+		DistanceKm:      fare.DistanceKm,
+		RatePerKm:       fare.RatePerKm,
+		BaseFare:        fare.Total,
+		Date:            day.Format(dateLayout),
+		SurgeMultiplier: multiplier,
+		Fare:            pricing.Surge(fare.Total, multiplier),
+	}, nil
+}
+
+// stopIndex returns the position of the named stop in stops, or -1. Names
+// match as models.StopKey normalizes them.
+func stopIndex(stops []models.RouteStop, name string) int {
+	key := models.StopKey(name)
+
+	for i, rs := range stops {
+		if models.StopKey(rs.Name) == key {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// DBHeader names the databases that served a request's reads, as
+// store.ReadFrom reports them, when debug headers are on.
+const DBHeader = "X-Debug-DB"
+
+// DebugHeaders is middleware that, if enabled, reports in DBHeader whether
+// each request read from the primary database or its replica. It is off
+// unless asked for, since it tells clients about the deployment.
+func DebugHeaders(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := store.TrackReads(r.Context())
+
+			next.ServeHTTP(&debugWriter{ResponseWriter: w, ctx: ctx}, r.WithContext(ctx))
+		})
+	}
+}
+
+// debugWriter adds the debug headers when the response header is written,
+// by which time the handler has made its reads.
+type debugWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	wroteHeader bool
+}
+
+func (w *debugWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set(DBHeader, store.ReadFrom(w.ctx))
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *debugWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *debugWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack keeps WebSocket upgrades working behind the middleware.
+func (w *debugWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	return h.Hijack()
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/delay"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// delayWindow is how far back GET /routes/{id}/delays looks.
+const delayWindow = 30 * 24 * time.Hour
+
+// minDelaySamples is how many arrivals a stop needs before its average
+// delay is reported.
+const minDelaySamples = 5
+
+// GetRouteDelays handles GET /routes/{id}/delays, averaging how late buses
+// have reached each stop on the route over the last 30 days.
+func (h *Handler) GetRouteDelays(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Add(-delayWindow).UTC()
+
+	recorded, err := h.store.GetArrivals(ctx, id, since)
+	if errors.Is(err, store.ErrRouteNotFound) {
+		return nil, notFound("route_not_found", "route %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	routeStops, err := h.store.GetRouteStops(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(routeStops))
+	for _, rs := range routeStops {
+		names = append(names, rs.Name)
+	}
+
+	arrivals := make([]delay.Arrival, 0, len(recorded))
+	for _, a := range recorded {
+		arrivals = append(arrivals, delay.Arrival{Stop: a.Stop, Scheduled: a.ScheduledAt, Actual: a.ArrivedAt})
+	}
+
+	resp := models.RouteDelays{RouteID: id, Since: since, Stops: delay.Summarize(names, arrivals, minDelaySamples)}
+
+	measured := 0
+	for _, s := range resp.Stops {
+		if s.AverageDelayMinutes != nil {
+			measured++
+		}
+	}
+
+	switch {
+	case measured == 0:
+		resp.Message = fmt.Sprintf("not enough arrivals have been recorded on route %d in the last %d days to estimate delays; each stop needs at least %d",
+			id, int(delayWindow.Hours()/24), minDelaySamples)
+	case measured < len(resp.Stops):
+		resp.Message = fmt.Sprintf("stops with fewer than %d recorded arrivals have no average delay", minDelaySamples)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/demand"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// GetBookingRate handles GET /buses/{id}/booking-rate?date=YYYY-MM-DD&bucket=hour|day,
+// the bookings for the bus's departure that day, or today, in its time
+// zone, counted per hour or day from the first booking up to the departure,
+// or now if it is still to come.
+func (h *Handler) GetBookingRate(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := ctx.Param("bucket")
+	if bucket == "" {
+		bucket = demand.Hour
+	}
+
+	bus, err := h.store.GetBusByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	day, err := dayParam(ctx, bus.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	rate := models.BookingRate{BusID: id, Date: day.Format(dateLayout), Bucket: bucket}
+
+	until := day.AddDate(0, 0, 1)
+	if departs, runs := bus.Timetable().Resolve(day); runs {
+		rate.DepartsAt, until = &departs, departs
+	}
+
+	if now := time.Now().In(bus.Location()); now.Before(until) {
+		until = now
+	}
+
+	booked, err := h.store.GetBookingTimes(ctx, id, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	rate.Series, err = demand.Series(booked, bucket, until)
+	if errors.Is(err, demand.ErrUnsupportedBucket) {
+		return nil, badRequest("invalid_parameter", "bucket %q is not supported; use %s or %s", bucket, demand.Hour, demand.Day)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if n := len(rate.Series); n > 0 {
+		rate.Total = rate.Series[n-1].Cumulative
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// etagOf returns a weak entity tag for v, hashed from its JSON form. It is
+// weak because gofr, not the hash, decides the exact bytes sent.
+func etagOf(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// notModified sets the response's ETag to tag and reports whether the
+// request's If-None-Match already names it, in which case the response is
+// switched to a 304 and the handler should return no body.
+func notModified(ctx context.Context, tag string) bool {
+	setHeader(ctx, "ETag", tag)
+
+	for _, candidate := range strings.Split(requestHeader(ctx, "If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			setStatus(ctx, http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// exchange gives handlers the raw request headers and lets them adjust the
+// status code and headers of the response, neither of which gofr's handler
+// signature exposes.
+type exchange struct {
+	request http.Header
+	status  int
+	header  http.Header
+}
+
+type exchangeKey struct{}
+
+// Exchange is middleware that makes requestHeader, setStatus and setHeader
+// work inside handlers. It must be installed for those helpers to have any
+// effect.
+func Exchange() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ex := &exchange{request: r.Header, header: make(http.Header)}
+			ctx := context.WithValue(r.Context(), exchangeKey{}, ex)
+
+			next.ServeHTTP(&exchangeWriter{ResponseWriter: w, ex: ex}, r.WithContext(ctx))
+		})
+	}
+}
+
+func exchangeFrom(ctx context.Context) *exchange {
+	ex, _ := ctx.Value(exchangeKey{}).(*exchange)
+	return ex
+}
+
+// requestHeader returns the named header of the current request.
+func requestHeader(ctx context.Context, name string) string {
+	if ex := exchangeFrom(ctx); ex != nil {
+		return ex.request.Get(name)
+	}
+
+	return ""
+}
+
+// setStatus overrides the status code gofr would otherwise pick.
+func setStatus(ctx context.Context, code int) {
+	if ex := exchangeFrom(ctx); ex != nil {
+		ex.status = code
+	}
+}
+
+// setHeader adds a header to the response.
+func setHeader(ctx context.Context, name, value string) {
+	if ex := exchangeFrom(ctx); ex != nil {
+		ex.header.Set(name, value)
+	}
+}
+
+// exchangeWriter applies the exchange's status and headers when the response
+// header is written.
+type exchangeWriter struct {
+	http.ResponseWriter
+	ex          *exchange
+	wroteHeader bool
+}
+
+func (w *exchangeWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+
+		for k, v := range w.ex.header {
+			w.Header()[k] = v
+		}
+
+		if w.ex.status != 0 {
+			code = w.ex.status
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *exchangeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *exchangeWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack keeps WebSocket upgrades working behind the middleware.
+func (w *exchangeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	return h.Hijack()
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/requestlog"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// exportFlushEvery is how many tickets are written between flushes.
+const exportFlushEvery = 100
+
+var exportColumns = []string{
+	"ticket_id", "user_id", "bus_id", "travel_date", "seat_numbers", "status", "fare", "cancelled_at",
+}
+
+// ExportTickets handles GET /tickets/export?bus_id=...&date=YYYY-MM-DD&format=csv|json,
+// streaming every booking for the bus on that date. It is served through
+// Mount because the export is written as it is read.
+func (h *Handler) ExportTickets(w http.ResponseWriter, r *http.Request) {
+	if _, ok := auth.UserID(r.Context()); !ok {
+		apierror.Write(w, unauthorized("token_required", "a valid bearer token is required"))
+		return
+	}
+
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	if format != "csv" && format != "json" {
+		apierror.Write(w, badRequest("invalid_parameter", "format %q is not supported; use csv or json", format))
+		return
+	}
+
+	busID, err := strconv.Atoi(q.Get("bus_id"))
+	if err != nil || busID < 1 {
+		apierror.Write(w, badRequest("invalid_parameter", "bus_id must be a positive integer"))
+		return
+	}
+
+	date := q.Get("date")
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		apierror.Write(w, badRequest("invalid_parameter", "date must be a calendar date as YYYY-MM-DD"))
+		return
+	}
+
+	ctx := r.Context()
+
+	bus, err := h.store.GetBusByID(ctx, busID)
+	if err != nil && !errors.Is(err, store.ErrBusDeleted) {
+		if errors.Is(err, store.ErrNotFound) {
+			err = notFound("bus_not_found", "bus %d not found", busID)
+		}
+
+		apierror.Write(w, err)
+
+		return
+	}
+
+	// The date is a day in the bus's time zone.
+	day, _ := time.ParseInLocation(dateLayout, date, bus.Location())
+
+	filename := fmt.Sprintf("tickets-bus%d-%s.%s", busID, day.Format(dateLayout), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var enc ticketEncoder
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		enc = &csvTickets{w: csv.NewWriter(w)}
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		enc = &jsonTickets{w: w}
+	}
+
+	flusher, _ := w.(http.Flusher)
+	written := 0
+
+	// Headers are out once the first byte is written, so a failure past
+	// this point can only cut the export short.
+	err = enc.begin()
+	if err == nil {
+		err = h.store.EachTicket(ctx, busID, day, day.AddDate(0, 0, 1), func(t models.Ticket) error {
+			if err := enc.encode(t); err != nil {
+				return err
+			}
+
+			if written++; written%exportFlushEvery == 0 {
+				enc.flush()
+
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if endErr := enc.end(); err == nil {
+		err = endErr
+	}
+
+	if err != nil {
+		requestlog.Logger(ctx).ErrorContext(ctx, "ticket export failed",
+			slog.Int("bus_id", busID), slog.Int("written", written), slog.String("error", err.Error()))
+	}
+}
+
+// ticketEncoder writes an export one ticket at a time.
+type ticketEncoder interface {
+	begin() error
+	encode(t models.Ticket) error
+	flush()
+	end() error
+}
+
+// csvTickets writes a header row and then one row per ticket.
+type csvTickets struct {
+	w *csv.Writer
+}
+
+func (c *csvTickets) begin() error { return c.w.Write(exportColumns) }
+
+func (c *csvTickets) encode(t models.Ticket) error { return c.w.Write(ticketRecord(t)) }
+
+func (c *csvTickets) flush() { c.w.Flush() }
+
+func (c *csvTickets) end() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonTickets writes a JSON array of tickets.
+type jsonTickets struct {
+	w       io.Writer
+	started bool
+}
+
+func (j *jsonTickets) begin() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonTickets) encode(t models.Ticket) error {
+	if j.started {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+
+	j.started = true
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	_, err = j.w.Write(b)
+
+	return err
+}
+
+func (j *jsonTickets) flush() {}
+
+func (j *jsonTickets) end() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
+
+func ticketRecord(t models.Ticket) []string {
+	seats := make([]string, len(t.SeatNumbers))
+	for i, n := range t.SeatNumbers {
+		seats[i] = strconv.Itoa(n)
+	}
+
+	var cancelled string
+	if t.CancelledAt != nil {
+		cancelled = t.CancelledAt.Format(time.RFC3339)
+	}
+
+	return []string{
+		strconv.Itoa(t.ID),
+		strconv.Itoa(t.UserID),
+		strconv.Itoa(t.BusID),
+		t.TravelDate.Format(time.RFC3339),
+		strings.Join(seats, " "),
+		t.Status,
+		t.Fare.String(),
+		cancelled,
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/pricing"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// SetRouteFare handles PUT /routes/{id}/fare, fixing the seat fare of every
+// bus on the route in place of the buses' own.
+func (h *Handler) SetRouteFare(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.RouteFare
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	req.RouteID = id
+
+	if err := h.store.SetRouteFare(ctx, req); errors.Is(err, store.ErrRouteNotFound) {
+		return nil, notFound("route_not_found", "route %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Listings sorted by fare may now be in the wrong order.
+	h.buses.Purge()
+
+	return req, nil
+}
+
+// seatFare returns the price of one seat on bus: its route's fixed fare if
+// there is one, else the bus's own.
+func (h *Handler) seatFare(ctx *gofr.Context, bus models.Bus) (money.Money, error) {
+	fare, err := h.store.GetRouteFare(ctx, bus.Route.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		return bus.SeatFare, nil
+	}
+
+	return fare, err
+}
+
+// surgeMultiplier returns the multiplier fares on bus are raised by for
+// travel on the day of at, in the bus's time zone, given how full that
+// departure already is.
+func (h *Handler) surgeMultiplier(ctx *gofr.Context, bus models.Bus, at time.Time) (float64, error) {
+	y, m, d := at.In(bus.Location()).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, bus.Location())
+
+	booked, err := h.store.CountBookedSeats(ctx, bus.ID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, err
+	}
+
+	return h.surge.Multiplier(booked, bus.Capacity), nil
+}
+
+// applyDiscount takes the discount code t names off its fare, or reports why
+// the code cannot be used. The use itself is only recorded when t is booked.
+func (h *Handler) applyDiscount(ctx *gofr.Context, t *models.Ticket) error {
+	code := strings.ToUpper(strings.TrimSpace(t.DiscountCode))
+
+	d, err := h.store.GetDiscountCode(ctx, code)
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return badRequest("discount_invalid", "discount code %q is not valid", t.DiscountCode)
+	case err != nil:
+		return err
+	case d.ExpiresAt != nil && !d.ExpiresAt.After(time.Now()):
+		return badRequest("discount_expired", "discount code %q has expired", t.DiscountCode)
+	case d.MaxUses != nil && d.Uses >= *d.MaxUses:
+		return badRequest("discount_used_up", "discount code %q has been used up", t.DiscountCode)
+	}
+
+	t.DiscountCode = code
+	t.OriginalFare = t.Fare
+
+	if d.Kind == pricing.DiscountFlat {
+		t.Discount = pricing.FlatOff(t.Fare, d.Flat)
+	} else {
+		t.Discount = pricing.PercentOff(t.Fare, d.Percent)
+	}
+
+	t.Fare -= t.Discount
+
+	return nil
+}
@@ -0,0 +1,273 @@
+// Package handler implements the HTTP handlers registered in main.
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/cache"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/metrics"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/notify"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/payment"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/pricing"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/stopdist"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/ticketqr"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/tracking"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/validation"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/waitlist"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/webhook"
+)
+
+// Config holds the tunables the handlers need.
+type Config struct {
+	// DefaultSpeedKmh is used for ETAs of buses without their own average speed.
+	DefaultSpeedKmh float64
+	// FareRatesPerKm overrides the per-km fare of bus classes; classes it
+	// leaves out use pricing.DefaultRatesPerKm.
+	FareRatesPerKm map[string]float64
+	// SurgeTiers raise booking fares as departures fill up; none leaves
+	// fares as they are.
+	SurgeTiers []pricing.SurgeTier
+	// ReturnDiscountPercent is taken off both fares of a round trip booked
+	// through POST /tickets/book/return, after any discount code.
+	ReturnDiscountPercent float64
+	// Notifier is sent a confirmation for every new booking; nil disables
+	// confirmations.
+	Notifier notify.Notifier
+	// HoldTTL is how long POST /tickets/hold reserves seats for.
+	HoldTTL time.Duration
+	// BookingCutoff is how long before departure a bus stops taking
+	// bookings and holds, unless it sets its own cutoff.
+	BookingCutoff time.Duration
+	// MaxSeatsPerUser caps how many seats one user may hold across their
+	// tickets for a bus's departure; zero lifts the cap.
+	MaxSeatsPerUser int
+	// Metrics records booking, cancellation and validation counts; nil
+	// records nothing.
+	Metrics *metrics.Metrics
+	// Waitlist is kicked whenever seats may have freed up; nil leaves
+	// waitlisted riders to its regular passes.
+	Waitlist *waitlist.Promoter
+	// Webhooks is published every booking, cancellation and validation; nil
+	// publishes nothing.
+	Webhooks *webhook.Dispatcher
+	// Occupancy is told of every booking, cancellation and boarding, to
+	// push the new occupancy to watchers of the bus; nil pushes nothing.
+	Occupancy *tracking.Occupancy
+	// LocationStaleAfter is how long after its latest position arrived a
+	// bus is reported as having no recent signal; zero never does.
+	LocationStaleAfter time.Duration
+	// Distances caches the distances between stops; nil gives the handler
+	// a cache of its own.
+	Distances *stopdist.Matrix
+	// QRSigningKey signs the payloads in ticket QR codes.
+	QRSigningKey string
+	// BusCacheTTL is how long a GET /buses response is served from memory;
+	// zero disables the cache. Writes to buses empty it straight away, but
+	// only on the instance that made them.
+	BusCacheTTL time.Duration
+	// BusCacheSize is how many distinct GET /buses queries are cached.
+	BusCacheSize int
+	// PaymentWebhookSecret is shared with the payment provider to sign its
+	// webhooks.
+	PaymentWebhookSecret string
+	// DisableTransfers turns away POST /tickets/{id}/transfer, for operators
+	// whose tickets must stay with whoever booked them.
+	DisableTransfers bool
+}
+
+// Handler serves the API on top of a Store.
+type Handler struct {
+	store     store.Store
+	hub       *tracking.Hub
+	tokens    *auth.Tokens
+	fares     *pricing.FareCalculator
+	surge     *pricing.SurgePricer
+	distances *stopdist.Matrix
+	notifier  notify.Notifier
+	qr        *ticketqr.Signer
+	buses     *cache.LRU
+	webhooks  *payment.Verifier
+	cfg       Config
+}
+
+// New returns a Handler.
+func New(st store.Store, hub *tracking.Hub, tokens *auth.Tokens, cfg Config) *Handler {
+	var notifier notify.Notifier = notify.Nop{}
+	if cfg.Notifier != nil {
+		notifier = cfg.Notifier
+	}
+
+	var buses *cache.LRU
+	if cfg.BusCacheTTL > 0 && cfg.BusCacheSize > 0 {
+		buses = cache.New(cfg.BusCacheSize, cfg.BusCacheTTL)
+	}
+
+	distances := cfg.Distances
+	if distances == nil {
+		distances = stopdist.New(st)
+	}
+
+	return &Handler{
+		store:     st,
+		hub:       hub,
+		tokens:    tokens,
+		fares:     pricing.NewFareCalculator(cfg.FareRatesPerKm),
+		surge:     pricing.NewSurgePricer(cfg.SurgeTiers),
+		distances: distances,
+		notifier:  notifier,
+		qr:        ticketqr.NewSigner(cfg.QRSigningKey),
+		buses:     buses,
+		webhooks:  payment.NewVerifier(cfg.PaymentWebhookSecret, payment.DefaultTolerance),
+		cfg:       cfg,
+	}
+}
+
+// The helpers below build the *apierror.Error of each kind that handlers
+// return, naming the condition with code.
+
+func badRequest(code, format string, args ...interface{}) error {
+	return apierror.New(apierror.ErrValidation, code, format, args...)
+}
+
+func unauthorized(code, format string, args ...interface{}) error {
+	return apierror.New(apierror.ErrUnauthorized, code, format, args...)
+}
+
+func forbidden(code, format string, args ...interface{}) error {
+	return apierror.New(apierror.ErrForbidden, code, format, args...)
+}
+
+func notFound(code, format string, args ...interface{}) error {
+	return apierror.New(apierror.ErrNotFound, code, format, args...)
+}
+
+func conflict(code, format string, args ...interface{}) error {
+	return apierror.New(apierror.ErrConflict, code, format, args...)
+}
+
+func gone(code, format string, args ...interface{}) error {
+	return apierror.New(apierror.ErrGone, code, format, args...)
+}
+
+func unprocessable(code, format string, args ...interface{}) error {
+	return apierror.New(apierror.ErrUnprocessable, code, format, args...)
+}
+
+// bind decodes the request body into v and checks its validate tags. When it
+// fails, return both results from the handler so field errors reach the client.
+func bind(ctx *gofr.Context, v interface{}) (interface{}, error) {
+	if err := ctx.Bind(v); err != nil {
+		return nil, badRequest("invalid_body", "invalid request body: %v", err)
+	}
+
+	if verr := validation.Struct(v); verr != nil {
+		return verr.Body, verr
+	}
+
+	return nil, nil
+}
+
+// RequireUser wraps h so that it only runs for requests carrying a valid
+// bearer token.
+func RequireUser(h gofr.Handler) gofr.Handler {
+	return func(ctx *gofr.Context) (interface{}, error) {
+		if _, ok := auth.UserID(ctx); !ok {
+			return nil, unauthorized("token_required", "a valid bearer token is required")
+		}
+
+		return h(ctx)
+	}
+}
+
+// RequireRole returns a wrapper like RequireUser that also requires the
+// token to carry one of roles.
+func RequireRole(roles ...string) func(gofr.Handler) gofr.Handler {
+	return func(h gofr.Handler) gofr.Handler {
+		return RequireUser(func(ctx *gofr.Context) (interface{}, error) {
+			role := auth.Role(ctx)
+
+			for _, r := range roles {
+				if role == r {
+					return h(ctx)
+				}
+			}
+
+			return nil, forbidden("role_required", "the %s role is required; you are signed in as %s", strings.Join(roles, " or "), role)
+		})
+	}
+}
+
+// RequireScope returns a wrapper that only runs handlers for requests
+// carrying an unrevoked API key granted scope.
+func RequireScope(scope string) func(gofr.Handler) gofr.Handler {
+	return func(h gofr.Handler) gofr.Handler {
+		return func(ctx *gofr.Context) (interface{}, error) {
+			key, ok := auth.APIKey(ctx)
+			if !ok {
+				return nil, unauthorized("api_key_required", "a valid API key is required in the %s header", auth.APIKeyHeader)
+			}
+
+			if !key.Has(scope) {
+				return nil, forbidden("scope_required", "the API key needs the %s scope", scope)
+			}
+
+			return h(ctx)
+		}
+	}
+}
+
+// pathID parses the {id} path parameter.
+func pathID(ctx *gofr.Context) (int, error) {
+	id, err := strconv.Atoi(ctx.PathParam("id"))
+	if err != nil {
+		return 0, badRequest("invalid_parameter", "id %q is not a valid integer", ctx.PathParam("id"))
+	}
+
+	return id, nil
+}
+
+// Pagination defaults for list endpoints.
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageResponse is the envelope returned by paginated list endpoints.
+type pageResponse struct {
+	Data   interface{} `json:"data"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// parsePage reads the limit and offset query parameters.
+func parsePage(ctx *gofr.Context) (store.Page, error) {
+	page := store.Page{Limit: defaultPageLimit}
+
+	if v := ctx.Param("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return store.Page{}, badRequest("invalid_parameter", "limit must be an integer between 1 and %d", maxPageLimit)
+		}
+
+		page.Limit = n
+	}
+
+	if v := ctx.Param("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return store.Page{}, badRequest("invalid_parameter", "offset must be a non-negative integer")
+		}
+
+		page.Offset = n
+	}
+
+	return page, nil
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/health"
+)
+
+// Health reports the status of every dependency checked by c. Anything but
+// an ok status is served as a 503 so load balancers pull the instance.
+func Health(c *health.Checker) gofr.Handler {
+	return func(ctx *gofr.Context) (interface{}, error) {
+		report := c.Run(ctx)
+		if report.Status != health.StatusOK {
+			return report, apierror.New(apierror.ErrUnavailable, "unhealthy", "service is %s", report.Status)
+		}
+
+		return report, nil
+	}
+}
+
+// Live answers liveness probes. It checks nothing: a process that can
+// answer is not wedged, and restarting it would not bring a dependency
+// back.
+func Live(ctx *gofr.Context) (interface{}, error) {
+	return map[string]string{"status": health.StatusOK}, nil
+}
+
+// Ready answers readiness probes with a 503 until every warm-up task of r
+// is done and every critical dependency of c is up, so that no traffic is
+// routed to an instance that cannot serve it yet.
+func Ready(c *health.Checker, r *health.Readiness) gofr.Handler {
+	return func(ctx *gofr.Context) (interface{}, error) {
+		report := c.Ready(ctx, r)
+		if report.Status != health.StatusReady {
+			return report, apierror.New(apierror.ErrUnavailable, "not_ready", "service is not ready")
+		}
+
+		return report, nil
+	}
+}
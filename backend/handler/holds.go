@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// HoldSeats handles POST /tickets/hold, reserving seats for the configured
+// hold TTL. The returned hold_token is then passed to POST /tickets/book.
+func (h *Handler) HoldSeats(ctx *gofr.Context) (interface{}, error) {
+	var req models.Hold
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	travel, _ := time.Parse(time.RFC3339, req.TravelDate)
+
+	bus, err := h.store.GetBusByID(ctx, req.BusID)
+
+	switch {
+	case errors.Is(err, store.ErrBusDeleted):
+		return nil, gone("bus_out_of_service", "bus %d is no longer in service", req.BusID)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("bus_not_found", "bus %d not found", req.BusID)
+	case err != nil:
+		return nil, err
+	}
+
+	if err := checkRuns(bus, travel); err != nil {
+		return nil, err
+	}
+
+	if err := h.checkCutoff(bus, travel); err != nil {
+		return nil, err
+	}
+
+	token, err := newHoldToken()
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := auth.UserID(ctx)
+
+	hold, err := h.store.CreateHold(ctx, models.SeatHold{
+		Token:       token,
+		UserID:      userID,
+		BusID:       req.BusID,
+		SeatNumbers: req.SeatNumbers,
+		TravelDate:  travel,
+		ExpiresAt:   time.Now().Add(h.cfg.HoldTTL).UTC(),
+		SeatLimit:   h.cfg.MaxSeatsPerUser,
+	})
+
+	var (
+		unavailable *store.SeatsUnavailableError
+		overLimit   *store.SeatLimitError
+	)
+
+	switch {
+	case errors.Is(err, store.ErrBusDeleted):
+		return nil, gone("bus_out_of_service", "bus %d is no longer in service", req.BusID)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("bus_not_found", "bus %d not found", req.BusID)
+	case errors.As(err, &unavailable):
+		return models.SeatConflict{UnavailableSeats: unavailable.Seats}, seatsUnavailable(unavailable)
+	case errors.As(err, &overLimit):
+		return nil, seatLimitExceeded(overLimit, "%v", overLimit)
+	case err != nil:
+		return nil, err
+	}
+
+	h.cfg.Occupancy.RefreshHolds(ctx, hold)
+
+	return hold, nil
+}
+
+// applyHold fills in the bus, seats and travel date of a booking made with a
+// hold token from the user's hold.
+func (h *Handler) applyHold(ctx *gofr.Context, req *models.Booking) error {
+	hold, err := h.store.GetHold(ctx, req.HoldToken)
+	if errors.Is(err, store.ErrNotFound) {
+		return notFound("hold_not_found", "seat hold not found")
+	} else if err != nil {
+		return err
+	}
+
+	// Another user's hold is reported as missing rather than confirming the
+	// token exists.
+	if userID, _ := auth.UserID(ctx); hold.UserID != userID {
+		return notFound("hold_not_found", "seat hold not found")
+	}
+
+	if !hold.ExpiresAt.After(time.Now()) {
+		return conflict("hold_expired", "seat hold expired at %s", hold.ExpiresAt.Format(time.RFC3339))
+	}
+
+	req.BusID = hold.BusID
+	req.SeatNumbers = hold.SeatNumbers
+	req.TravelDate = hold.TravelDate.Format(time.RFC3339Nano)
+
+	return nil
+}
+
+func newHoldToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/notify"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/requestlog"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// BookJourney handles POST /journeys/book, booking a ticket for every leg of
+// a trip with transfers, or none of them if any leg cannot be booked.
+func (h *Handler) BookJourney(ctx *gofr.Context) (interface{}, error) {
+	var req models.JourneyBooking
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	userID, _ := auth.UserID(ctx)
+	j := models.Journey{UserID: userID, Legs: make([]models.JourneyTicket, 0, len(req.Legs))}
+	ts := make([]models.Ticket, 0, len(req.Legs))
+
+	for i, leg := range req.Legs {
+		t, err := h.prepareLeg(ctx, leg)
+		if err != nil {
+			return models.BulkFailure{Index: i, Reason: err.Error()}, err
+		}
+
+		if i > 0 {
+			prev := req.Legs[i-1]
+
+			switch {
+			case leg.From != prev.To:
+				return nil, badRequest("legs_not_connected", "leg %d must start at %q, where leg %d ends", i, prev.To, i-1)
+			case t.TravelDate.Before(ts[i-1].TravelDate):
+				return nil, badRequest("legs_out_of_order", "leg %d departs before leg %d", i, i-1)
+			}
+		}
+
+		j.Legs = append(j.Legs, models.JourneyTicket{From: leg.From, To: leg.To, Ticket: t})
+		ts = append(ts, t)
+	}
+
+	j, err := h.store.CreateJourney(ctx, j)
+	if err != nil {
+		h.cfg.Metrics.BookingFailed()
+		return bulkFailure(err, ts, "leg")
+	}
+
+	h.cfg.Metrics.Booked(len(j.Legs))
+
+	for i, leg := range j.Legs {
+		ts[i] = leg.Ticket
+	}
+
+	h.cfg.Occupancy.Refresh(ctx, ts...)
+
+	notify.Async(requestlog.Logger(ctx), h.notifier, ts...)
+
+	for _, t := range ts {
+		h.cfg.Webhooks.Publish(ctx, models.EventBookingCreated, t)
+	}
+
+	return j, nil
+}
+
+// prepareLeg checks that a leg's stops are on its bus's route in order and
+// prices its ticket like any other booking.
+func (h *Handler) prepareLeg(ctx *gofr.Context, leg models.JourneyLeg) (models.Ticket, error) {
+	t, err := h.prepareTicket(ctx, models.Booking{
+		BusID:       leg.BusID,
+		SeatNumbers: leg.SeatNumbers,
+		TravelDate:  leg.TravelDate,
+		Timezone:    leg.Timezone,
+	})
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	bus, err := h.store.GetBusByID(ctx, leg.BusID)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	stops, err := h.store.GetRouteStops(ctx, bus.Route.ID)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	start, end := stopIndex(stops, leg.From), stopIndex(stops, leg.To)
+
+	switch {
+	case start < 0:
+		return models.Ticket{}, notFound("stop_not_on_route", "stop %q is not on the route of bus %d", leg.From, leg.BusID)
+	case end < 0:
+		return models.Ticket{}, notFound("stop_not_on_route", "stop %q is not on the route of bus %d", leg.To, leg.BusID)
+	case start >= end:
+		return models.Ticket{}, badRequest("stops_out_of_order", "from must come before to on the route of bus %d", leg.BusID)
+	}
+
+	return t, nil
+}
+
+// GetJourney handles GET /journeys/{id}.
+func (h *Handler) GetJourney(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := h.store.GetJourney(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("journey_not_found", "journey %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if userID, _ := auth.UserID(ctx); j.UserID != userID {
+		return nil, forbidden("not_owner", "journey %d belongs to another user", id)
+	}
+
+	return j, nil
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/i18n"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/validation"
+)
+
+// Localize wraps h so that the errors it returns are in the language the
+// request's Accept-Language header prefers, falling back to English. It
+// names the language chosen in Content-Language.
+func Localize(h gofr.Handler) gofr.Handler {
+	return func(ctx *gofr.Context) (interface{}, error) {
+		lang := language(ctx)
+		setHeader(ctx, "Content-Language", lang)
+
+		result, err := h(ctx)
+		if err == nil {
+			return result, nil
+		}
+
+		var verr *validation.Error
+		if errors.As(err, &verr) {
+			verr = verr.Localize(lang)
+			return verr.Body, verr
+		}
+
+		var aerr *apierror.Error
+		if errors.As(err, &aerr) {
+			return result, aerr.Localize(lang)
+		}
+
+		return result, err
+	}
+}
+
+// language returns the language to answer the current request in.
+func language(ctx context.Context) string {
+	return i18n.Negotiate(requestHeader(ctx, "Accept-Language"))
+}
+
+// translate returns msg in the language of the current request.
+func translate(ctx context.Context, msg string) string {
+	return i18n.Translate(language(ctx), msg)
+}
+
+// translatef formats args with format in the language of the current request.
+func translatef(ctx context.Context, format string, args ...interface{}) string {
+	return i18n.Sprintf(language(ctx), format, args...)
+}
+
+// seatsUnavailable is the conflict returned when seats asked for are taken.
+func seatsUnavailable(err *store.SeatsUnavailableError) error {
+	if err.Full {
+		return conflict("seats_unavailable", "bus is fully booked")
+	}
+
+	return conflict("seats_unavailable", "seats %v are not available", err.Seats)
+}
@@ -0,0 +1,662 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/eta"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/geo"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/stopdist"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/validation"
+)
+
+// GetLocation handles GET /bus/location/{id}, returning the last reported
+// position, when it arrived and whether that was recent enough for the bus
+// to count as live. A bus that has not reported lately, or ever, but should
+// be on a trip by its timetable gets a position estimated along its route
+// instead, so riders still see roughly where it is.
+func (h *Handler) GetLocation(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	pos, ok := h.hub.Live(id)
+	if ok {
+		if pos = h.signal(pos, now); pos.Signal == models.SignalLive {
+			return pos, nil
+		}
+	}
+
+	est, estimated, err := h.estimateLocation(ctx, id, now)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case estimated && ok:
+		est.LastReport = &pos.LocationUpdate
+		return est, nil
+	case estimated:
+		return est, nil
+	case !ok:
+		return nil, notFound("location_not_found", "no live location has been reported for bus %d", id)
+	}
+
+	return pos, nil
+}
+
+// estimateLocation places bus id along its route at now as far as its
+// timetable says it has got, reporting false if it should not be on a trip
+// then, or its route cannot be measured, or there is no such bus.
+func (h *Handler) estimateLocation(ctx *gofr.Context, id int, now time.Time) (models.LiveLocation, bool, error) {
+	bus, err := h.store.GetBusByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return models.LiveLocation{}, false, nil
+	} else if err != nil {
+		return models.LiveLocation{}, false, err
+	}
+
+	routeStops, err := h.store.GetRouteStops(ctx, bus.Route.ID)
+	if err != nil {
+		return models.LiveLocation{}, false, err
+	}
+
+	stops, err := h.etaStops(ctx, bus, routeStops)
+	if errors.Is(err, apierror.ErrUnprocessable) {
+		return models.LiveLocation{}, false, nil
+	} else if err != nil {
+		return models.LiveLocation{}, false, err
+	}
+
+	p, ok := eta.PositionEstimator{SpeedKmh: h.speed(bus)}.Estimate(bus.Timetable(), stops, now)
+	if !ok {
+		return models.LiveLocation{}, false, nil
+	}
+
+	at := now.UTC()
+
+	return models.LiveLocation{
+		LocationUpdate: models.LocationUpdate{BusID: id, Lat: p.Point.Lat, Lng: p.Point.Lng, Timestamp: at},
+		LastSeen:       at,
+		Signal:         models.SignalNone,
+		Estimated:      true,
+		Method:         models.EstimateSchedule,
+	}, true, nil
+}
+
+// signal judges from l.LastSeen whether the bus still has a recent signal at
+// now, by Config.LocationStaleAfter.
+func (h *Handler) signal(l models.LiveLocation, now time.Time) models.LiveLocation {
+	l.Signal = models.SignalLive
+	if h.cfg.LocationStaleAfter > 0 && now.Sub(l.LastSeen) > h.cfg.LocationStaleAfter {
+		l.Signal = models.SignalNone
+	}
+
+	return l
+}
+
+// ReportLocation handles POST /bus/location/{id}, for API keys with the
+// location:write scope.
+func (h *Handler) ReportLocation(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.LocationReport
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	if _, err := h.store.GetBusByID(ctx, id); errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if req.Timestamp.IsZero() {
+		req.Timestamp = time.Now().UTC()
+	}
+
+	update := models.LocationUpdate{BusID: id, Lat: req.Lat, Lng: req.Lng, Timestamp: req.Timestamp}
+	if err := h.store.RecordPosition(ctx, update); err != nil {
+		return nil, err
+	}
+
+	h.hub.Publish(update)
+
+	return update, nil
+}
+
+// maxLocationBatch bounds how many points one batch may carry.
+const maxLocationBatch = 1000
+
+// maxClockSkew is how far in the future a point's timestamp may be before it
+// is rejected, allowing for GPS units whose clocks run slightly fast.
+const maxClockSkew = time.Minute
+
+// ReportLocationBatch handles POST /bus/location/batch, storing the valid
+// points of a batch which may mix several buses and reporting why the rest
+// were rejected. Points may arrive in any order: every one is kept in the
+// trail, but only one newer than a bus's latest position replaces it.
+func (h *Handler) ReportLocationBatch(ctx *gofr.Context) (interface{}, error) {
+	var points []models.LocationUpdate
+	if err := ctx.Bind(&points); err != nil {
+		return nil, badRequest("invalid_body", "invalid request body: %v", err)
+	}
+
+	switch {
+	case len(points) == 0:
+		return nil, badRequest("invalid_body", "at least one point is required")
+	case len(points) > maxLocationBatch:
+		return nil, badRequest("invalid_body", "at most %d points may be sent at once", maxLocationBatch)
+	}
+
+	now := time.Now().UTC()
+	result := models.LocationBatchResult{Rejections: []models.RejectedPoint{}}
+	accepted := make([]models.LocationUpdate, 0, len(points))
+	known := make(map[int]bool)
+
+	for i, p := range points {
+		reason, err := h.checkPoint(ctx, &p, now, known)
+		if err != nil {
+			return nil, err
+		}
+
+		if reason != "" {
+			result.Rejections = append(result.Rejections, models.RejectedPoint{Index: i, Reason: reason})
+			continue
+		}
+
+		accepted = append(accepted, p)
+	}
+
+	if len(accepted) > 0 {
+		if err := h.store.RecordPositions(ctx, accepted); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].Timestamp.Before(accepted[j].Timestamp) })
+
+	for _, p := range accepted {
+		h.hub.Publish(p)
+	}
+
+	result.Accepted, result.Rejected = len(accepted), len(result.Rejections)
+	setStatus(ctx, http.StatusOK)
+
+	return result, nil
+}
+
+// checkPoint returns why p cannot be stored, or "" if it can, defaulting its
+// timestamp to now. known caches which buses exist across a batch.
+func (h *Handler) checkPoint(ctx *gofr.Context, p *models.LocationUpdate, now time.Time, known map[int]bool) (string, error) {
+	if verr := validation.Struct(p); verr != nil {
+		msgs := make([]string, 0, len(verr.Body.Errors))
+		for _, f := range verr.Body.Errors {
+			msgs = append(msgs, f.Field+" "+f.Message)
+		}
+
+		return strings.Join(msgs, "; "), nil
+	}
+
+	if p.Timestamp.IsZero() {
+		p.Timestamp = now
+	} else if p.Timestamp.After(now.Add(maxClockSkew)) {
+		return "timestamp is in the future", nil
+	}
+
+	exists, checked := known[p.BusID]
+	if !checked {
+		_, err := h.store.GetBusByID(ctx, p.BusID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return "", err
+		}
+
+		exists = err == nil
+		known[p.BusID] = exists
+	}
+
+	if !exists {
+		return fmt.Sprintf("bus %d not found", p.BusID), nil
+	}
+
+	return "", nil
+}
+
+// defaultTrailWindow is how far back GET /bus/{id}/trail looks without since.
+const defaultTrailWindow = time.Hour
+
+// maxTrailPoints caps the points one trail request returns.
+const maxTrailPoints = 5000
+
+// GetTrail handles GET /bus/{id}/trail?since=..., returning the positions
+// recorded for the bus since the given RFC3339 time, oldest first.
+func (h *Handler) GetTrail(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Add(-defaultTrailWindow)
+
+	if raw := ctx.Param("since"); raw != "" {
+		since, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, badRequest("invalid_parameter", "since %q is not a valid RFC3339 timestamp", raw)
+		}
+	}
+
+	if _, err := h.store.GetBusByID(ctx, id); errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return h.store.GetTrail(ctx, id, since, maxTrailPoints)
+}
+
+// StreamLocation handles the GET /ws/bus/location/{id} WebSocket, pushing
+// every position reported for the bus, and the occupancy of its departures
+// whenever a booking, cancellation or boarding changes it, until the client
+// goes away. Each message is a models.BusEvent.
+func (h *Handler) StreamLocation(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Returning an error closes the socket with the error as its close reason.
+	if _, err := h.store.GetBusByID(ctx, id); errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	updates, unsubscribe := h.hub.Subscribe(id)
+	defer unsubscribe()
+
+	occupancy, unsubscribeOccupancy := h.hub.SubscribeOccupancy(id)
+	defer unsubscribeOccupancy()
+
+	if last, ok := h.hub.Latest(id); ok {
+		if err := ctx.WriteMessageToSocket(models.BusEvent{Type: models.BusEventLocation, Location: &last}); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		var event models.BusEvent
+
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case u := <-updates:
+			event = models.BusEvent{Type: models.BusEventLocation, Location: &u}
+		case o := <-occupancy:
+			event = models.BusEvent{Type: models.BusEventOccupancy, Occupancy: &o}
+		}
+
+		if err := ctx.WriteMessageToSocket(event); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// StreamSeats handles the GET /ws/buses/{id}/seats?date=X WebSocket. It
+// sends the seat map of the bus's departure on date, as GET
+// /buses/{id}/seats does, then every change as seats are booked, held or
+// released, until the client goes away. Each message is a models.SeatEvent.
+func (h *Handler) StreamSeats(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Returning an error closes the socket with the error as its close reason.
+	date := ctx.Param("date")
+
+	travel, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return nil, fmt.Errorf("date %q is not a valid RFC3339 timestamp", date)
+	}
+
+	bus, err := h.store.GetBusByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := checkRuns(bus, travel); err != nil {
+		return nil, err
+	}
+
+	// Subscribe before reading the seats so that no change falls between
+	// the snapshot and the first update.
+	day := travel.In(bus.Location()).Format(dateLayout)
+
+	updates, unsubscribe := h.hub.SubscribeSeats(id, day)
+	defer unsubscribe()
+
+	booked, err := h.store.GetBookedSeats(ctx, id, travel)
+	if err != nil {
+		return nil, err
+	}
+
+	seatMap := models.NewSeatMap(bus.Capacity, bus.Model, h.hub.SeedSeats(id, day, booked))
+	seatMap.BusID = id
+	seatMap.TravelDate = date
+
+	if err := ctx.WriteMessageToSocket(models.SeatEvent{Type: models.SeatEventSnapshot, SeatMap: &seatMap}); err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case u, ok := <-updates:
+			if !ok {
+				return nil, fmt.Errorf("fell behind on seat updates; reconnect for a fresh seat map")
+			}
+
+			if err := ctx.WriteMessageToSocket(models.SeatEvent{Type: models.SeatEventUpdate, Update: &u}); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// GetETA handles GET /bus/{id}/eta?stop=X.
+func (h *Handler) GetETA(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := ctx.Param("stop")
+	if stop == "" {
+		return nil, badRequest("invalid_parameter", "stop is required")
+	}
+
+	bus, err := h.store.GetBusByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	pos, ok := h.hub.Latest(id)
+	if !ok {
+		return nil, notFound("location_not_found", "no live location has been reported for bus %d", id)
+	}
+
+	routeStops, err := h.store.GetRouteStops(ctx, bus.Route.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Match the stop however the caller cased or spaced it.
+	if i := stopIndex(routeStops, stop); i >= 0 {
+		stop = routeStops[i].Name
+	}
+
+	stops, err := h.etaStops(ctx, bus, routeStops)
+	if err != nil {
+		return nil, err
+	}
+
+	speed := h.speed(bus)
+
+	est, err := eta.Calculate(geo.Point{Lat: pos.Lat, Lng: pos.Lng}, stops, stop, speed)
+	if errors.Is(err, eta.ErrUnknownStop) {
+		return nil, notFound("stop_not_on_route", "stop %q is not on the route of bus %d", stop, id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !est.Upcoming {
+		return models.StopPassed{
+			BusID:   id,
+			Stop:    stop,
+			Message: translatef(ctx, "bus %d has already passed %s on its current trip", id, stop),
+		}, nil
+	}
+
+	return models.ETA{
+		BusID:      id,
+		Stop:       stop,
+		Upcoming:   true,
+		DistanceKm: est.DistanceMeters / 1000,
+		SpeedKmh:   speed,
+		ETAMinutes: est.Duration.Minutes(),
+		AsOf:       pos.Timestamp,
+	}, nil
+}
+
+// etaStops returns routeStops, the stops of bus's route, with their
+// coordinates and the distances between them. A stop without coordinates is
+// a 422, since the route cannot be measured past it.
+func (h *Handler) etaStops(ctx *gofr.Context, bus models.Bus, routeStops []models.RouteStop) ([]eta.Stop, error) {
+	stops := make([]eta.Stop, 0, len(routeStops))
+
+	for _, rs := range routeStops {
+		if rs.Lat == nil || rs.Lng == nil {
+			return nil, unprocessable("stop_without_coordinates",
+				"stop %q on route %s has no coordinates", rs.Name, bus.Route.Name)
+		}
+
+		stops = append(stops, eta.Stop{Name: rs.Name, Point: geo.Point{Lat: *rs.Lat, Lng: *rs.Lng}})
+	}
+
+	for i := 1; i < len(stops); i++ {
+		d, err := h.distances.DistanceBetween(ctx, routeStops[i-1].StopID, routeStops[i].StopID)
+		if err != nil && !errors.Is(err, stopdist.ErrNoCoordinates) {
+			return nil, err
+		}
+
+		stops[i-1].ToNext = d
+	}
+
+	return stops, nil
+}
+
+// speed is how fast bus is taken to travel: its own average speed, or the
+// default for buses without one.
+func (h *Handler) speed(bus models.Bus) float64 {
+	if bus.AvgSpeedKmh != nil && *bus.AvgSpeedKmh > 0 {
+		return *bus.AvgSpeedKmh
+	}
+
+	return h.cfg.DefaultSpeedKmh
+}
+
+// defaultNearbyRadius and maxNearbyRadius bound the radius, in meters, of
+// GET /buses/nearby.
+const (
+	defaultNearbyRadius = 1000.0
+	maxNearbyRadius     = 50000.0
+)
+
+// NearbyBuses handles GET /buses/nearby?lat=...&lng=...&radius=..., listing
+// the buses whose latest position is within radius meters, nearest first.
+func (h *Handler) NearbyBuses(ctx *gofr.Context) (interface{}, error) {
+	lat, err := floatParam(ctx, "lat", -90, 90)
+	if err != nil {
+		return nil, err
+	}
+
+	lng, err := floatParam(ctx, "lng", -180, 180)
+	if err != nil {
+		return nil, err
+	}
+
+	radius := defaultNearbyRadius
+
+	if ctx.Param("radius") != "" {
+		radius, err = floatParam(ctx, "radius", 0, maxNearbyRadius)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	origin := geo.Point{Lat: lat, Lng: lng}
+	nearby := []models.NearbyBus{}
+	now := time.Now()
+
+	for _, l := range h.hub.LiveAll() {
+		if d := geo.Distance(origin, geo.Point{Lat: l.Lat, Lng: l.Lng}); d <= radius {
+			nearby = append(nearby, models.NearbyBus{LiveLocation: h.signal(l, now), DistanceMeters: d})
+		}
+	}
+
+	sort.Slice(nearby, func(i, j int) bool { return nearby[i].DistanceMeters < nearby[j].DistanceMeters })
+
+	return nearby, nil
+}
+
+// defaultActiveWithin and maxActiveWithin bound the window, in minutes, a
+// bus must have reported within to be listed by GET /buses/active.
+const (
+	defaultActiveWithin = 10
+	maxActiveWithin     = 24 * 60
+)
+
+// ActiveBuses handles GET /buses/active?within=N&route=ID, listing the buses
+// that reported a position in the last N minutes, by ID, each with its
+// occupancy today in its time zone and its next stop.
+func (h *Handler) ActiveBuses(ctx *gofr.Context) (interface{}, error) {
+	within := defaultActiveWithin
+
+	if v := ctx.Param("within"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxActiveWithin {
+			return nil, badRequest("invalid_parameter", "within must be a number of minutes between 1 and %d", maxActiveWithin)
+		}
+
+		within = n
+	}
+
+	var routeID int
+
+	if v := ctx.Param("route"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, badRequest("invalid_parameter", "route must be a positive integer")
+		}
+
+		routeID = n
+	}
+
+	now := time.Now()
+	since := now.Add(-time.Duration(within) * time.Minute)
+	routes := make(map[int][]eta.Stop)
+	active := []models.ActiveBus{}
+
+	for _, l := range h.hub.LiveAll() {
+		if l.LastSeen.Before(since) {
+			continue
+		}
+
+		bus, err := h.store.GetBusByID(ctx, l.BusID)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if routeID != 0 && bus.Route.ID != routeID {
+			continue
+		}
+
+		y, m, d := now.In(bus.Location()).Date()
+		day := time.Date(y, m, d, 0, 0, 0, 0, bus.Location())
+
+		booked, err := h.store.CountBookedSeats(ctx, bus.ID, day, day.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+
+		stops, ok := routes[bus.Route.ID]
+		if !ok {
+			if stops, err = h.routePoints(ctx, bus.Route.ID); err != nil {
+				return nil, err
+			}
+
+			routes[bus.Route.ID] = stops
+		}
+
+		a := models.ActiveBus{
+			LiveLocation: h.signal(l, now),
+			RouteID:      bus.Route.ID,
+			RouteName:    bus.Route.Name,
+			Capacity:     bus.Capacity,
+			BookedSeats:  booked,
+			Occupancy:    models.OccupancyPercent(booked, bus.Capacity),
+		}
+
+		if len(stops) > 0 {
+			next := stops[eta.NextStop(geo.Point{Lat: l.Lat, Lng: l.Lng}, stops)].Name
+			a.NextStop = &next
+		}
+
+		active = append(active, a)
+	}
+
+	sort.Slice(active, func(i, j int) bool { return active[i].BusID < active[j].BusID })
+
+	return active, nil
+}
+
+// routePoints returns the stops of a route placed for eta, or none if any
+// of them has no coordinates.
+func (h *Handler) routePoints(ctx *gofr.Context, routeID int) ([]eta.Stop, error) {
+	routeStops, err := h.store.GetRouteStops(ctx, routeID)
+	if err != nil {
+		return nil, err
+	}
+
+	stops := make([]eta.Stop, 0, len(routeStops))
+
+	for _, rs := range routeStops {
+		if rs.Lat == nil || rs.Lng == nil {
+			return nil, nil
+		}
+
+		stops = append(stops, eta.Stop{Name: rs.Name, Point: geo.Point{Lat: *rs.Lat, Lng: *rs.Lng}})
+	}
+
+	return stops, nil
+}
+
+// floatParam parses the required query parameter name as a number in
+// [min, max].
+func floatParam(ctx *gofr.Context, name string, min, max float64) (float64, error) {
+	raw := ctx.Param(name)
+	if raw == "" {
+		return 0, badRequest("invalid_parameter", "%s is required", name)
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(v) || v < min || v > max {
+		return 0, badRequest("invalid_parameter", "%s must be a number between %g and %g", name, min, max)
+	}
+
+	return v, nil
+}
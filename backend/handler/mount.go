@@ -0,0 +1,20 @@
+package handler
+
+import "net/http"
+
+// Mount is middleware that serves route ("METHOD /path") with h directly,
+// bypassing gofr. It is for the few endpoints, such as streaming exports,
+// whose responses cannot be returned as a single value. Install it after
+// the auth middleware so h sees the authenticated user.
+func Mount(route string, h http.HandlerFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method+" "+r.URL.Path != route {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h(w, r)
+		})
+	}
+}
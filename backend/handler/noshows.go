@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// GetNoShows handles GET /buses/{id}/no-shows?date=YYYY-MM-DD, the seats on
+// the bus's departure that day, or today, in its time zone, whose riders
+// never boarded.
+func (h *Handler) GetNoShows(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bus, err := h.store.GetBusByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	day, err := dayParam(ctx, bus.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	noShows, err := h.store.GetNoShows(ctx, id, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.NoShowReport{BusID: id, Date: day.Format(dateLayout), Total: len(noShows), NoShows: noShows}
+
+	for _, n := range noShows {
+		if n.Released {
+			report.Released++
+		}
+	}
+
+	return report, nil
+}
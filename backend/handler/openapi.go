@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"github.com/abhinav/gofr"
+	"github.com/abhinav/gofr/http/response"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/openapi"
+)
+
+// OpenAPI serves the document in spec as-is, without gofr's data envelope.
+func OpenAPI(spec *openapi.Spec) gofr.Handler {
+	return func(ctx *gofr.Context) (interface{}, error) {
+		return response.Raw{Data: spec.Document()}, nil
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/notify"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/payment"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/requestlog"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/validation"
+)
+
+// maxWebhookBody bounds the payment provider's webhook bodies.
+const maxWebhookBody = 64 << 10
+
+// CreatePaymentIntent handles POST /payments/intent, pricing the seats of
+// the user's hold and returning the client secret to pay for them with.
+// Asking again while the hold's payment is pending returns that payment
+// with a 200.
+func (h *Handler) CreatePaymentIntent(ctx *gofr.Context) (interface{}, error) {
+	var req models.PaymentIntentRequest
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	booking := models.Booking{HoldToken: req.HoldToken, DiscountCode: req.DiscountCode}
+	if err := h.applyHold(ctx, &booking); err != nil {
+		return nil, err
+	}
+
+	t, err := h.prepareTicket(ctx, booking)
+	if err != nil {
+		return nil, err
+	}
+
+	intentID, secret, err := payment.NewIntent()
+	if err != nil {
+		return nil, err
+	}
+
+	p, created, err := h.store.CreatePayment(ctx, models.Payment{
+		IntentID:        intentID,
+		ClientSecret:    secret,
+		UserID:          t.UserID,
+		HoldToken:       req.HoldToken,
+		Amount:          t.Fare,
+		Discount:        t.Discount,
+		DiscountCode:    t.DiscountCode,
+		SurgeMultiplier: t.SurgeMultiplier,
+	})
+
+	switch {
+	case errors.Is(err, store.ErrHoldNotFound):
+		return nil, notFound("hold_not_found", "seat hold not found")
+	case errors.Is(err, store.ErrHoldExpired):
+		return nil, conflict("hold_expired", "seat hold has expired")
+	case err != nil:
+		return nil, err
+	}
+
+	if !created {
+		setStatus(ctx, http.StatusOK)
+	}
+
+	return p, nil
+}
+
+// PaymentWebhook handles POST /payments/webhook from the payment provider,
+// booking the held seats of a successful payment and releasing those of a
+// failed one. Events are applied once however often they are retried. It is
+// served through Mount because the signature covers the raw body.
+func (h *Handler) PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		apierror.Write(w, badRequest("invalid_body", "reading request body: %v", err))
+		return
+	}
+
+	if err := h.webhooks.Verify(r.Header.Get(payment.SignatureHeader), body, time.Now()); err != nil {
+		apierror.Write(w, unauthorized("invalid_signature", "%v", err))
+		return
+	}
+
+	var e models.PaymentEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		apierror.Write(w, badRequest("invalid_body", "invalid request body: %v", err))
+		return
+	}
+
+	if verr := validation.Struct(&e); verr != nil {
+		apierror.Write(w, verr)
+		return
+	}
+
+	p, applied, err := h.store.ApplyPaymentEvent(ctx, e)
+	if errors.Is(err, store.ErrNotFound) {
+		apierror.Write(w, notFound("payment_not_found", "payment intent %q not found", e.IntentID))
+		return
+	} else if err != nil {
+		apierror.Write(w, err)
+		return
+	}
+
+	logger := requestlog.Logger(ctx).With(slog.String("intent_id", p.IntentID), slog.String("event_id", e.ID))
+
+	switch {
+	case applied && p.Status == models.PaymentSucceeded:
+		h.cfg.Metrics.Booked(1)
+		logger.InfoContext(ctx, "payment confirmed", slog.Int("ticket_id", *p.TicketID))
+
+		if ticket, err := h.store.GetTicket(ctx, *p.TicketID); err == nil {
+			h.cfg.Occupancy.Refresh(ctx, ticket)
+			notify.Async(logger, h.notifier, ticket)
+			h.cfg.Webhooks.Publish(ctx, models.EventBookingCreated, ticket)
+		}
+	case applied && e.Type == models.EventPaymentSucceeded:
+		h.cfg.Metrics.BookingFailed()
+		h.cfg.Waitlist.Kick()
+		logger.WarnContext(ctx, "payment succeeded but its seats could not be booked; it needs refunding",
+			slog.String("reason", p.FailureReason))
+	case applied:
+		h.cfg.Waitlist.Kick()
+		logger.InfoContext(ctx, "payment failed", slog.String("reason", p.FailureReason))
+	case e.Type == models.EventPaymentSucceeded && p.Status != models.PaymentSucceeded:
+		// Most likely the hold expired before the provider took the money.
+		logger.WarnContext(ctx, "payment succeeded after it was settled; it needs refunding",
+			slog.String("status", p.Status))
+	}
+
+	p.ClientSecret = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/notify"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/pricing"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/requestlog"
+)
+
+// BookReturnTrip handles POST /tickets/book/return, booking a ticket each
+// way, or neither if either cannot be booked. Both fares are reduced by
+// Config.ReturnDiscountPercent.
+func (h *Handler) BookReturnTrip(ctx *gofr.Context) (interface{}, error) {
+	var req models.ReturnBooking
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	if req.Outbound.HoldToken != "" || req.Inbound.HoldToken != "" {
+		return nil, badRequest("invalid_body", "hold tokens cannot be used in round-trip bookings")
+	}
+
+	ts := make([]models.Ticket, 0, 2)
+
+	for i, leg := range []models.Booking{req.Outbound, req.Inbound} {
+		t, err := h.prepareTicket(ctx, leg)
+		if err != nil {
+			return models.BulkFailure{Index: i, Reason: err.Error()}, err
+		}
+
+		ts = append(ts, t)
+	}
+
+	if ts[1].TravelDate.Before(ts[0].TravelDate) {
+		return nil, badRequest("inbound_before_outbound", "the inbound leg departs before the outbound leg")
+	}
+
+	var trip models.ReturnTrip
+
+	for i := range ts {
+		off := pricing.PercentOff(ts[i].Fare, h.cfg.ReturnDiscountPercent)
+		ts[i].Discount += off
+		ts[i].Fare -= off
+		trip.ReturnDiscount += off
+	}
+
+	out, in, err := h.store.CreateReturnTrip(ctx, ts[0], ts[1])
+	if err != nil {
+		h.cfg.Metrics.BookingFailed()
+		return bulkFailure(err, ts, "leg")
+	}
+
+	h.cfg.Metrics.Booked(2)
+	h.cfg.Occupancy.Refresh(ctx, out, in)
+
+	notify.Async(requestlog.Logger(ctx), h.notifier, out, in)
+
+	for _, t := range []models.Ticket{out, in} {
+		h.cfg.Webhooks.Publish(ctx, models.EventBookingCreated, t)
+	}
+
+	trip.Outbound, trip.Inbound = out, in
+	trip.TotalFare = out.Fare + in.Fare
+
+	return trip, nil
+}
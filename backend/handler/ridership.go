@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/ridership"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// GetStopCounts handles GET /buses/{id}/stop-counts?date=YYYY-MM-DD, how many
+// riders boarded and alighted at each stop of the bus's route on its
+// departure that day, or today, in its time zone.
+func (h *Handler) GetStopCounts(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bus, err := h.store.GetBusByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	day, err := dayParam(ctx, bus.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	route, err := h.store.GetRouteStops(ctx, bus.Route.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rides, err := h.store.GetRides(ctx, id, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := ridership.Count(route, rides)
+	counts.BusID, counts.Date = id, day.Format(dateLayout)
+
+	return counts, nil
+}
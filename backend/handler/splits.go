@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// GetTicket handles GET /tickets/{id}, the ticket with how its fare is split
+// and whether it is fully paid. Its booker, the co-riders it is split with
+// and staff may see it.
+func (h *Handler) GetTicket(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := h.store.GetTicket(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	splits, err := h.store.GetFareSplits(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := auth.UserID(ctx)
+	allowed := ticket.UserID == userID || auth.Role(ctx) == models.RoleAdmin || auth.Role(ctx) == models.RoleConductor
+
+	for _, sp := range splits {
+		allowed = allowed || sp.UserID == userID
+	}
+
+	if !allowed {
+		return nil, forbidden("not_owner", "ticket %d belongs to another user", id)
+	}
+
+	return models.TicketDetail{Ticket: ticket, Splits: splits, Paid: models.SplitsPaid(splits)}, nil
+}
+
+// SplitFare handles POST /tickets/{id}/split, sharing the fare of the
+// caller's ticket among co-riders. Each owes their portion until they settle
+// it; the booker's own portion is taken as paid.
+func (h *Handler) SplitFare(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.SplitRequest
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	ticket, err := h.store.GetTicket(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if userID, _ := auth.UserID(ctx); ticket.UserID != userID {
+		return nil, forbidden("not_owner", "ticket %d belongs to another user", id)
+	}
+
+	splits, err := h.store.SplitFare(ctx, id, req.Portions)
+
+	var (
+		totalErr   *store.SplitTotalError
+		unknownErr *store.UnknownUsersError
+	)
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	case errors.Is(err, store.ErrTicketCancelled):
+		return nil, conflict("ticket_cancelled", "ticket %d has been cancelled", id)
+	case errors.Is(err, store.ErrSplitSettled):
+		return nil, conflict("split_settled", "%v", err)
+	case errors.As(err, &totalErr):
+		aerr := apierror.New(apierror.ErrValidation, "split_total_mismatch",
+			"portions add up to %s but the fare is %s", totalErr.Total.Display(), totalErr.Fare.Display())
+		aerr.Details = map[string]interface{}{"fare": totalErr.Fare, "total": totalErr.Total}
+
+		return nil, aerr
+	case errors.As(err, &unknownErr):
+		return nil, notFound("user_not_found", "users %v not found", unknownErr.UserIDs)
+	case err != nil:
+		return nil, err
+	}
+
+	setStatus(ctx, http.StatusOK)
+
+	return models.TicketDetail{Ticket: ticket, Splits: splits, Paid: models.SplitsPaid(splits)}, nil
+}
+
+// SettleFareSplit handles POST /tickets/{id}/split/settle, recording that
+// the caller has paid their share of the ticket's fare. Settling again
+// changes nothing.
+func (h *Handler) SettleFareSplit(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := auth.UserID(ctx)
+
+	split, err := h.store.SettleFareSplit(ctx, id, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("split_not_found", "you have no share of the fare of ticket %d", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	setStatus(ctx, http.StatusOK)
+
+	return split, nil
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/notify"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/requestlog"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// SubstituteBus handles POST /buses/{id}/substitute, moving the upcoming
+// bookings of a bus that has broken down onto a replacement running the
+// same route. Each ticket gets the seats laid out most like its own; those
+// the replacement has no room for are left in place and listed for staff to
+// resolve. Riders whose tickets moved are sent a fresh confirmation.
+func (h *Handler) SubstituteBus(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.BusSubstitution
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	if req.ReplacementBusID == id {
+		return nil, badRequest("same_bus", "bus %d cannot replace itself", id)
+	}
+
+	bus, err := h.store.GetBusByID(ctx, id)
+
+	switch {
+	case errors.Is(err, store.ErrBusDeleted):
+		return nil, gone("bus_out_of_service", "bus %d is no longer in service", id)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	case err != nil:
+		return nil, err
+	}
+
+	replacement, err := h.store.GetBusByID(ctx, req.ReplacementBusID)
+
+	switch {
+	case errors.Is(err, store.ErrBusDeleted):
+		return nil, gone("bus_out_of_service", "bus %d is no longer in service", req.ReplacementBusID)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, badRequest("replacement_not_found", "bus %d not found", req.ReplacementBusID)
+	case err != nil:
+		return nil, err
+	}
+
+	if replacement.Route.ID != bus.Route.ID {
+		return nil, conflict("route_mismatch", "bus %d runs route %d, not route %d",
+			replacement.ID, replacement.Route.ID, bus.Route.ID)
+	}
+
+	from, to := time.Now(), time.Time{}
+	if req.Date != "" {
+		from, _ = time.ParseInLocation(dateLayout, req.Date, bus.Location())
+		to = from.AddDate(0, 0, 1)
+	}
+
+	report, moved, err := h.store.SubstituteBus(ctx, id, replacement.ID, from, to)
+
+	switch {
+	case errors.Is(err, store.ErrBusDeleted):
+		return nil, gone("bus_out_of_service", "bus %d or %d is no longer in service", id, replacement.ID)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("bus_not_found", "bus %d or %d not found", id, replacement.ID)
+	case err != nil:
+		return nil, err
+	}
+
+	// Both the departures the tickets left and those they joined are
+	// recounted.
+	left := make([]models.Ticket, len(moved))
+	for i, t := range moved {
+		left[i] = t
+		left[i].BusID = id
+
+		h.cfg.Webhooks.Publish(ctx, models.EventTicketChanged, t)
+	}
+
+	h.cfg.Occupancy.Refresh(ctx, append(left, moved...)...)
+	h.cfg.Waitlist.Kick()
+	notify.Async(requestlog.Logger(ctx), h.notifier, moved...)
+
+	if n := len(report.Unmapped); n > 0 {
+		report.Warning = fmt.Sprintf("%d ticket(s) could not be given seats on bus %d and stay on bus %d",
+			n, replacement.ID, id)
+	}
+
+	setStatus(ctx, http.StatusOK)
+
+	return report, nil
+}
@@ -0,0 +1,673 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/abhinav/gofr"
+	"github.com/abhinav/gofr/http/response"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/notify"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/pricing"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/requestlog"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/ticketqr"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/validation"
+)
+
+// maxIdempotencyKeyLen bounds the Idempotency-Key header we are willing to store.
+const maxIdempotencyKeyLen = 255
+
+// BookTicket handles POST /tickets/book. A request repeating the
+// Idempotency-Key header of an earlier booking by the same user, within
+// store.IdempotencyKeyTTL, gets that booking back with a 200 instead of a
+// second ticket.
+func (h *Handler) BookTicket(ctx *gofr.Context) (interface{}, error) {
+	var req models.Booking
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	key := requestHeader(ctx, "Idempotency-Key")
+	if len(key) > maxIdempotencyKeyLen {
+		return nil, badRequest("invalid_header", "Idempotency-Key must be at most %d characters", maxIdempotencyKeyLen)
+	}
+
+	if req.HoldToken != "" {
+		if err := h.applyHold(ctx, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := h.prepareTicket(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.IdempotencyKey = key
+
+	ticket, created, err := h.store.CreateTicket(ctx, t)
+	if err != nil {
+		h.cfg.Metrics.BookingFailed()
+	}
+
+	var (
+		unavailable *store.SeatsUnavailableError
+		notEnough   *store.NotEnoughSeatsError
+		overLimit   *store.SeatLimitError
+	)
+
+	switch {
+	case errors.Is(err, store.ErrHoldNotFound):
+		return nil, notFound("hold_not_found", "seat hold not found")
+	case errors.Is(err, store.ErrHoldExpired):
+		return nil, conflict("hold_expired", "seat hold has expired")
+	case errors.Is(err, store.ErrDiscountUnavailable):
+		return nil, badRequest("discount_unavailable", "discount code %q is no longer available", t.DiscountCode)
+	case errors.Is(err, store.ErrBusDeleted):
+		return nil, gone("bus_out_of_service", "bus %d is no longer in service", req.BusID)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("bus_not_found", "bus %d not found", req.BusID)
+	case errors.As(err, &unavailable):
+		body := models.SeatConflict{UnavailableSeats: unavailable.Seats}
+		if unavailable.Full {
+			body.Waitlist = waitlistPath
+		}
+
+		return body, seatsUnavailable(unavailable)
+	case errors.As(err, &notEnough):
+		return nil, conflict("not_enough_seats", "%v", notEnough)
+	case errors.As(err, &overLimit):
+		return nil, seatLimitExceeded(overLimit, "%v", overLimit)
+	case err != nil:
+		return nil, err
+	}
+
+	if !created {
+		setStatus(ctx, http.StatusOK)
+	} else {
+		h.cfg.Metrics.Booked(1)
+		h.cfg.Occupancy.Refresh(ctx, ticket)
+		h.cfg.Webhooks.Publish(ctx, models.EventBookingCreated, ticket)
+
+		logger := requestlog.Logger(ctx)
+		logger.InfoContext(ctx, "ticket booked",
+			slog.Int("ticket_id", ticket.ID), slog.Int("bus_id", ticket.BusID), slog.Int("seats", len(ticket.SeatNumbers)))
+		notify.Async(logger, h.notifier, ticket)
+	}
+
+	ticket.Message = translatef(ctx, "booked %d seat(s) on bus %d", len(ticket.SeatNumbers), ticket.BusID)
+
+	return ticket, nil
+}
+
+// maxBulkBookings bounds how many bookings one bulk request may carry.
+const maxBulkBookings = 50
+
+// BookTicketsBulk handles POST /tickets/book/bulk. The body is an array of
+// bookings that are created together or not at all.
+func (h *Handler) BookTicketsBulk(ctx *gofr.Context) (interface{}, error) {
+	var reqs []models.Booking
+	if err := ctx.Bind(&reqs); err != nil {
+		return nil, badRequest("invalid_body", "invalid request body: %v", err)
+	}
+
+	switch {
+	case len(reqs) == 0:
+		return nil, badRequest("invalid_body", "at least one booking is required")
+	case len(reqs) > maxBulkBookings:
+		return nil, badRequest("invalid_body", "at most %d bookings may be made at once", maxBulkBookings)
+	}
+
+	for i := range reqs {
+		if verr := validation.Struct(&reqs[i]); verr != nil {
+			verr = verr.Prefix(fmt.Sprintf("[%d].", i))
+			return verr.Body, verr
+		}
+
+		if reqs[i].HoldToken != "" {
+			return nil, badRequest("invalid_body", "booking %d: hold tokens cannot be used in bulk bookings", i)
+		}
+	}
+
+	ts := make([]models.Ticket, 0, len(reqs))
+
+	for i, req := range reqs {
+		t, err := h.prepareTicket(ctx, req)
+		if err != nil {
+			return models.BulkFailure{Index: i, Reason: err.Error()}, err
+		}
+
+		ts = append(ts, t)
+	}
+
+	tickets, err := h.store.CreateTickets(ctx, ts)
+	if err != nil {
+		h.cfg.Metrics.BookingFailed()
+	} else {
+		h.cfg.Metrics.Booked(len(tickets))
+		h.cfg.Occupancy.Refresh(ctx, tickets...)
+	}
+
+	if err != nil {
+		return bulkFailure(err, ts, "booking")
+	}
+
+	notify.Async(requestlog.Logger(ctx), h.notifier, tickets...)
+
+	for _, t := range tickets {
+		h.cfg.Webhooks.Publish(ctx, models.EventBookingCreated, t)
+	}
+
+	ids := make([]int, 0, len(tickets))
+	for _, t := range tickets {
+		ids = append(ids, t.ID)
+	}
+
+	return models.BulkBooking{TicketIDs: ids, Tickets: tickets}, nil
+}
+
+// bulkFailure maps an error from booking ts all together to the response
+// naming the failing entry, which messages call entry ("booking", "leg").
+func bulkFailure(err error, ts []models.Ticket, entry string) (interface{}, error) {
+	var (
+		bulkErr     *store.BulkError
+		unavailable *store.SeatsUnavailableError
+		notEnough   *store.NotEnoughSeatsError
+		overLimit   *store.SeatLimitError
+	)
+
+	switch {
+	case errors.As(err, &bulkErr) && errors.As(err, &unavailable):
+		return models.BulkFailure{Index: bulkErr.Index, Reason: unavailable.Error(), UnavailableSeats: unavailable.Seats},
+			conflict("seats_unavailable", "%s %d: %v", entry, bulkErr.Index, unavailable)
+	case errors.As(err, &bulkErr) && errors.As(err, &notEnough):
+		return models.BulkFailure{Index: bulkErr.Index, Reason: notEnough.Error()},
+			conflict("not_enough_seats", "%s %d: %v", entry, bulkErr.Index, notEnough)
+	case errors.As(err, &bulkErr) && errors.As(err, &overLimit):
+		return models.BulkFailure{Index: bulkErr.Index, Reason: overLimit.Error()},
+			seatLimitExceeded(overLimit, "%s %d: %v", entry, bulkErr.Index, overLimit)
+	case errors.As(err, &bulkErr) && errors.Is(err, store.ErrDiscountUnavailable):
+		return models.BulkFailure{Index: bulkErr.Index, Reason: store.ErrDiscountUnavailable.Error()},
+			badRequest("discount_unavailable", "%s %d: discount code %q is no longer available", entry, bulkErr.Index, ts[bulkErr.Index].DiscountCode)
+	case errors.As(err, &bulkErr) && errors.Is(err, store.ErrBusDeleted):
+		return models.BulkFailure{Index: bulkErr.Index, Reason: "bus is no longer in service"},
+			gone("bus_out_of_service", "%s %d: bus %d is no longer in service", entry, bulkErr.Index, ts[bulkErr.Index].BusID)
+	case errors.As(err, &bulkErr) && errors.Is(err, store.ErrNotFound):
+		return models.BulkFailure{Index: bulkErr.Index, Reason: "bus not found"},
+			notFound("bus_not_found", "%s %d: bus %d not found", entry, bulkErr.Index, ts[bulkErr.Index].BusID)
+	}
+
+	return nil, err
+}
+
+// seatLimitExceeded is the 422 returned for limit, giving the seats the
+// user already holds and the most they may.
+func seatLimitExceeded(limit *store.SeatLimitError, format string, args ...interface{}) error {
+	err := apierror.New(apierror.ErrUnprocessable, "seat_limit_exceeded", format, args...)
+	err.Details = map[string]interface{}{"booked_seats": limit.Booked, "max_seats": limit.Max}
+
+	return err
+}
+
+// prepareTicket checks an already validated booking on behalf of the
+// authenticated user and prices the ticket it asks for, taking off any
+// discount code it gives. Fares are raised by the surge multiplier of the
+// departure as it stands now, which the ticket keeps whatever happens to
+// occupancy later.
+func (h *Handler) prepareTicket(ctx *gofr.Context, req models.Booking) (models.Ticket, error) {
+	userID, _ := auth.UserID(ctx)
+	if req.UserID == 0 {
+		req.UserID = userID
+	} else if req.UserID != userID {
+		return models.Ticket{}, forbidden("not_owner", "cannot book tickets for another user")
+	}
+
+	bus, err := h.store.GetBusByID(ctx, req.BusID)
+
+	switch {
+	case errors.Is(err, store.ErrBusDeleted):
+		return models.Ticket{}, gone("bus_out_of_service", "bus %d is no longer in service", req.BusID)
+	case errors.Is(err, store.ErrNotFound):
+		return models.Ticket{}, notFound("bus_not_found", "bus %d not found", req.BusID)
+	case err != nil:
+		return models.Ticket{}, err
+	}
+
+	loc := bus.Location()
+	if req.Timezone != "" {
+		loc, _ = time.LoadLocation(req.Timezone)
+	}
+
+	seatFare, err := h.seatFare(ctx, bus)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	t := req.Ticket(loc)
+	t.SeatLimit = h.cfg.MaxSeatsPerUser
+
+	if err := checkRuns(bus, t.TravelDate); err != nil {
+		return models.Ticket{}, err
+	}
+
+	if err := h.checkCutoff(bus, t.TravelDate); err != nil {
+		return models.Ticket{}, err
+	}
+
+	t.Timezone = bus.Timezone
+	seats := len(t.SeatNumbers)
+	if seats == 0 {
+		seats = t.SeatCount
+	}
+
+	if n := len(t.Passengers); n > 0 && n != seats {
+		return models.Ticket{}, badRequest("passengers_mismatch", "%d passengers named for %d seats", n, seats)
+	}
+
+	t.SurgeMultiplier, err = h.surgeMultiplier(ctx, bus, t.TravelDate)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	t.BaseFare = seatFare * money.Money(seats)
+	t.Fare = pricing.Surge(t.BaseFare, t.SurgeMultiplier)
+	t.OriginalFare = t.Fare
+
+	if t.DiscountCode != "" {
+		if err := h.applyDiscount(ctx, &t); err != nil {
+			return models.Ticket{}, err
+		}
+	}
+
+	return t, nil
+}
+
+// ValidateTicket handles POST /tickets/validate, given the payload scanned
+// from a ticket's QR code. Scanning a ticket again is safe: it is reported
+// as already validated, with when and by whom, rather than as valid.
+func (h *Handler) ValidateTicket(ctx *gofr.Context) (interface{}, error) {
+	var req models.Validation
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	scanned, err := h.qr.Verify(req.Payload)
+	if err != nil {
+		h.cfg.Metrics.Validated(false)
+		return nil, badRequest("invalid_ticket_code", "%v", err)
+	}
+
+	id := scanned.TicketID
+	conductorID, _ := auth.UserID(ctx)
+
+	result, err := h.store.ValidateTicket(ctx, id, scanned.Version, conductorID, req.DeviceID)
+	h.cfg.Metrics.Validated(err == nil && result.Valid)
+
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !result.Revoked {
+		result.Passengers = scanned.Passengers
+	}
+
+	if result.Valid {
+		h.cfg.Webhooks.Publish(ctx, models.EventTicketValidated, result)
+	}
+
+	switch {
+	case result.Revoked:
+		result.Message = translate(ctx, "code has been replaced by a newer one")
+	case result.Valid:
+		result.Message = translate(ctx, "ticket is valid")
+	case result.AlreadyValidated && result.ValidatedAt != nil:
+		result.Message = translatef(ctx, "already validated at %s", result.ValidatedAt.Format(clockLayout))
+	case result.AlreadyValidated:
+		result.Message = translate(ctx, "already validated")
+	default:
+		result.Message = translatef(ctx, "ticket is %s", result.Status)
+	}
+
+	return result, nil
+}
+
+// BoardTicket handles POST /tickets/{id}/board, recording that the riders of
+// a validated ticket's seats got on, and where the bus was. Boarding a seat
+// again keeps its first boarding, so a repeated request is harmless.
+func (h *Handler) BoardTicket(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.Boarding
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	ticket, err := h.store.GetTicket(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	if req.BoardedAt.IsZero() {
+		req.BoardedAt = now
+	} else if req.BoardedAt.After(now.Add(maxClockSkew)) {
+		return nil, badRequest("invalid_body", "boarded_at is in the future")
+	}
+
+	if req.Lat == nil {
+		if pos, ok := h.hub.Latest(ticket.BusID); ok {
+			req.Lat, req.Lng = &pos.Lat, &pos.Lng
+		}
+	}
+
+	conductorID, _ := auth.UserID(ctx)
+
+	result, err := h.store.BoardSeats(ctx, id, conductorID, req)
+
+	var notOnTicket *store.SeatsNotOnTicketError
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	case errors.Is(err, store.ErrTicketCancelled):
+		return nil, conflict("ticket_cancelled", "ticket %d has been cancelled", id)
+	case errors.Is(err, store.ErrTicketNotValidated):
+		return nil, conflict("ticket_not_validated", "ticket %d has not been validated; validate it before boarding", id)
+	case errors.As(err, &notOnTicket):
+		return nil, badRequest("seats_not_on_ticket", "%v", notOnTicket)
+	case err != nil:
+		return nil, err
+	}
+
+	if len(result.Boarded) > 0 {
+		h.cfg.Occupancy.Refresh(ctx, ticket)
+	}
+
+	setStatus(ctx, http.StatusOK)
+
+	return result, nil
+}
+
+// GetTicketQR handles GET /tickets/{id}/qr, returning a PNG QR code of a
+// signed payload for the ticket that POST /tickets/validate accepts.
+func (h *Handler) GetTicketQR(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := h.store.GetTicket(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if userID, _ := auth.UserID(ctx); ticket.UserID != userID {
+		return nil, forbidden("not_owner", "ticket %d belongs to another user", id)
+	}
+
+	if ticket.Status == models.StatusCancelled {
+		return nil, conflict("ticket_cancelled", "ticket %d has been cancelled", id)
+	}
+
+	payload, err := h.qr.Sign(id, ticket.QRVersion, ticket.Passengers)
+	if err != nil {
+		return nil, err
+	}
+
+	png, err := ticketqr.PNG(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.File{Content: png, ContentType: "image/png"}, nil
+}
+
+// CancelTicket handles POST /tickets/{id}/cancel, refunding according to
+// pricing.RefundPolicy. With with_return=true, the other leg of a round
+// trip is cancelled along with it, unless it has been used or cancelled
+// already.
+func (h *Handler) CancelTicket(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := h.store.GetTicket(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if userID, _ := auth.UserID(ctx); ticket.UserID != userID {
+		return nil, forbidden("not_owner", "ticket %d belongs to another user", id)
+	}
+
+	var other models.Ticket
+
+	if ctx.Param("with_return") == "true" {
+		ticket, other, err = h.store.CancelReturnTrip(ctx, id)
+	} else {
+		ticket, err = h.store.CancelTicket(ctx, id)
+	}
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	case errors.Is(err, store.ErrTicketCancelled):
+		return nil, conflict("ticket_cancelled", "%v", err)
+	case errors.Is(err, store.ErrTicketUsed):
+		return nil, conflict("ticket_used", "%v", err)
+	case err != nil:
+		return nil, err
+	}
+
+	cancelled := []models.Ticket{ticket}
+	if other.ID != 0 {
+		cancelled = append(cancelled, other)
+	}
+
+	for _, t := range cancelled {
+		h.cfg.Metrics.Cancelled()
+		h.cfg.Webhooks.Publish(ctx, models.EventTicketCancelled, t)
+	}
+
+	h.cfg.Occupancy.Refresh(ctx, cancelled...)
+	h.cfg.Waitlist.Kick()
+
+	c := refund(ctx, ticket)
+	if other.ID != 0 {
+		r := refund(ctx, other)
+		c.Return = &r
+	}
+
+	return c, nil
+}
+
+// refund is the Cancellation of the cancelled ticket t.
+func refund(ctx *gofr.Context, t models.Ticket) models.Cancellation {
+	amount, reason := pricing.RefundPolicy(t.Fare, t.TravelDate.Sub(*t.CancelledAt))
+
+	return models.Cancellation{Ticket: t, RefundAmount: amount, RefundReason: translate(ctx, reason)}
+}
+
+// CancelSeats handles POST /tickets/{id}/cancel-seats, giving up some of a
+// ticket's seats. The refund follows pricing.RefundPolicy on the fare those
+// seats carried; cancelling every seat cancels the ticket.
+func (h *Handler) CancelSeats(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.SeatCancellation
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	ticket, err := h.store.GetTicket(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if userID, _ := auth.UserID(ctx); ticket.UserID != userID {
+		return nil, forbidden("not_owner", "ticket %d belongs to another user", id)
+	}
+
+	ticket, released, err := h.store.CancelSeats(ctx, id, req.SeatNumbers)
+
+	var notOnTicket *store.SeatsNotOnTicketError
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	case errors.Is(err, store.ErrTicketCancelled):
+		return nil, conflict("ticket_cancelled", "%v", err)
+	case errors.Is(err, store.ErrTicketUsed):
+		return nil, conflict("ticket_used", "%v", err)
+	case errors.As(err, &notOnTicket):
+		return nil, badRequest("seats_not_on_ticket", "%v", notOnTicket)
+	case errors.Is(err, store.ErrTicketSplit):
+		return nil, conflict("ticket_split", "the fare of ticket %d is split among its riders; cancel the whole ticket instead", id)
+	case err != nil:
+		return nil, err
+	}
+
+	if ticket.Status == models.StatusCancelled {
+		h.cfg.Metrics.Cancelled()
+		h.cfg.Webhooks.Publish(ctx, models.EventTicketCancelled, ticket)
+	}
+
+	h.cfg.Occupancy.Refresh(ctx, ticket)
+	h.cfg.Waitlist.Kick()
+
+	amount, reason := pricing.RefundPolicy(released, time.Until(ticket.TravelDate))
+
+	return models.Cancellation{Ticket: ticket, CancelledSeats: req.SeatNumbers, RefundAmount: amount, RefundReason: translate(ctx, reason)}, nil
+}
+
+// ChangeTicket handles POST /tickets/{id}/change, moving a ticket to other
+// seats, another bus or another travel date in one step. The new selection
+// is priced like a new booking; if it cannot be had the ticket is left as
+// it was.
+func (h *Handler) ChangeTicket(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.TicketChange
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	ticket, err := h.store.GetTicket(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if userID, _ := auth.UserID(ctx); ticket.UserID != userID {
+		return nil, forbidden("not_owner", "ticket %d belongs to another user", id)
+	}
+
+	if !ticket.TravelDate.After(time.Now()) {
+		return nil, conflict("ticket_departed", "the bus of ticket %d has already departed", id)
+	}
+
+	booking := models.Booking{
+		BusID:       ticket.BusID,
+		SeatNumbers: ticket.SeatNumbers,
+		TravelDate:  ticket.TravelDate.Format(time.RFC3339),
+		Timezone:    req.Timezone,
+		Passengers:  ticket.Passengers,
+	}
+
+	if req.BusID != 0 {
+		booking.BusID = req.BusID
+	}
+
+	if len(req.SeatNumbers) > 0 {
+		booking.SeatNumbers = req.SeatNumbers
+	}
+
+	if req.TravelDate != "" {
+		booking.TravelDate = req.TravelDate
+	}
+
+	// The ticket's passengers can only move seat for seat; for any other
+	// number of seats the rider has to say who rides them.
+	switch {
+	case len(req.Passengers) > 0:
+		booking.Passengers = req.Passengers
+	case len(ticket.Passengers) > 0 && len(booking.SeatNumbers) != len(ticket.SeatNumbers):
+		return nil, badRequest("passengers_required",
+			"ticket %d names %d passengers; name one for each of the %d seats to change to",
+			id, len(ticket.Passengers), len(booking.SeatNumbers))
+	}
+
+	to, err := h.prepareTicket(ctx, booking)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := h.store.ChangeTicket(ctx, id, to)
+
+	var (
+		unavailable *store.SeatsUnavailableError
+		overLimit   *store.SeatLimitError
+	)
+
+	switch {
+	case errors.Is(err, store.ErrTicketCancelled):
+		return nil, conflict("ticket_cancelled", "%v", err)
+	case errors.Is(err, store.ErrTicketUsed):
+		return nil, conflict("ticket_used", "%v", err)
+	case errors.Is(err, store.ErrTicketSplit):
+		return nil, conflict("ticket_split", "the fare of ticket %d is split among its riders; it cannot be changed", id)
+	case errors.Is(err, store.ErrBusDeleted):
+		return nil, gone("bus_out_of_service", "bus %d is no longer in service", booking.BusID)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	case errors.As(err, &unavailable):
+		body := models.SeatConflict{UnavailableSeats: unavailable.Seats}
+		if unavailable.Full {
+			body.Waitlist = waitlistPath
+		}
+
+		return body, seatsUnavailable(unavailable)
+	case errors.As(err, &overLimit):
+		return nil, seatLimitExceeded(overLimit, "%v", overLimit)
+	case err != nil:
+		return nil, err
+	}
+
+	h.cfg.Occupancy.Refresh(ctx, ticket, changed)
+	h.cfg.Waitlist.Kick()
+	h.cfg.Webhooks.Publish(ctx, models.EventTicketChanged, changed)
+
+	setStatus(ctx, http.StatusOK)
+
+	return models.TicketChangeResult{Ticket: changed, FareDelta: changed.Fare - ticket.Fare}, nil
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/notify"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/requestlog"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// TransferTicket handles POST /tickets/{id}/transfer, giving a ticket that
+// has not been used to another user, named by ID or email. The ticket's QR
+// codes printed so far stop validating; the new owner is sent a
+// confirmation and gets a fresh code from GET /tickets/{id}/qr.
+func (h *Handler) TransferTicket(ctx *gofr.Context) (interface{}, error) {
+	if h.cfg.DisableTransfers {
+		return nil, forbidden("transfers_disabled", "ticket transfers are disabled")
+	}
+
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.TicketTransfer
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	ticket, err := h.store.GetTicket(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	userID, _ := auth.UserID(ctx)
+	if ticket.UserID != userID {
+		return nil, forbidden("not_owner", "ticket %d belongs to another user", id)
+	}
+
+	if !ticket.TravelDate.After(time.Now()) {
+		return nil, conflict("ticket_departed", "the bus of ticket %d has already departed", id)
+	}
+
+	var to models.User
+
+	if req.Email != "" {
+		to, err = h.store.GetUserByEmail(ctx, req.Email)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, notFound("user_not_found", "no user is registered with email %s", req.Email)
+		}
+	} else {
+		to, err = h.store.GetUserByID(ctx, req.UserID)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, notFound("user_not_found", "user %d not found", req.UserID)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if to.ID == userID {
+		return nil, badRequest("transfer_to_self", "ticket %d is already yours", id)
+	}
+
+	transferred, err := h.store.TransferTicket(ctx, id, userID, to.ID)
+
+	switch {
+	case errors.Is(err, store.ErrNotTicketOwner):
+		return nil, forbidden("not_owner", "ticket %d belongs to another user", id)
+	case errors.Is(err, store.ErrTicketCancelled):
+		return nil, conflict("ticket_cancelled", "%v", err)
+	case errors.Is(err, store.ErrTicketUsed):
+		return nil, conflict("ticket_used", "%v", err)
+	case errors.Is(err, store.ErrTicketSplit):
+		return nil, conflict("ticket_split", "the fare of ticket %d is split among its riders; it cannot be transferred", id)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("ticket_not_found", "ticket %d not found", id)
+	case err != nil:
+		return nil, err
+	}
+
+	notify.Async(requestlog.Logger(ctx), h.notifier, transferred)
+	h.cfg.Webhooks.Publish(ctx, models.EventTicketTransferred, transferred)
+
+	setStatus(ctx, http.StatusOK)
+
+	return models.TicketTransferResult{Ticket: transferred, FromUserID: userID}, nil
+}
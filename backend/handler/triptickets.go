@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// GetTripTickets handles GET /buses/{id}/tickets?status=...&date=YYYY-MM-DD,
+// the tickets for a bus's departure on that day, or today, with a count of
+// them by status. The summary always covers the whole departure; status
+// only narrows the tickets listed.
+func (h *Handler) GetTripTickets(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := ctx.Param("status")
+	switch status {
+	case "", models.StatusBooked, models.StatusValidated, models.StatusBoarded, models.StatusCancelled:
+	default:
+		return nil, badRequest("invalid_parameter", "status %q is not supported; use %s, %s, %s or %s", status,
+			models.StatusBooked, models.StatusValidated, models.StatusBoarded, models.StatusCancelled)
+	}
+
+	bus, err := h.store.GetBusByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("bus_not_found", "bus %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	day, err := dayParam(ctx, bus.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	tickets, err := h.store.GetTripTickets(ctx, id, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	trip := models.TripTickets{BusID: id, Date: day.Format(dateLayout), Status: status, Tickets: []models.Ticket{}}
+
+	for _, t := range tickets {
+		trip.Summary.Total++
+
+		switch t.Status {
+		case models.StatusBooked:
+			trip.Summary.Booked++
+		case models.StatusValidated:
+			trip.Summary.Validated++
+		case models.StatusBoarded:
+			trip.Summary.Boarded++
+		case models.StatusCancelled:
+			trip.Summary.Cancelled++
+		}
+
+		if status == "" || t.Status == status {
+			trip.Tickets = append(trip.Tickets, t)
+		}
+	}
+
+	return trip, nil
+}
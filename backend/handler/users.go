@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/abhinav/gofr"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// Login handles POST /auth/login, exchanging credentials for a bearer token.
+func (h *Handler) Login(ctx *gofr.Context) (interface{}, error) {
+	var req models.Login
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	invalid := unauthorized("invalid_credentials", "invalid email or password")
+
+	user, err := h.store.GetUserByEmail(ctx, normalizeEmail(req.Email))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, invalid
+	} else if err != nil {
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		return nil, invalid
+	}
+
+	token, expires, err := h.tokens.Issue(user.ID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.Token{AccessToken: token, TokenType: "bearer", ExpiresAt: expires}, nil
+}
+
+// CreateUser handles POST /users, registering a new account.
+func (h *Handler) CreateUser(ctx *gofr.Context) (interface{}, error) {
+	var req models.Registration
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.store.CreateUser(ctx, models.User{
+		Name:         strings.TrimSpace(req.Name),
+		Email:        normalizeEmail(req.Email),
+		PasswordHash: string(hash),
+	})
+	if errors.Is(err, store.ErrEmailTaken) {
+		return nil, conflict("email_taken", "email %s is already registered", req.Email)
+	}
+
+	return user, err
+}
+
+// normalizeEmail lowercases email so that registration and login agree on
+// which account an address belongs to.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ListUsers handles GET /users, for admins only.
+func (h *Handler) ListUsers(ctx *gofr.Context) (interface{}, error) {
+	page, err := parsePage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users, total, err := h.store.GetUsers(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return pageResponse{Data: users, Total: total, Limit: page.Limit, Offset: page.Offset}, nil
+}
+
+// minUserSearch is the shortest query GET /users/search accepts, so that a
+// search cannot list every user.
+const minUserSearch = 2
+
+// SearchUsers handles GET /users/search?q=..., the users whose name or email
+// starts with q, ignoring case.
+func (h *Handler) SearchUsers(ctx *gofr.Context) (interface{}, error) {
+	q := strings.TrimSpace(ctx.Param("q"))
+	if utf8.RuneCountInString(q) < minUserSearch {
+		return nil, badRequest("invalid_parameter", "q must be at least %d characters", minUserSearch)
+	}
+
+	page, err := parsePage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users, total, err := h.store.SearchUsers(ctx, q, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return pageResponse{Data: users, Total: total, Limit: page.Limit, Offset: page.Offset}, nil
+}
+
+// ListUserTickets handles GET /users/{id}/tickets?status=upcoming|past|all,
+// the user's booking history. Riders may only see their own; admins may see
+// anyone's.
+func (h *Handler) ListUserTickets(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID, _ := auth.UserID(ctx); userID != id && auth.Role(ctx) != models.RoleAdmin {
+		return nil, forbidden("not_owner", "you may only view your own tickets")
+	}
+
+	when := ctx.Param("status")
+
+	switch when {
+	case "":
+		when = store.TicketsAll
+	case store.TicketsUpcoming, store.TicketsPast, store.TicketsAll:
+	default:
+		return nil, badRequest("invalid_parameter", "status %q is not supported; use upcoming, past or all", when)
+	}
+
+	page, err := parsePage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := h.store.GetUserByID(ctx, id); errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("user_not_found", "user %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	trips, total, err := h.store.GetUserTickets(ctx, id, when, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return pageResponse{Data: trips, Total: total, Limit: page.Limit, Offset: page.Offset}, nil
+}
+
+// GetUser handles GET /users/{id}. Users may only see their own profile;
+// admins may see anyone's.
+func (h *Handler) GetUser(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID, _ := auth.UserID(ctx); userID != id && auth.Role(ctx) != models.RoleAdmin {
+		return nil, forbidden("not_owner", "you may only view your own profile")
+	}
+
+	user, err := h.store.GetUserByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("user_not_found", "user %d not found", id)
+	}
+
+	return user, err
+}
+
+// UpdateUser handles PATCH /users/{id}, changing only the profile fields the
+// body gives. Users may only update their own profile; admins may update
+// anyone's.
+func (h *Handler) UpdateUser(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID, _ := auth.UserID(ctx); userID != id && auth.Role(ctx) != models.RoleAdmin {
+		return nil, forbidden("not_owner", "you may only update your own profile")
+	}
+
+	var req models.ProfileUpdate
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+
+	if req.Email != nil {
+		email := normalizeEmail(*req.Email)
+		req.Email = &email
+	}
+
+	user, err := h.store.UpdateUser(ctx, id, req)
+
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("user_not_found", "user %d not found", id)
+	case errors.Is(err, store.ErrEmailTaken):
+		return nil, conflict("email_taken", "email %s is already registered", *req.Email)
+	}
+
+	return user, err
+}
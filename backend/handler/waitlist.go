@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// waitlistPath is where a rider turned away by a full bus can queue instead.
+const waitlistPath = "/v1/tickets/waitlist"
+
+// JoinWaitlist handles POST /tickets/waitlist, queueing the user for seats on
+// a bus and travel date. The entry is booked, and the user notified, once
+// enough seats free up for everyone ahead of it and then for it.
+func (h *Handler) JoinWaitlist(ctx *gofr.Context) (interface{}, error) {
+	var req models.WaitlistRequest
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	travel, _ := time.Parse(time.RFC3339, req.TravelDate)
+
+	bus, err := h.store.GetBusByID(ctx, req.BusID)
+
+	switch {
+	case errors.Is(err, store.ErrBusDeleted):
+		return nil, gone("bus_out_of_service", "bus %d is no longer in service", req.BusID)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("bus_not_found", "bus %d not found", req.BusID)
+	case err != nil:
+		return nil, err
+	}
+
+	if err := checkRuns(bus, travel); err != nil {
+		return nil, err
+	}
+
+	userID, _ := auth.UserID(ctx)
+
+	entry, err := h.store.CreateWaitlistEntry(ctx, models.WaitlistEntry{
+		UserID:     userID,
+		BusID:      req.BusID,
+		Seats:      req.Seats,
+		TravelDate: travel,
+	})
+
+	switch {
+	case errors.Is(err, store.ErrBusDeleted):
+		return nil, gone("bus_out_of_service", "bus %d is no longer in service", req.BusID)
+	case errors.Is(err, store.ErrNotFound):
+		return nil, notFound("bus_not_found", "bus %d not found", req.BusID)
+	case errors.Is(err, store.ErrExceedsCapacity):
+		return nil, badRequest("seats_exceed_capacity", "bus %d does not have %d seats", req.BusID, req.Seats)
+	case err != nil:
+		return nil, err
+	}
+
+	// Seats may already be free; let the promoter take them straight away.
+	h.cfg.Waitlist.Kick()
+
+	return entry, nil
+}
+
+// GetWaitlistEntry handles GET /tickets/waitlist/{id}, reporting the entry's
+// position while it waits and its ticket once promoted.
+func (h *Handler) GetWaitlistEntry(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := h.store.GetWaitlistEntry(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("waitlist_entry_not_found", "waitlist entry %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if userID, _ := auth.UserID(ctx); entry.UserID != userID {
+		return nil, forbidden("not_owner", "waitlist entry %d belongs to another user", id)
+	}
+
+	return entry, nil
+}
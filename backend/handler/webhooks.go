@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/webhook"
+)
+
+// CreateWebhookSubscription handles POST /webhooks/subscriptions,
+// subscribing a URL to booking events. The response carries the secret
+// deliveries are signed with, which is not shown again.
+func (h *Handler) CreateWebhookSubscription(ctx *gofr.Context) (interface{}, error) {
+	var req models.NewWebhookSubscription
+	if body, err := bind(ctx, &req); err != nil {
+		return body, err
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := auth.UserID(ctx)
+
+	return h.store.CreateWebhookSubscription(ctx, models.WebhookSubscription{
+		URL: req.URL, Events: req.Events, Secret: secret, CreatedBy: userID,
+	})
+}
+
+// DeleteWebhookSubscription handles DELETE /webhooks/subscriptions/{id}.
+// Deliveries still pending are not sent.
+func (h *Handler) DeleteWebhookSubscription(ctx *gofr.Context) (interface{}, error) {
+	id, err := pathID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.store.DeleteWebhookSubscription(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, notFound("subscription_not_found", "webhook subscription %d not found", id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	setStatus(ctx, http.StatusNoContent)
+
+	return nil, nil
+}
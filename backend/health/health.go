@@ -0,0 +1,152 @@
+// Package health checks the service's dependencies and summarises them into
+// a single status a load balancer can act on.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// Overall and per-dependency statuses.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+
+	DependencyUp   = "up"
+	DependencyDown = "down"
+)
+
+// DefaultTimeout bounds each dependency check when a Checker sets none.
+const DefaultTimeout = 2 * time.Second
+
+// Dependency is something the service talks to. A failing Critical
+// dependency takes the service down; any other failure only degrades it.
+// State, if set, describes the dependency beyond up or down, such as the
+// state of the circuit breaker in front of it.
+type Dependency struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) error
+	State    func() string
+}
+
+// DependencyStatus is the outcome of checking one Dependency.
+type DependencyStatus struct {
+	Status         string  `json:"status"`
+	ResponseTimeMs float64 `json:"response_time_ms"`
+	State          string  `json:"state,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// Report is the result of checking every dependency.
+type Report struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+	DBPool       *PoolStats                  `json:"db_pool,omitempty"`
+}
+
+// PoolStats is a snapshot of the database connection pool.
+type PoolStats struct {
+	MaxOpen           int     `json:"max_open"`
+	Open              int     `json:"open"`
+	InUse             int     `json:"in_use"`
+	Idle              int     `json:"idle"`
+	WaitCount         int64   `json:"wait_count"`
+	WaitDurationMs    float64 `json:"wait_duration_ms"`
+	MaxIdleClosed     int64   `json:"max_idle_closed"`
+	MaxLifetimeClosed int64   `json:"max_lifetime_closed"`
+}
+
+// Checker runs dependency checks concurrently, each under Timeout. When
+// DBStats is set its pool statistics are reported too.
+type Checker struct {
+	Dependencies []Dependency
+	Timeout      time.Duration
+	DBStats      func() sql.DBStats
+}
+
+// Run checks every dependency and reports StatusDown if a critical one failed,
+// StatusDegraded if any other did and StatusOK otherwise.
+func (c *Checker) Run(ctx context.Context) Report {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	statuses := make([]DependencyStatus, len(c.Dependencies))
+
+	var wg sync.WaitGroup
+
+	for i, d := range c.Dependencies {
+		wg.Add(1)
+
+		go func(i int, d Dependency) {
+			defer wg.Done()
+
+			statuses[i] = check(ctx, d, timeout)
+		}(i, d)
+	}
+
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Dependencies: make(map[string]DependencyStatus, len(statuses))}
+
+	for i, d := range c.Dependencies {
+		s := statuses[i]
+		report.Dependencies[d.Name] = s
+
+		if s.Status == DependencyUp {
+			continue
+		}
+
+		if d.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	if c.DBStats != nil {
+		s := c.DBStats()
+		report.DBPool = &PoolStats{
+			MaxOpen:           s.MaxOpenConnections,
+			Open:              s.OpenConnections,
+			InUse:             s.InUse,
+			Idle:              s.Idle,
+			WaitCount:         s.WaitCount,
+			WaitDurationMs:    float64(s.WaitDuration.Microseconds()) / 1000,
+			MaxIdleClosed:     s.MaxIdleClosed,
+			MaxLifetimeClosed: s.MaxLifetimeClosed,
+		}
+	}
+
+	return report
+}
+
+func check(ctx context.Context, d Dependency, timeout time.Duration) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := d.Check(ctx)
+	elapsed := time.Since(start)
+
+	s := DependencyStatus{
+		Status:         DependencyUp,
+		ResponseTimeMs: float64(elapsed.Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		s.Status = DependencyDown
+		s.Error = err.Error()
+	}
+
+	if d.State != nil {
+		s.State = d.State()
+	}
+
+	return s
+}
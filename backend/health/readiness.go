@@ -0,0 +1,87 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Readiness statuses.
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+// ReadyReport is the result of checking whether the service may take
+// traffic: warm-up tasks still Pending, and the critical dependencies.
+type ReadyReport struct {
+	Status       string                      `json:"status"`
+	Pending      []string                    `json:"pending,omitempty"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// Readiness tracks the warm-up tasks the service must finish before it is
+// ready for traffic.
+type Readiness struct {
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+// NewReadiness returns a Readiness waiting on every one of tasks.
+func NewReadiness(tasks ...string) *Readiness {
+	pending := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		pending[t] = true
+	}
+
+	return &Readiness{pending: pending}
+}
+
+// Done marks task finished.
+func (r *Readiness) Done(task string) {
+	r.mu.Lock()
+	delete(r.pending, task)
+	r.mu.Unlock()
+}
+
+// Pending returns the tasks not yet finished, in name order.
+func (r *Readiness) Pending() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tasks := make([]string, 0, len(r.pending))
+	for t := range r.pending {
+		tasks = append(tasks, t)
+	}
+
+	sort.Strings(tasks)
+
+	return tasks
+}
+
+// Ready reports StatusReady once every task of r is done and every critical
+// dependency of c is up, and StatusNotReady until then. Other dependencies
+// are not checked: a degraded service still takes traffic.
+func (c *Checker) Ready(ctx context.Context, r *Readiness) ReadyReport {
+	critical := Checker{Timeout: c.Timeout}
+
+	for _, d := range c.Dependencies {
+		if d.Critical {
+			critical.Dependencies = append(critical.Dependencies, d)
+		}
+	}
+
+	report := ReadyReport{Status: StatusReady, Pending: r.Pending(), Dependencies: critical.Run(ctx).Dependencies}
+
+	if len(report.Pending) > 0 {
+		report.Status = StatusNotReady
+	}
+
+	for _, s := range report.Dependencies {
+		if s.Status != DependencyUp {
+			report.Status = StatusNotReady
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,45 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// RedisPing returns a check that sends PING to the Redis server at addr and
+// expects PONG. It speaks the wire protocol directly so the service does not
+// need a Redis client just to report on the cache.
+func RedisPing(addr string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if deadline, ok := ctx.Deadline(); ok {
+			if err := conn.SetDeadline(deadline); err != nil {
+				return err
+			}
+		}
+
+		if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+			return err
+		}
+
+		reply, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return err
+		}
+
+		if reply = strings.TrimSpace(reply); reply != "+PONG" {
+			return fmt.Errorf("unexpected reply to PING: %q", reply)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package i18n
+
+// catalogs holds the translations of each supported language but English,
+// keyed by the English message or format they replace.
+var catalogs = map[string]map[string]string{
+	"hi": hindi,
+}
+
+var hindi = map[string]string{
+	// Bookings.
+	"booked %d seat(s) on bus %d":                                   "बस %[2]d पर %[1]d सीट(ें) बुक की गईं",
+	"bus %d not found":                                              "बस %d नहीं मिली",
+	"bus %d is no longer in service":                                "बस %d अब सेवा में नहीं है",
+	"bus is fully booked":                                           "बस पूरी तरह बुक हो चुकी है",
+	"seats %v are not available":                                    "सीटें %v उपलब्ध नहीं हैं",
+	"seat hold not found":                                           "सीट होल्ड नहीं मिला",
+	"seat hold has expired":                                         "सीट होल्ड की अवधि समाप्त हो गई है",
+	"discount code %q is no longer available":                       "छूट कोड %q अब उपलब्ध नहीं है",
+	"bookings for bus %d close %d minutes before it departs at %s":  "बस %[1]d की बुकिंग %[3]s पर प्रस्थान से %[2]d मिनट पहले बंद हो जाती है",
+	"bus %d has already passed %s on its current trip":              "बस %d अपनी मौजूदा यात्रा में %s से आगे निकल चुकी है",
+	"too many requests; retry in %d seconds":                        "बहुत अधिक अनुरोध; %d सेकंड बाद फिर से प्रयास करें",
+	"ticket %d not found":                                           "टिकट %d नहीं मिला",
+	"ticket %d has been cancelled":                                  "टिकट %d रद्द किया जा चुका है",
+	"ticket %d belongs to another user":                             "टिकट %d किसी अन्य उपयोगकर्ता का है",
+	"you may only update your own profile":                          "आप केवल अपनी प्रोफ़ाइल बदल सकते हैं",
+	"you may only view your own tickets":                            "आप केवल अपने टिकट देख सकते हैं",
+	"ticket is valid":                                               "टिकट मान्य है",
+	"already validated":                                             "पहले ही सत्यापित किया जा चुका है",
+	"ticket is %s":                                                  "टिकट %s है",
+	"code has been replaced by a newer one":                         "इस कोड की जगह एक नया कोड जारी किया जा चुका है",
+	"already validated at %s":                                       "%s पर पहले ही सत्यापित किया जा चुका है",
+	"the bus has already departed; no refund is due":                "बस प्रस्थान कर चुकी है; कोई धनवापसी देय नहीं है",
+	"cancelled more than 24 hours before departure; full refund":    "प्रस्थान से 24 घंटे से अधिक पहले रद्द किया गया; पूरी धनवापसी",
+	"cancelled between 2 and 24 hours before departure; 50% refund": "प्रस्थान से 2 से 24 घंटे पहले रद्द किया गया; 50% धनवापसी",
+	"cancelled less than 2 hours before departure; no refund":       "प्रस्थान से 2 घंटे से कम पहले रद्द किया गया; कोई धनवापसी नहीं",
+	"a valid bearer token is required":                              "एक मान्य बेयरर टोकन आवश्यक है",
+	"invalid email or password":                                     "ईमेल या पासवर्ड गलत है",
+	"email %s is already registered":                                "ईमेल %s पहले से पंजीकृत है",
+	"%d passengers named for %d seats":                              "%[2]d सीटों के लिए %[1]d यात्रियों के नाम दिए गए",
+	"stop %q is not on the route of bus %d":                         "स्टॉप %q बस %d के मार्ग पर नहीं है",
+	"the %s role is required; you are signed in as %s":              "%s भूमिका आवश्यक है; आप %s के रूप में साइन इन हैं",
+
+	// Validation.
+	"invalid request: %s":                            "अमान्य अनुरोध: %s",
+	"is required":                                    "आवश्यक है",
+	"must not be empty":                              "खाली नहीं होना चाहिए",
+	"must not contain control characters":            "नियंत्रण वर्ण नहीं होने चाहिए",
+	"must be at least %s":                            "कम से कम %s होना चाहिए",
+	"must be greater than %s":                        "%s से अधिक होना चाहिए",
+	"must be at most %s":                             "अधिकतम %s होना चाहिए",
+	"must be at least %s characters":                 "कम से कम %s अक्षरों का होना चाहिए",
+	"must be at most %s characters":                  "अधिकतम %s अक्षरों का होना चाहिए",
+	"must contain at least %s items":                 "इसमें कम से कम %s आइटम होने चाहिए",
+	"must contain at most %s items":                  "इसमें अधिकतम %s आइटम होने चाहिए",
+	"must be one of: %s":                             "इनमें से एक होना चाहिए: %s",
+	"must not contain duplicates":                    "इसमें दोहराव नहीं होना चाहिए",
+	"must be a valid email address":                  "एक मान्य ईमेल पता होना चाहिए",
+	"must be formatted as %s":                        "%s के प्रारूप में होना चाहिए",
+	"must be a phone number such as +919876543210":   "+919876543210 जैसा फ़ोन नंबर होना चाहिए",
+	"must be an http or https URL":                   "एक http या https URL होना चाहिए",
+	"must be an IANA time zone such as Asia/Kolkata": "Asia/Kolkata जैसा IANA समय क्षेत्र होना चाहिए",
+}
@@ -0,0 +1,58 @@
+// Package i18n translates the messages riders are shown. Messages are looked
+// up by their English text, or format for Sprintf, so a message without a
+// translation, or a request for a language without a catalog, falls back
+// to English.
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// English is the language messages are written in, and the fallback.
+const English = "en"
+
+// Negotiate picks the supported language an Accept-Language header prefers
+// most, matching on the primary subtag so that "hi-IN" gets Hindi. An empty
+// or unmatched header gets English.
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := English, 0.0
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+
+			q = parsed
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+
+		if _, ok := catalogs[primary]; (ok || primary == English) && q > bestQ {
+			best, bestQ = primary, q
+		}
+	}
+
+	return best
+}
+
+// Translate returns msg in lang, or msg itself if there is no translation.
+func Translate(lang, msg string) string {
+	if t, ok := catalogs[lang][msg]; ok {
+		return t
+	}
+
+	return msg
+}
+
+// Sprintf formats args with the translation of format into lang. A
+// translation may reorder its verbs with explicit argument indexes.
+func Sprintf(lang, format string, args ...interface{}) string {
+	return fmt.Sprintf(Translate(lang, format), args...)
+}
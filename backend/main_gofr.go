@@ -1,71 +1,877 @@
 package main
 
 import (
-	"github.com/abhinav/gofr"
+	"context"
+	"database/sql"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	// Bus time zones must resolve even on hosts without a zoneinfo database.
+	_ "time/tzdata"
+
+	"github.com/abhinav/gofr"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/bodylimit"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/breaker"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/cors"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/delay"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/geo"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/geofence"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/handler"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/health"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/metrics"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/migrations"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/noshow"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/notify"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/openapi"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/pricing"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/ratelimit"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/reaper"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/requestlog"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/stopdist"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/timeout"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/tracking"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/waitlist"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/webhook"
 )
 
+// apiV1 prefixes every route of version 1 of the API. Health checks and
+// metrics scrapes stay unversioned, so probes need not follow API versions.
+const apiV1 = "/v1"
+
 func main() {
 	app := gofr.New()
 
-	app.GET("/", func(ctx *gofr.Context) (interface{}, error) {
-		return "Welcome to Gofr backend!", nil
-	})
+	secret := app.Config.Get("JWT_SECRET")
+	if secret == "" {
+		app.Logger().Fatal("JWT_SECRET must be set")
+	}
 
-	app.GET("/health", func(ctx *gofr.Context) (interface{}, error) {
-		return map[string]string{"status": "ok"}, nil
-	})
+	qrKey := app.Config.Get("QR_SIGNING_KEY")
+	if qrKey == "" {
+		app.Logger().Fatal("QR_SIGNING_KEY must be set")
+	}
+
+	webhookSecret := app.Config.Get("PAYMENT_WEBHOOK_SECRET")
+	if webhookSecret == "" {
+		app.Logger().Fatal("PAYMENT_WEBHOOK_SECRET must be set")
+	}
+
+	tokenTTL, err := time.ParseDuration(app.Config.GetOrDefault("JWT_TTL", "24h"))
+	if err != nil {
+		app.Logger().Fatalf("invalid JWT_TTL: %v", err)
+	}
+
+	defaultSpeed, err := strconv.ParseFloat(app.Config.GetOrDefault("BUS_DEFAULT_SPEED_KMH", "25"), 64)
+	if err != nil || defaultSpeed <= 0 {
+		app.Logger().Fatalf("BUS_DEFAULT_SPEED_KMH must be a positive number")
+	}
+
+	bookingLimit, err := strconv.Atoi(app.Config.GetOrDefault("BOOKING_RATE_LIMIT", "10"))
+	if err != nil || bookingLimit < 1 {
+		app.Logger().Fatalf("BOOKING_RATE_LIMIT must be a positive integer")
+	}
+
+	bookingWindow, err := time.ParseDuration(app.Config.GetOrDefault("BOOKING_RATE_WINDOW", "1m"))
+	if err != nil {
+		app.Logger().Fatalf("invalid BOOKING_RATE_WINDOW: %v", err)
+	}
+
+	drainTimeout, err := time.ParseDuration(app.Config.GetOrDefault("SHUTDOWN_DRAIN_TIMEOUT", "30s"))
+	if err != nil {
+		app.Logger().Fatalf("invalid SHUTDOWN_DRAIN_TIMEOUT: %v", err)
+	}
+
+	// Exports stream a whole day of bookings, so they get longer than other
+	// requests. 0 disables either timeout.
+	requestTimeout, err := time.ParseDuration(app.Config.GetOrDefault("REQUEST_TIMEOUT", "30s"))
+	if err != nil || requestTimeout < 0 {
+		app.Logger().Fatalf("REQUEST_TIMEOUT must be a non-negative duration")
+	}
+
+	maxBodyBytes, err := strconv.ParseInt(
+		app.Config.GetOrDefault("MAX_BODY_BYTES", strconv.Itoa(bodylimit.DefaultMaxBytes)), 10, 64)
+	if err != nil || maxBodyBytes < 1 {
+		app.Logger().Fatalf("MAX_BODY_BYTES must be a positive integer")
+	}
+
+	exportTimeout, err := time.ParseDuration(app.Config.GetOrDefault("EXPORT_TIMEOUT", "5m"))
+	if err != nil || exportTimeout < 0 {
+		app.Logger().Fatalf("EXPORT_TIMEOUT must be a non-negative duration")
+	}
+
+	holdTTL, err := time.ParseDuration(app.Config.GetOrDefault("SEAT_HOLD_TTL", "10m"))
+	if err != nil || holdTTL <= 0 {
+		app.Logger().Fatalf("SEAT_HOLD_TTL must be a positive duration")
+	}
+
+	locationStaleAfter, err := time.ParseDuration(app.Config.GetOrDefault("LOCATION_STALE_AFTER", "2m"))
+	if err != nil || locationStaleAfter < 0 {
+		app.Logger().Fatalf("LOCATION_STALE_AFTER must be a non-negative duration")
+	}
+
+	holdReapInterval, err := time.ParseDuration(app.Config.GetOrDefault("SEAT_HOLD_REAP_INTERVAL", "1m"))
+	if err != nil || holdReapInterval <= 0 {
+		app.Logger().Fatalf("SEAT_HOLD_REAP_INTERVAL must be a positive duration")
+	}
+
+	bookingCutoff, err := time.ParseDuration(app.Config.GetOrDefault("BOOKING_CUTOFF", "15m"))
+	if err != nil || bookingCutoff < 0 {
+		app.Logger().Fatalf("BOOKING_CUTOFF must be a non-negative duration")
+	}
+
+	noShowGrace, err := time.ParseDuration(app.Config.GetOrDefault("NO_SHOW_GRACE", "15m"))
+	if err != nil || noShowGrace < 0 {
+		app.Logger().Fatalf("NO_SHOW_GRACE must be a non-negative duration")
+	}
 
-	// Demo: List users
-	app.GET("/users", func(ctx *gofr.Context) (interface{}, error) {
-		users := []map[string]interface{}{
-			{"id": 1, "name": "Alice"},
-			{"id": 2, "name": "Bob"},
+	noShowInterval, err := time.ParseDuration(app.Config.GetOrDefault("NO_SHOW_INTERVAL", "1m"))
+	if err != nil || noShowInterval <= 0 {
+		app.Logger().Fatalf("NO_SHOW_INTERVAL must be a positive duration")
+	}
+
+	// Released no-show seats can be given to riders boarding further along.
+	noShowRelease := app.Config.GetOrDefault("NO_SHOW_RELEASE", "false") == "true"
+
+	// Operators that require tickets to stay with whoever booked them turn
+	// transfers off.
+	disableTransfers := app.Config.GetOrDefault("DISABLE_TICKET_TRANSFERS", "false") == "true"
+
+	positionRetention, err := time.ParseDuration(app.Config.GetOrDefault("LOCATION_RETENTION", "168h"))
+	if err != nil || positionRetention <= 0 {
+		app.Logger().Fatalf("LOCATION_RETENTION must be a positive duration")
+	}
+
+	waitlistInterval, err := time.ParseDuration(app.Config.GetOrDefault("WAITLIST_PROMOTE_INTERVAL", "30s"))
+	if err != nil || waitlistInterval <= 0 {
+		app.Logger().Fatalf("WAITLIST_PROMOTE_INTERVAL must be a positive duration")
+	}
+
+	webhookAttempts, err := strconv.Atoi(app.Config.GetOrDefault("WEBHOOK_MAX_ATTEMPTS", "8"))
+	if err != nil || webhookAttempts < 1 {
+		app.Logger().Fatalf("WEBHOOK_MAX_ATTEMPTS must be a positive integer")
+	}
+
+	webhookBackoff, err := time.ParseDuration(app.Config.GetOrDefault("WEBHOOK_RETRY_BACKOFF", "30s"))
+	if err != nil || webhookBackoff <= 0 {
+		app.Logger().Fatalf("WEBHOOK_RETRY_BACKOFF must be a positive duration")
+	}
+
+	// The bus list is cached in memory; gofr's Redis datasource is not set up
+	// for this service, so each instance keeps its own. 0 disables it.
+	busCacheTTL, err := time.ParseDuration(app.Config.GetOrDefault("BUS_CACHE_TTL", "30s"))
+	if err != nil || busCacheTTL < 0 {
+		app.Logger().Fatalf("BUS_CACHE_TTL must be a non-negative duration")
+	}
+
+	busCacheSize, err := strconv.Atoi(app.Config.GetOrDefault("BUS_CACHE_SIZE", "256"))
+	if err != nil || busCacheSize < 1 {
+		app.Logger().Fatalf("BUS_CACHE_SIZE must be a positive integer")
+	}
+
+	maxSeatsPerUser, err := strconv.Atoi(app.Config.GetOrDefault("MAX_SEATS_PER_USER", "6"))
+	if err != nil || maxSeatsPerUser < 0 {
+		app.Logger().Fatalf("MAX_SEATS_PER_USER must be a non-negative integer")
+	}
+
+	maxOpenConns, err := strconv.Atoi(
+		app.Config.GetOrDefault("DB_MAX_OPEN_CONNS", strconv.Itoa(store.DefaultPool.MaxOpenConns)))
+	if err != nil || maxOpenConns < 1 {
+		app.Logger().Fatalf("DB_MAX_OPEN_CONNS must be a positive integer")
+	}
+
+	maxIdleConns, err := strconv.Atoi(
+		app.Config.GetOrDefault("DB_MAX_IDLE_CONNS", strconv.Itoa(store.DefaultPool.MaxIdleConns)))
+	if err != nil || maxIdleConns < 0 {
+		app.Logger().Fatalf("DB_MAX_IDLE_CONNS must be a non-negative integer")
+	}
+
+	connMaxLifetime, err := time.ParseDuration(
+		app.Config.GetOrDefault("DB_CONN_MAX_LIFETIME", store.DefaultPool.ConnMaxLifetime.String()))
+	if err != nil || connMaxLifetime < 0 {
+		app.Logger().Fatalf("DB_CONN_MAX_LIFETIME must be a non-negative duration")
+	}
+
+	pool := store.PoolConfig{MaxOpenConns: maxOpenConns, MaxIdleConns: maxIdleConns, ConnMaxLifetime: connMaxLifetime}
+
+	retryAttempts, err := strconv.Atoi(
+		app.Config.GetOrDefault("DB_RETRY_MAX_ATTEMPTS", strconv.Itoa(store.DefaultRetry.MaxAttempts)))
+	if err != nil || retryAttempts < 1 {
+		app.Logger().Fatalf("DB_RETRY_MAX_ATTEMPTS must be a positive integer")
+	}
+
+	retryBackoff, err := time.ParseDuration(
+		app.Config.GetOrDefault("DB_RETRY_BACKOFF", store.DefaultRetry.Backoff.String()))
+	if err != nil || retryBackoff < 0 {
+		app.Logger().Fatalf("DB_RETRY_BACKOFF must be a non-negative duration")
+	}
+
+	retry := store.RetryConfig{MaxAttempts: retryAttempts, Backoff: retryBackoff, MaxBackoff: store.DefaultRetry.MaxBackoff}
+
+	// Reads that can stand to lag a moment behind the primary go to the
+	// replica, if one is configured. gofr registers the postgres driver it
+	// opens the primary with; the replica shares its pool limits.
+	var replica *store.Replica
+
+	if dsn := app.Config.Get("DB_REPLICA_URL"); dsn != "" {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			app.Logger().Fatalf("DB_REPLICA_URL must be a Postgres connection string")
 		}
-		return users, nil
+
+		replica = &store.Replica{DB: db, Breaker: breaker.New(breaker.DefaultThreshold, breaker.DefaultCooldown)}
+	}
+
+	debugHeaders := app.Config.GetOrDefault("DEBUG_HEADERS", "false") == "true"
+
+	// Set before anything handles an amount: every money.Money is in it.
+	currency, ok := money.Lookup(app.Config.GetOrDefault("CURRENCY", money.DefaultCurrency.Code))
+	if !ok {
+		app.Logger().Fatalf("CURRENCY must be one of INR, USD, EUR, GBP or JPY")
+	}
+
+	money.SetCurrency(currency)
+
+	fareRates := make(map[string]float64)
+
+	for _, class := range []string{pricing.ClassStandard, pricing.ClassAC, pricing.ClassSleeper} {
+		key := "FARE_PER_KM_" + strings.ToUpper(class)
+
+		raw := app.Config.Get(key)
+		if raw == "" {
+			continue
+		}
+
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate < 0 {
+			app.Logger().Fatalf("%s must be a non-negative number", key)
+		}
+
+		fareRates[class] = rate
+	}
+
+	surgeTiers, err := pricing.ParseSurgeTiers(app.Config.GetOrDefault("SURGE_TIERS", "0.7:1.2,0.9:1.5"))
+	if err != nil {
+		app.Logger().Fatalf("SURGE_TIERS: %v", err)
+	}
+
+	returnDiscount, err := strconv.ParseFloat(app.Config.GetOrDefault("RETURN_DISCOUNT_PERCENT", "0"), 64)
+	if err != nil || returnDiscount < 0 || returnDiscount > 100 {
+		app.Logger().Fatalf("RETURN_DISCOUNT_PERCENT must be a number from 0 to 100")
+	}
+
+	breakerThreshold, err := strconv.Atoi(
+		app.Config.GetOrDefault("NOTIFY_BREAKER_THRESHOLD", strconv.Itoa(breaker.DefaultThreshold)))
+	if err != nil || breakerThreshold < 1 {
+		app.Logger().Fatalf("NOTIFY_BREAKER_THRESHOLD must be a positive integer")
+	}
+
+	breakerCooldown, err := time.ParseDuration(
+		app.Config.GetOrDefault("NOTIFY_BREAKER_COOLDOWN", breaker.DefaultCooldown.String()))
+	if err != nil || breakerCooldown <= 0 {
+		app.Logger().Fatalf("NOTIFY_BREAKER_COOLDOWN must be a positive duration")
+	}
+
+	var notifier notify.Notifier
+
+	if app.Config.GetOrDefault("NOTIFY_ENABLED", "false") == "true" {
+		url := app.Config.Get("NOTIFY_WEBHOOK_URL")
+		if url == "" {
+			app.Logger().Fatal("NOTIFY_WEBHOOK_URL must be set when NOTIFY_ENABLED is true")
+		}
+
+		notifier = notify.NewWebhook(url, 10*time.Second)
+	}
+
+	// Only the admin frontend's dev server by default; set "*" to allow any
+	// origin during development.
+	corsOrigins := cors.ParseOrigins(app.Config.GetOrDefault("CORS_ALLOWED_ORIGINS", "http://localhost:3000"))
+
+	tokens := auth.NewTokens(secret, tokenTTL)
+
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(app.Config.GetOrDefault("LOG_LEVEL", "INFO"))); err != nil {
+		app.Logger().Fatalf("invalid LOG_LEVEL: %v", err)
+	}
+
+	m := metrics.New()
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+
+	// notifyBreaker trips when the notification gateway keeps failing;
+	// confirmations are then held back rather than sent.
+	var (
+		notifyBreaker *breaker.Breaker
+		guarded       *notify.Guarded
+	)
+
+	if notifier != nil {
+		notifyBreaker = breaker.New(breakerThreshold, breakerCooldown)
+		guarded = notify.NewGuarded(notifier, notifyBreaker, logger, notify.DefaultMaxDeferred)
+		notifier = guarded
+	}
+
+	app.UseMiddleware(
+		m.Middleware(),
+		requestlog.Middleware(logger),
+		handler.DebugHeaders(debugHeaders),
+		cors.Middleware(corsOrigins),
+		bodylimit.Middleware(maxBodyBytes),
+		timeout.Middleware(requestTimeout, map[string]time.Duration{"GET " + apiV1 + "/tickets/export": exportTimeout}),
+		handler.Exchange(),
+		auth.Middleware(tokens),
+		ratelimit.Middleware(ratelimit.New(bookingLimit, bookingWindow),
+			"POST "+apiV1+"/tickets/book", "POST "+apiV1+"/tickets/book/return", "POST "+apiV1+"/tickets/hold",
+			"POST "+apiV1+"/journeys/book"),
+	)
+
+	app.Migrate(migrations.All())
+
+	st := store.New(app.DB(), replica, pool, retry)
+	hub := tracking.NewHub()
+	distances := stopdist.New(st)
+	occupancy := tracking.NewOccupancy(st, hub, logger)
+	promoter := waitlist.NewPromoter(st, notifier, occupancy, logger, waitlistInterval)
+	dispatcher := webhook.New(st, logger, webhook.Config{
+		MaxAttempts: webhookAttempts,
+		Backoff:     webhookBackoff,
+		MaxBackoff:  time.Hour,
+		Timeout:     10 * time.Second,
+		Interval:    30 * time.Second,
 	})
 
-	// Demo: Get user by ID
-	app.GET("/users/{id}", func(ctx *gofr.Context) (interface{}, error) {
-		id := ctx.PathParam("id")
-		user := map[string]interface{}{"id": id, "name": "Demo User"}
-		return user, nil
+	h := handler.New(st, hub, tokens, handler.Config{
+		DefaultSpeedKmh:       defaultSpeed,
+		FareRatesPerKm:        fareRates,
+		SurgeTiers:            surgeTiers,
+		ReturnDiscountPercent: returnDiscount,
+		Notifier:              notifier,
+		Metrics:               m,
+		Waitlist:              promoter,
+		Webhooks:              dispatcher,
+		Occupancy:             occupancy,
+		Distances:             distances,
+		LocationStaleAfter:    locationStaleAfter,
+		QRSigningKey:          qrKey,
+		HoldTTL:               holdTTL,
+		BookingCutoff:         bookingCutoff,
+		MaxSeatsPerUser:       maxSeatsPerUser,
+		BusCacheTTL:           busCacheTTL,
+		BusCacheSize:          busCacheSize,
+		PaymentWebhookSecret:  webhookSecret,
+		DisableTransfers:      disableTransfers,
 	})
 
-	// Demo: List buses
-	app.GET("/buses", func(ctx *gofr.Context) (interface{}, error) {
-		buses := []map[string]interface{}{
-			{"id": 101, "route": "A-B"},
-			{"id": 102, "route": "B-C"},
+	// API keys are checked against the store, which does not exist until
+	// after the middleware above. Exports stream rows as they are read, and
+	// payment webhooks are signed over their raw bodies, neither of which
+	// gofr's handlers allow.
+	app.UseMiddleware(
+		auth.KeyMiddleware(h.LookupAPIKey),
+		handler.Mount("GET "+apiV1+"/tickets/export", h.ExportTickets),
+		handler.Mount("POST "+apiV1+"/payments/webhook", h.PaymentWebhook),
+		handler.Mount("GET /metrics", m.Handler().ServeHTTP),
+	)
+
+	// ctx is cancelled on SIGTERM or interrupt, starting the shutdown below.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	// Watch every stop with known coordinates and announce buses approaching
+	// them, recording each arrival for route delay statistics; a
+	// notification service subscribes to the same monitor.
+	stops, err := st.GetStops(ctx)
+	if err != nil {
+		app.Logger().Fatalf("loading stops for geofencing: %v", err)
+	}
+
+	var fences []geofence.Stop
+
+	for _, s := range stops {
+		if s.Lat == nil || s.Lng == nil {
+			continue
 		}
-		return buses, nil
+
+		fence := geofence.Stop{Name: s.Name, Point: geo.Point{Lat: *s.Lat, Lng: *s.Lng}}
+		if s.RadiusM != nil {
+			fence.RadiusMeters = *s.RadiusM
+		}
+
+		fences = append(fences, fence)
+	}
+
+	monitor := geofence.NewMonitor(fences)
+	locations, _ := hub.SubscribeAll()
+	approaching, _ := monitor.Subscribe()
+	arrivals, _ := monitor.Subscribe()
+
+	go monitor.Run(ctx, locations)
+	go delay.NewRecorder(st, distances, logger, defaultSpeed).Run(ctx, arrivals)
+
+	// The service is not ready for traffic until the distances fares and
+	// ETAs need are loaded; a failed load is tried again until it works.
+	readiness := health.NewReadiness("distances")
+
+	go func() {
+		for {
+			err := distances.Warm(ctx)
+			if err == nil {
+				readiness.Done("distances")
+				return
+			}
+
+			app.Logger().Errorf("warming stop distances: %v", err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}()
+
+	go func() {
+		for e := range approaching {
+			app.Logger().Infof("bus %d approaching stop %s (%.0fm away)", e.BusID, e.Stop, e.DistanceMeters)
+		}
+	}()
+
+	go promoter.Run(ctx)
+
+	// Release expired seat holds, and the payments left pending on them, until
+	// shutdown; the pool is not closed until the reaper has stopped.
+	reaped := make(chan struct{})
+
+	go func() {
+		defer close(reaped)
+		reaper.New(st, promoter, occupancy, logger, holdReapInterval).Run(ctx)
+	}()
+
+	// Flag riders who had not boarded by the end of the grace period after
+	// departure; like the reaper, it must stop before the pool closes.
+	detected := make(chan struct{})
+
+	go func() {
+		defer close(detected)
+		noshow.New(st, occupancy, logger, noshow.Config{
+			Grace: noShowGrace, Release: noShowRelease, Interval: noShowInterval,
+		}).Run(ctx)
+	}()
+
+	// Send subscribers their booking events; like the reaper, it must stop
+	// before the pool closes.
+	dispatched := make(chan struct{})
+
+	go func() {
+		defer close(dispatched)
+		dispatcher.Run(ctx)
+	}()
+
+	// Send the confirmations held back while the notification breaker was
+	// open, once it lets calls through again.
+	if guarded != nil {
+		go guarded.Run(ctx, breakerCooldown)
+	}
+
+	// Drop recorded positions once they fall out of the retention window.
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			n, err := st.PrunePositions(ctx, time.Now().Add(-positionRetention))
+			if err != nil && ctx.Err() == nil {
+				app.Logger().Errorf("pruning bus positions: %v", err)
+			} else if n > 0 {
+				app.Logger().Infof("pruned %d bus positions older than %s", n, positionRetention)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	checker := &health.Checker{
+		Dependencies: []health.Dependency{
+			{Name: "database", Critical: true, Check: app.DB().PingContext},
+		},
+		DBStats: app.DB().Stats,
+	}
+
+	if notifyBreaker != nil {
+		checker.Dependencies = append(checker.Dependencies, health.Dependency{
+			Name: "notifications", Check: notifyBreaker.Check, State: notifyBreaker.State,
+		})
+	}
+
+	if replica != nil {
+		checker.Dependencies = append(checker.Dependencies, health.Dependency{
+			Name: "database_replica", Check: replica.DB.PingContext, State: replica.Breaker.State,
+		})
+	}
+
+	if host := app.Config.Get("REDIS_HOST"); host != "" {
+		addr := net.JoinHostPort(host, app.Config.GetOrDefault("REDIS_PORT", "6379"))
+		checker.Dependencies = append(checker.Dependencies, health.Dependency{Name: "cache", Check: health.RedisPing(addr)})
+	}
+
+	spec := openapi.New("Bus Tracking and Ticket Booking API", "1.0.0")
+	// Everything but health checks and metrics is registered through r, under
+	// apiV1; a /v2 would get a group of its own alongside it.
+	root := openapi.NewRouter(app, spec).Use(handler.Localize)
+	r := root.Group(apiV1)
+
+	// Any signed-in user may book; only staff may validate and board
+	// tickets, and only admins may change buses, schedules and fares.
+	staffOnly := handler.RequireRole(models.RoleConductor, models.RoleAdmin)
+	adminOnly := handler.RequireRole(models.RoleAdmin)
+
+	page := []openapi.Query{
+		{Name: "limit", Type: "integer", Description: "page size, 1-100 (default 20)"},
+		{Name: "offset", Type: "integer", Description: "items to skip"},
+	}
+
+	r.GET("/", func(ctx *gofr.Context) (interface{}, error) {
+		return "Welcome to Gofr backend!", nil
+	}, openapi.Operation{Summary: "Welcome message", Response: ""})
+
+	root.GET("/health", handler.Health(checker), openapi.Operation{
+		Summary: "Dependency health; 503 when degraded or down", Response: health.Report{},
+	})
+	root.GET("/livez", handler.Live, openapi.Operation{
+		Summary: "Liveness; answers whenever the process does", Response: map[string]string{},
 	})
+	root.GET("/readyz", handler.Ready(checker, readiness), openapi.Operation{
+		Summary: "Readiness; 503 until warm-up is done and the database is up", Response: health.ReadyReport{},
+	})
+
+	r.GET("/openapi.json", handler.OpenAPI(spec), openapi.Operation{Summary: "This document"})
 
-	// Demo: Get bus by ID
-	app.GET("/buses/{id}", func(ctx *gofr.Context) (interface{}, error) {
-		id := ctx.PathParam("id")
-		bus := map[string]interface{}{"id": id, "route": "Demo Route"}
-		return bus, nil
+	r.POST("/auth/login", h.Login, openapi.Operation{
+		Summary: "Exchange credentials for a bearer token", Request: models.Login{}, Response: models.Token{},
+		Status: http.StatusOK,
 	})
 
-	// Demo: Book ticket
-	app.POST("/tickets/book", func(ctx *gofr.Context) (interface{}, error) {
-		// In real app, parse request body
-		return map[string]interface{}{"ticket_id": 555, "status": "booked"}, nil
+	r.POST("/users", h.CreateUser, openapi.Operation{
+		Summary: "Register a user", Request: models.Registration{}, Response: models.User{},
+	})
+	r.GET("/users", adminOnly(h.ListUsers), openapi.Operation{
+		Summary: "List users", Auth: true, Query: page, Response: []models.User{}, Paged: true,
+	})
+	r.GET("/users/search", adminOnly(h.SearchUsers), openapi.Operation{
+		Summary: "Find users by name or email prefix", Auth: true,
+		Query: append([]openapi.Query{
+			{Name: "q", Required: true, Description: "case-insensitive prefix of a name or email, at least 2 characters"},
+		}, page...),
+		Response: []models.User{}, Paged: true,
+	})
+	r.GET("/users/{id}", handler.RequireUser(h.GetUser), openapi.Operation{
+		Summary: "Get a user", Auth: true, Response: models.User{},
+	})
+	r.PATCH("/users/{id}", handler.RequireUser(h.UpdateUser), openapi.Operation{
+		Summary: "Update some of a user's profile", Auth: true, Request: models.ProfileUpdate{}, Response: models.User{},
+	})
+	r.GET("/users/{id}/tickets", handler.RequireUser(h.ListUserTickets), openapi.Operation{
+		Summary: "A user's booking history", Auth: true,
+		Query: append([]openapi.Query{
+			{Name: "status", Description: "upcoming (soonest first), past or all (latest first, the default)"},
+		}, page...),
+		Response: []models.TripTicket{}, Paged: true,
+	})
+
+	r.GET("/stops", h.ListStops, openapi.Operation{Summary: "List stops", Response: []models.Stop{}})
+
+	r.GET("/buses", h.ListBuses, openapi.Operation{
+		Summary: "List buses, earliest departure first unless sorted",
+		Query: append([]openapi.Query{
+			{Name: "from", Description: "stop the bus must call at before to, matched ignoring case and spacing"},
+			{Name: "to", Description: "stop the bus must call at after from, matched ignoring case and spacing"},
+			{Name: "departure_after", Description: "earliest departure, HH:MM"},
+			{Name: "departure_before", Description: "latest departure, HH:MM"},
+			{Name: "include_deleted", Type: "boolean", Description: "admins only"},
+			{Name: "amenities", Description: "comma-separated amenities every bus must have: wifi, charging, ac or wheelchair"},
+			{Name: "sort", Description: "departure, fare or occupancy; prefix with - for descending"},
+		}, page...),
+		Response: []models.Bus{}, Paged: true,
+	})
+	// Registered ahead of /buses/{id} so "nearby" is not taken for an ID.
+	r.GET("/buses/nearby", h.NearbyBuses, openapi.Operation{
+		Summary: "Buses whose latest position is near a point, nearest first",
+		Query: []openapi.Query{
+			{Name: "lat", Type: "number", Required: true},
+			{Name: "lng", Type: "number", Required: true},
+			{Name: "radius", Type: "number", Description: "meters (default 1000)"},
+		},
+		Response: []models.NearbyBus{},
+	})
+	r.GET("/buses/active", h.ActiveBuses, openapi.Operation{
+		Summary: "Buses that reported a position lately, with occupancy and next stop",
+		Query: []openapi.Query{
+			{Name: "within", Type: "integer", Description: "minutes since the last report (default 10)"},
+			{Name: "route", Type: "integer", Description: "only buses on this route"},
+		},
+		Response: []models.ActiveBus{},
+	})
+	r.GET("/buses/{id}", h.GetBus, openapi.Operation{
+		Summary:  "Get a bus and its occupancy",
+		Query:    []openapi.Query{{Name: "date", Description: "occupancy date, YYYY-MM-DD (default today)"}},
+		Response: models.BusDetail{},
+	})
+	r.POST("/buses", adminOnly(h.CreateBus), openapi.Operation{
+		Summary: "Add a bus of an existing model to a route", Auth: true,
+		Request: models.NewBus{}, Response: models.Bus{},
+	})
+	r.GET("/bus-models", h.ListBusModels, openapi.Operation{
+		Summary: "Seat layouts buses can be built to", Response: []models.BusModel{},
+	})
+	r.PUT("/buses/{id}/schedule", adminOnly(h.UpdateSchedule), openapi.Operation{
+		Summary: "Change a bus's departure time, run days and route", Auth: true,
+		Request: models.Schedule{}, Response: models.ScheduleChange{},
+	})
+	r.POST("/buses/{id}/substitute", adminOnly(h.SubstituteBus), openapi.Operation{
+		Summary: "Move a bus's upcoming bookings onto a replacement bus", Auth: true,
+		Request: models.BusSubstitution{}, Response: models.BusSubstitutionReport{}, Status: http.StatusOK,
+	})
+	r.DELETE("/buses/{id}", adminOnly(h.DeleteBus), openapi.Operation{
+		Summary: "Decommission a bus", Auth: true,
+	})
+	r.POST("/api-keys", adminOnly(h.CreateAPIKey), openapi.Operation{
+		Summary: "Issue an API key for a server-to-server client", Auth: true,
+		Request: models.NewAPIKey{}, Response: models.APIKey{},
+	})
+	r.DELETE("/api-keys/{id}", adminOnly(h.RevokeAPIKey), openapi.Operation{
+		Summary: "Revoke an API key", Auth: true,
+	})
+	r.GET("/audit", adminOnly(h.GetAuditLog), openapi.Operation{
+		Summary: "Changes made to a resource, newest first", Auth: true,
+		Query: append([]openapi.Query{
+			{Name: "resource", Required: true, Description: "ticket, bus, route_fare, user, waitlist_entry, api_key or webhook_subscription"},
+			{Name: "id", Type: "integer", Description: "the resource's ID (default every resource of the kind)"},
+		}, page...),
+		Response: []models.AuditEntry{}, Paged: true,
+	})
+	r.POST("/webhooks/subscriptions", adminOnly(h.CreateWebhookSubscription), openapi.Operation{
+		Summary: "Subscribe a URL to booking events", Auth: true,
+		Request: models.NewWebhookSubscription{}, Response: models.WebhookSubscription{},
+	})
+	r.DELETE("/webhooks/subscriptions/{id}", adminOnly(h.DeleteWebhookSubscription), openapi.Operation{
+		Summary: "Unsubscribe from booking events", Auth: true,
+	})
+	r.PUT("/routes/{id}/fare", adminOnly(h.SetRouteFare), openapi.Operation{
+		Summary: "Fix the seat fare of a route", Auth: true,
+		Request: models.RouteFare{}, Response: models.RouteFare{},
+	})
+	r.GET("/routes/{id}/delays", h.GetRouteDelays, openapi.Operation{
+		Summary: "Average delay at each stop of a route over the last 30 days", Response: models.RouteDelays{},
+	})
+	r.GET("/buses/{id}/seats", h.GetSeatMap, openapi.Operation{
+		Summary:  "Seat map for a departure",
+		Query:    []openapi.Query{{Name: "date", Required: true, Description: "RFC3339 travel date"}},
+		Response: models.SeatMap{},
+	})
+	r.GET("/buses/{id}/no-shows", staffOnly(h.GetNoShows), openapi.Operation{
+		Summary: "Seats whose riders never boarded a departure", Auth: true,
+		Query:    []openapi.Query{{Name: "date", Description: "YYYY-MM-DD in the bus's time zone (default today)"}},
+		Response: models.NoShowReport{},
+	})
+	r.GET("/buses/{id}/tickets", staffOnly(h.GetTripTickets), openapi.Operation{
+		Summary: "Tickets for a departure, with counts by status", Auth: true,
+		Query: []openapi.Query{
+			{Name: "status", Description: "booked, validated, boarded or cancelled (default all)"},
+			{Name: "date", Description: "YYYY-MM-DD in the bus's time zone (default today)"},
+		},
+		Response: models.TripTickets{},
+	})
+	r.GET("/buses/{id}/booking-rate", adminOnly(h.GetBookingRate), openapi.Operation{
+		Summary: "Bookings per hour or day leading up to a departure", Auth: true,
+		Query: []openapi.Query{
+			{Name: "date", Description: "YYYY-MM-DD in the bus's time zone (default today)"},
+			{Name: "bucket", Description: "hour (the default) or day"},
+		},
+		Response: models.BookingRate{},
+	})
+	r.GET("/buses/{id}/stop-counts", adminOnly(h.GetStopCounts), openapi.Operation{
+		Summary: "Riders boarding and alighting at each stop of a departure", Auth: true,
+		Query:    []openapi.Query{{Name: "date", Description: "YYYY-MM-DD in the bus's time zone (default today)"}},
+		Response: models.StopCounts{},
+	})
+	r.GET("/buses/{id}/fare", h.GetFare, openapi.Operation{
+		Summary: "Fare between two stops",
+		Query: []openapi.Query{
+			{Name: "from", Required: true},
+			{Name: "to", Required: true},
+			{Name: "date", Description: "departure to price the surge of, YYYY-MM-DD (default today)"},
+		},
+		Response: models.FareQuote{},
 	})
 
-	// Demo: Validate ticket
-	app.POST("/tickets/validate", func(ctx *gofr.Context) (interface{}, error) {
-		// In real app, parse request body
-		return map[string]interface{}{"ticket_id": 555, "valid": true}, nil
+	r.POST("/tickets/hold", handler.RequireUser(h.HoldSeats), openapi.Operation{
+		Summary: "Hold seats for a limited time", Auth: true, Request: models.Hold{}, Response: models.SeatHold{},
+	})
+	r.POST("/payments/intent", handler.RequireUser(h.CreatePaymentIntent), openapi.Operation{
+		Summary: "Start paying for a seat hold", Auth: true,
+		Request: models.PaymentIntentRequest{}, Response: models.Payment{},
+	})
+	r.POST("/journeys/book", handler.RequireUser(h.BookJourney), openapi.Operation{
+		Summary: "Book every leg of a journey with transfers", Auth: true,
+		Request: models.JourneyBooking{}, Response: models.Journey{},
+	})
+	r.GET("/journeys/{id}", handler.RequireUser(h.GetJourney), openapi.Operation{
+		Summary: "Get a journey and its tickets", Auth: true, Response: models.Journey{},
+	})
+	r.POST("/tickets/waitlist", handler.RequireUser(h.JoinWaitlist), openapi.Operation{
+		Summary: "Join the waitlist for a fully booked bus", Auth: true,
+		Request: models.WaitlistRequest{}, Response: models.WaitlistEntry{},
+	})
+	r.GET("/tickets/waitlist/{id}", handler.RequireUser(h.GetWaitlistEntry), openapi.Operation{
+		Summary: "Waitlist position", Auth: true, Response: models.WaitlistEntry{},
+	})
+	r.POST("/tickets/book", handler.RequireUser(h.BookTicket), openapi.Operation{
+		Summary: "Book a ticket", Auth: true,
+		Headers: []openapi.Query{{Name: "Idempotency-Key", Description: "replays return the original ticket"}},
+		Request: models.Booking{}, Response: models.Ticket{},
+	})
+	r.POST("/tickets/book/bulk", handler.RequireUser(h.BookTicketsBulk), openapi.Operation{
+		Summary: "Book several tickets atomically", Auth: true,
+		Request: []models.Booking{}, Response: models.BulkBooking{},
+	})
+	r.POST("/tickets/book/return", handler.RequireUser(h.BookReturnTrip), openapi.Operation{
+		Summary: "Book a round trip, both ways at once", Auth: true,
+		Request: models.ReturnBooking{}, Response: models.ReturnTrip{},
+	})
+	r.POST("/tickets/validate", staffOnly(h.ValidateTicket), openapi.Operation{
+		Summary: "Validate a scanned ticket QR payload", Auth: true, Request: models.Validation{},
+		Response: models.ValidationResult{}, Status: http.StatusOK,
+	})
+	r.POST("/tickets/{id}/cancel", handler.RequireUser(h.CancelTicket), openapi.Operation{
+		Summary: "Cancel a ticket", Auth: true, Response: models.Cancellation{}, Status: http.StatusOK,
+		Query: []openapi.Query{
+			{Name: "with_return", Type: "boolean", Description: "also cancel the other leg of a round trip"},
+		},
+	})
+	r.POST("/tickets/{id}/cancel-seats", handler.RequireUser(h.CancelSeats), openapi.Operation{
+		Summary: "Cancel some of a ticket's seats", Auth: true, Request: models.SeatCancellation{},
+		Response: models.Cancellation{}, Status: http.StatusOK,
+	})
+	r.POST("/tickets/{id}/change", handler.RequireUser(h.ChangeTicket), openapi.Operation{
+		Summary: "Move a ticket to other seats, another bus or another date", Auth: true,
+		Request: models.TicketChange{}, Response: models.TicketChangeResult{}, Status: http.StatusOK,
+	})
+	r.POST("/tickets/{id}/transfer", handler.RequireUser(h.TransferTicket), openapi.Operation{
+		Summary: "Give an unused ticket to another user, revoking its QR code", Auth: true,
+		Request: models.TicketTransfer{}, Response: models.TicketTransferResult{}, Status: http.StatusOK,
+	})
+	r.POST("/tickets/{id}/board", staffOnly(h.BoardTicket), openapi.Operation{
+		Summary: "Record that a validated ticket's riders boarded", Auth: true, Request: models.Boarding{},
+		Response: models.BoardingResult{}, Status: http.StatusOK,
+	})
+	r.GET("/tickets/{id}", handler.RequireUser(h.GetTicket), openapi.Operation{
+		Summary: "A ticket, with how its fare is split", Auth: true, Response: models.TicketDetail{},
+	})
+	r.POST("/tickets/{id}/split", handler.RequireUser(h.SplitFare), openapi.Operation{
+		Summary: "Split a ticket's fare among co-riders", Auth: true, Request: models.SplitRequest{},
+		Response: models.TicketDetail{}, Status: http.StatusOK,
+	})
+	r.POST("/tickets/{id}/split/settle", handler.RequireUser(h.SettleFareSplit), openapi.Operation{
+		Summary: "Record that you paid your share of a ticket's fare", Auth: true, Response: models.FareSplit{},
+		Status: http.StatusOK,
+	})
+	r.GET("/tickets/{id}/qr", handler.RequireUser(h.GetTicketQR), openapi.Operation{
+		Summary: "Ticket QR code", Auth: true, ContentType: "image/png",
+	})
+	// Served by handler.Mount, so only documented here.
+	root.Document(http.MethodGet, "/metrics", openapi.Operation{
+		Summary: "Prometheus metrics", ContentType: "text/plain",
+	})
+	r.Document(http.MethodGet, "/tickets/export", openapi.Operation{
+		Summary: "Export a day's bookings for a bus", Auth: true,
+		Query: []openapi.Query{
+			{Name: "bus_id", Type: "integer", Required: true},
+			{Name: "date", Required: true, Description: "YYYY-MM-DD"},
+			{Name: "format", Description: "csv or json (default json)"},
+		},
+		ContentType: "text/csv",
+	})
+	r.Document(http.MethodPost, "/payments/webhook", openapi.Operation{
+		Summary: "Payment provider events, signed in the Payment-Signature header",
+		Request: models.PaymentEvent{}, Response: models.Payment{}, Status: http.StatusOK,
 	})
 
-	// Demo: Live bus location
-	app.GET("/bus/location/{id}", func(ctx *gofr.Context) (interface{}, error) {
-		id := ctx.PathParam("id")
-		location := map[string]interface{}{"bus_id": id, "lat": 12.9716, "lng": 77.5946}
-		return location, nil
+	// Registered ahead of /bus/location/{id}, which "batch" would otherwise match.
+	r.POST("/bus/location/batch", handler.RequireScope(auth.ScopeLocationWrite)(h.ReportLocationBatch), openapi.Operation{
+		Summary: "Report a batch of positions from GPS units", Scopes: []string{auth.ScopeLocationWrite},
+		Request:  []models.LocationUpdate{},
+		Response: models.LocationBatchResult{}, Status: http.StatusOK,
+	})
+	r.GET("/bus/location/{id}", h.GetLocation, openapi.Operation{
+		Summary:  "Latest reported position, or one estimated from the timetable when the signal is lost",
+		Response: models.LiveLocation{},
+	})
+	r.POST("/bus/location/{id}", handler.RequireScope(auth.ScopeLocationWrite)(h.ReportLocation), openapi.Operation{
+		Summary: "Report a bus's position", Scopes: []string{auth.ScopeLocationWrite},
+		Request: models.LocationReport{}, Response: models.LocationUpdate{},
+	})
+	r.GET("/bus/{id}/eta", h.GetETA, openapi.Operation{
+		Summary:  "Estimated arrival at a stop",
+		Query:    []openapi.Query{{Name: "stop", Required: true}},
+		Response: models.ETA{},
+	})
+	r.GET("/bus/{id}/trail", h.GetTrail, openapi.Operation{
+		Summary:  "Recorded positions, oldest first",
+		Query:    []openapi.Query{{Name: "since", Description: "RFC3339 (default an hour ago)"}},
+		Response: []models.LocationUpdate{},
 	})
+	app.WebSocket(r.Path("/ws/bus/location/{id}"), h.StreamLocation)
+	app.WebSocket(r.Path("/ws/buses/{id}/seats"), h.StreamSeats)
+
+	// Stop accepting connections once signalled and give in-flight requests
+	// up to drainTimeout to finish; whatever is still open after that is cut
+	// off when the process exits.
+	drained := make(chan struct{})
+
+	go func() {
+		defer close(drained)
+
+		<-ctx.Done()
+		app.Logger().Infof("shutting down, draining in-flight requests for up to %s", drainTimeout)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		defer cancel()
+
+		if err := app.Shutdown(shutdownCtx); err != nil {
+			app.Logger().Warnf("drain incomplete, force-closing remaining connections: %v", err)
+		}
+	}()
 
 	app.Start()
+
+	// Start also returns if the server stops on its own; make sure the
+	// shutdown goroutine runs either way before the pool is closed.
+	stop()
+	<-drained
+	<-reaped
+	<-detected
+	<-dispatched
+
+	if err := app.DB().Close(); err != nil {
+		app.Logger().Errorf("closing database pool: %v", err)
+	}
+
+	if replica != nil {
+		if err := replica.DB.Close(); err != nil {
+			app.Logger().Errorf("closing replica pool: %v", err)
+		}
+	}
 }
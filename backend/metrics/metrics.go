@@ -0,0 +1,151 @@
+// Package metrics keeps the Prometheus counters and histograms the service
+// exposes at /metrics.
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the service's collectors. A nil *Metrics records nothing, so
+// callers need not check whether metrics are enabled.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	bookings      *prometheus.CounterVec
+	cancellations prometheus.Counter
+	validations   *prometheus.CounterVec
+	requests      *prometheus.HistogramVec
+}
+
+// New returns Metrics registered in a fresh registry, alongside the Go
+// runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		bookings: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bus_bookings_total",
+			Help: "Tickets booked, by result (success or failure).",
+		}, []string{"result"}),
+		cancellations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bus_cancellations_total",
+			Help: "Tickets cancelled.",
+		}),
+		validations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bus_ticket_validations_total",
+			Help: "Ticket validations, by result (success or failure).",
+		}, []string{"result"}),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route, method and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	m.registry.MustRegister(
+		m.bookings, m.cancellations, m.validations, m.requests,
+		collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Booked counts n tickets booked.
+func (m *Metrics) Booked(n int) {
+	if m != nil {
+		m.bookings.WithLabelValues("success").Add(float64(n))
+	}
+}
+
+// BookingFailed counts a booking attempt that was refused or errored.
+func (m *Metrics) BookingFailed() {
+	if m != nil {
+		m.bookings.WithLabelValues("failure").Inc()
+	}
+}
+
+// Cancelled counts a cancelled ticket.
+func (m *Metrics) Cancelled() {
+	if m != nil {
+		m.cancellations.Inc()
+	}
+}
+
+// Validated counts a ticket validation attempt by whether it succeeded.
+func (m *Metrics) Validated(ok bool) {
+	if m == nil {
+		return
+	}
+
+	result := "failure"
+	if ok {
+		result = "success"
+	}
+
+	m.validations.WithLabelValues(result).Inc()
+}
+
+// idSegment matches the numeric path segments folded into "{id}" so that
+// routes, not individual resources, label the latency histogram.
+var idSegment = regexp.MustCompile(`/\d+(/|$)`)
+
+// Middleware observes the latency of every request.
+func (m *Metrics) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(sw, r)
+
+			route := idSegment.ReplaceAllString(r.URL.Path, "/{id}$1")
+			m.requests.WithLabelValues(r.Method, route, strconv.Itoa(sw.status)).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wrote {
+		w.status, w.wrote = code, true
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack keeps WebSocket upgrades working behind the middleware.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	w.status, w.wrote = http.StatusSwitchingProtocols, true
+
+	return h.Hijack()
+}
@@ -0,0 +1,17 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+const createBuses = `CREATE TABLE IF NOT EXISTS buses (
+	id    INTEGER PRIMARY KEY,
+	route TEXT NOT NULL
+)`
+
+func createBusesTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(createBuses)
+			return err
+		},
+	}
+}
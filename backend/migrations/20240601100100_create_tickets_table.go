@@ -0,0 +1,36 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+const createTickets = `CREATE TABLE IF NOT EXISTS tickets (
+	id           SERIAL PRIMARY KEY,
+	user_id      INTEGER NOT NULL,
+	bus_id       INTEGER NOT NULL REFERENCES buses (id),
+	travel_date  TIMESTAMPTZ NOT NULL,
+	status       TEXT NOT NULL DEFAULT 'booked',
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	validated_at TIMESTAMPTZ
+)`
+
+// Seats live in their own table so a seat can be looked up (and, later,
+// constrained) per bus and travel date without unpacking every ticket.
+const createTicketSeats = `CREATE TABLE IF NOT EXISTS ticket_seats (
+	ticket_id   INTEGER NOT NULL REFERENCES tickets (id),
+	bus_id      INTEGER NOT NULL REFERENCES buses (id),
+	travel_date TIMESTAMPTZ NOT NULL,
+	seat_number INTEGER NOT NULL,
+	PRIMARY KEY (ticket_id, seat_number)
+)`
+
+func createTicketsTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			if _, err := d.SQL.Exec(createTickets); err != nil {
+				return err
+			}
+
+			_, err := d.SQL.Exec(createTicketSeats)
+			return err
+		},
+	}
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// The two buses the demo handlers used to return inline.
+const seedBuses = `INSERT INTO buses (id, route) VALUES
+	(101, 'A-B'),
+	(102, 'B-C')
+ON CONFLICT (id) DO NOTHING`
+
+func seedDemoBuses() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(seedBuses)
+			return err
+		},
+	}
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+const addBusCapacity = `ALTER TABLE buses ADD COLUMN IF NOT EXISTS capacity INTEGER NOT NULL DEFAULT 40`
+
+// Backstop for the availability check in the booking transaction: a seat can
+// only be held by one ticket per bus and travel date.
+const uniqueTicketSeat = `CREATE UNIQUE INDEX IF NOT EXISTS ticket_seats_bus_date_seat
+	ON ticket_seats (bus_id, travel_date, seat_number)`
+
+func addBusCapacityColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			if _, err := d.SQL.Exec(addBusCapacity); err != nil {
+				return err
+			}
+
+			_, err := d.SQL.Exec(uniqueTicketSeat)
+			return err
+		},
+	}
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+const addTicketCancelledAt = `ALTER TABLE tickets ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMPTZ`
+
+func addTicketCancelledAtColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addTicketCancelledAt)
+			return err
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+const createUsers = `CREATE TABLE IF NOT EXISTS users (
+	id            SERIAL PRIMARY KEY,
+	name          TEXT NOT NULL,
+	email         TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL
+)`
+
+// The users the /users handlers used to return inline, both with the password
+// "password123" so the login flow can be exercised locally.
+const seedUsers = `INSERT INTO users (id, name, email, password_hash) VALUES
+	(1, 'Alice', 'alice@example.com', '$2a$10$0jb9Lx3GogoSf0znvsbeaO5QTlI2gkHBi4Rvbt.XgdzIc35jZixJq'),
+	(2, 'Bob', 'bob@example.com', '$2a$10$0jb9Lx3GogoSf0znvsbeaO5QTlI2gkHBi4Rvbt.XgdzIc35jZixJq')
+ON CONFLICT (id) DO NOTHING`
+
+// Keeps SERIAL from handing out the seeded IDs again.
+const resetUsersSequence = `SELECT setval(pg_get_serial_sequence('users', 'id'), (SELECT MAX(id) FROM users))`
+
+func createUsersTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range []string{createUsers, seedUsers, resetUsersSequence} {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+const createRoutes = `CREATE TABLE IF NOT EXISTS routes (
+	id   SERIAL PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+)`
+
+const createRouteStops = `CREATE TABLE IF NOT EXISTS route_stops (
+	route_id INTEGER NOT NULL REFERENCES routes (id),
+	position INTEGER NOT NULL,
+	name     TEXT NOT NULL,
+	PRIMARY KEY (route_id, position)
+)`
+
+// Existing buses carry their route as "A-B" style text; each distinct value
+// becomes a route whose stops are the dash-separated parts, in order.
+var moveBusRoutes = []string{
+	`INSERT INTO routes (name) SELECT DISTINCT route FROM buses ON CONFLICT (name) DO NOTHING`,
+	`INSERT INTO route_stops (route_id, position, name)
+		SELECT r.id, s.position, s.name
+		FROM routes r, unnest(string_to_array(r.name, '-')) WITH ORDINALITY AS s (name, position)
+		ON CONFLICT DO NOTHING`,
+	`ALTER TABLE buses ADD COLUMN IF NOT EXISTS route_id INTEGER REFERENCES routes (id)`,
+	`UPDATE buses b SET route_id = r.id FROM routes r WHERE r.name = b.route`,
+	`ALTER TABLE buses ALTER COLUMN route_id SET NOT NULL`,
+	`ALTER TABLE buses DROP COLUMN route`,
+}
+
+func createRoutesTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			queries := append([]string{createRoutes, createRouteStops}, moveBusRoutes...)
+
+			for _, q := range queries {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+var addStopCoordinates = []string{
+	`ALTER TABLE route_stops ADD COLUMN IF NOT EXISTS lat DOUBLE PRECISION`,
+	`ALTER TABLE route_stops ADD COLUMN IF NOT EXISTS lng DOUBLE PRECISION`,
+	// NULL means the bus uses the configured default speed.
+	`ALTER TABLE buses ADD COLUMN IF NOT EXISTS avg_speed_kmh DOUBLE PRECISION`,
+	// Coordinates for the demo stops, around the point the location demo reports.
+	`UPDATE route_stops SET lat = 12.9716, lng = 77.5946 WHERE name = 'A' AND lat IS NULL`,
+	`UPDATE route_stops SET lat = 12.9352, lng = 77.6245 WHERE name = 'B' AND lat IS NULL`,
+	`UPDATE route_stops SET lat = 12.9141, lng = 77.6411 WHERE name = 'C' AND lat IS NULL`,
+}
+
+func addStopCoordinatesColumns() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range addStopCoordinates {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+var addTicketIdempotencyKey = []string{
+	`ALTER TABLE tickets ADD COLUMN IF NOT EXISTS idempotency_key TEXT`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS tickets_user_idempotency_key ON tickets (user_id, idempotency_key)
+		WHERE idempotency_key IS NOT NULL`,
+}
+
+func addTicketIdempotencyKeyColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range addTicketIdempotencyKey {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
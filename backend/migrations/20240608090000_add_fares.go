@@ -0,0 +1,22 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+var addFares = []string{
+	`ALTER TABLE buses ADD COLUMN IF NOT EXISTS seat_fare NUMERIC(10, 2) NOT NULL DEFAULT 0`,
+	`ALTER TABLE tickets ADD COLUMN IF NOT EXISTS fare NUMERIC(10, 2) NOT NULL DEFAULT 0`,
+}
+
+func addFareColumns() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range addFares {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,15 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// NULL means the geofence default radius.
+const addStopRadius = `ALTER TABLE route_stops ADD COLUMN IF NOT EXISTS radius_m DOUBLE PRECISION`
+
+func addStopRadiusColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addStopRadius)
+			return err
+		},
+	}
+}
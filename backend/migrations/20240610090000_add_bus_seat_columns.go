@@ -0,0 +1,15 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Seats per row; the default is the common 2+2 layout.
+const addBusSeatColumns = `ALTER TABLE buses ADD COLUMN IF NOT EXISTS seat_columns INTEGER NOT NULL DEFAULT 4`
+
+func addBusSeatColumnsColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addBusSeatColumns)
+			return err
+		},
+	}
+}
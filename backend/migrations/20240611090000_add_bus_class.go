@@ -0,0 +1,23 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+var addBusClass = []string{
+	`ALTER TABLE buses ADD COLUMN IF NOT EXISTS class TEXT NOT NULL DEFAULT 'standard'
+		CHECK (class IN ('standard', 'ac', 'sleeper'))`,
+	`UPDATE buses SET class = 'ac' WHERE id = 102`,
+}
+
+func addBusClassColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range addBusClass {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
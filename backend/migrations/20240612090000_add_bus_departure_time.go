@@ -0,0 +1,27 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Every bus runs once a day, leaving its first stop at departure_time.
+var addBusDepartureTime = []string{
+	`ALTER TABLE buses ADD COLUMN IF NOT EXISTS departure_time TIME`,
+	`UPDATE buses SET departure_time = '08:00' WHERE id = 101 AND departure_time IS NULL`,
+	`UPDATE buses SET departure_time = '09:30' WHERE id = 102 AND departure_time IS NULL`,
+	`UPDATE buses SET departure_time = '00:00' WHERE departure_time IS NULL`,
+	`ALTER TABLE buses ALTER COLUMN departure_time SET NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS buses_departure_time_idx ON buses (departure_time)`,
+}
+
+func addBusDepartureTimeColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range addBusDepartureTime {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
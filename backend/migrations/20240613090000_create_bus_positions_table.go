@@ -0,0 +1,30 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+var createBusPositions = []string{
+	`CREATE TABLE IF NOT EXISTS bus_positions (
+		id          BIGSERIAL PRIMARY KEY,
+		bus_id      INTEGER NOT NULL REFERENCES buses (id),
+		lat         DOUBLE PRECISION NOT NULL,
+		lng         DOUBLE PRECISION NOT NULL,
+		recorded_at TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS bus_positions_bus_recorded_idx ON bus_positions (bus_id, recorded_at)`,
+	// Pruning deletes by age across all buses.
+	`CREATE INDEX IF NOT EXISTS bus_positions_recorded_idx ON bus_positions (recorded_at)`,
+}
+
+func createBusPositionsTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range createBusPositions {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
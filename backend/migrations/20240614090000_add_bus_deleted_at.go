@@ -0,0 +1,15 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Set when a bus is decommissioned; the row stays for its ticket history.
+const addBusDeletedAt = `ALTER TABLE buses ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`
+
+func addBusDeletedAtColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addBusDeletedAt)
+			return err
+		},
+	}
+}
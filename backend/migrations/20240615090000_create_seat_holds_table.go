@@ -0,0 +1,33 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// A hold keeps its seats from being booked by anyone else until expires_at,
+// after which they are free again whether or not the row has been swept.
+var createSeatHolds = []string{
+	`CREATE TABLE IF NOT EXISTS seat_holds (
+		token        TEXT PRIMARY KEY,
+		user_id      INTEGER NOT NULL REFERENCES users (id),
+		bus_id       INTEGER NOT NULL REFERENCES buses (id),
+		travel_date  TIMESTAMPTZ NOT NULL,
+		seat_numbers INTEGER[] NOT NULL,
+		expires_at   TIMESTAMPTZ NOT NULL,
+		created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS seat_holds_bus_travel_idx ON seat_holds (bus_id, travel_date)`,
+	`CREATE INDEX IF NOT EXISTS seat_holds_expires_idx ON seat_holds (expires_at)`,
+}
+
+func createSeatHoldsTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range createSeatHolds {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,24 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+var addUserRole = []string{
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'rider'
+		CHECK (role IN ('rider', 'admin'))`,
+	// Alice becomes the demo operator.
+	`UPDATE users SET role = 'admin' WHERE email = 'alice@example.com'`,
+}
+
+func addUserRoleColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range addUserRole {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Waitlist entries are served first come, first served per bus and travel
+// date, in id order.
+var createWaitlist = []string{
+	`CREATE TABLE IF NOT EXISTS waitlist_entries (
+		id          SERIAL PRIMARY KEY,
+		user_id     INTEGER NOT NULL REFERENCES users (id),
+		bus_id      INTEGER NOT NULL REFERENCES buses (id),
+		travel_date TIMESTAMPTZ NOT NULL,
+		seats       INTEGER NOT NULL CHECK (seats > 0),
+		status      TEXT NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'promoted')),
+		ticket_id   INTEGER REFERENCES tickets (id),
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS waitlist_entries_waiting_idx ON waitlist_entries (bus_id, travel_date, id)
+		WHERE status = 'waiting'`,
+}
+
+func createWaitlistTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range createWaitlist {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
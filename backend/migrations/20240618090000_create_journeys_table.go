@@ -0,0 +1,34 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// A journey groups the tickets of a trip with transfers, one per leg.
+var createJourneys = []string{
+	`CREATE TABLE IF NOT EXISTS journeys (
+		id         SERIAL PRIMARY KEY,
+		user_id    INTEGER NOT NULL REFERENCES users (id),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE TABLE IF NOT EXISTS journey_legs (
+		journey_id INTEGER NOT NULL REFERENCES journeys (id),
+		leg        INTEGER NOT NULL,
+		ticket_id  INTEGER NOT NULL UNIQUE REFERENCES tickets (id),
+		from_stop  TEXT NOT NULL,
+		to_stop    TEXT NOT NULL,
+		PRIMARY KEY (journey_id, leg)
+	)`,
+}
+
+func createJourneysTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range createJourneys {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,16 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Departure times and travel dates are local to the IANA time zone a bus
+// runs in.
+const addBusTimezone = `ALTER TABLE buses ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC'`
+
+func addBusTimezoneColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addBusTimezone)
+			return err
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// A route fare fixes the seat price on every bus of the route, overriding
+// the buses' own. Discount codes take a percentage or a flat amount off a
+// booking, until they expire or run out of uses.
+var fareTables = []string{
+	`CREATE TABLE IF NOT EXISTS route_fares (
+		route_id  INTEGER PRIMARY KEY REFERENCES routes (id),
+		seat_fare NUMERIC(10, 2) NOT NULL CHECK (seat_fare >= 0)
+	)`,
+	`CREATE TABLE IF NOT EXISTS discount_codes (
+		code       TEXT PRIMARY KEY,
+		kind       TEXT NOT NULL CHECK (kind IN ('percent', 'flat')),
+		amount     NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+		expires_at TIMESTAMPTZ,
+		max_uses   INTEGER CHECK (max_uses > 0),
+		uses       INTEGER NOT NULL DEFAULT 0
+	)`,
+	`ALTER TABLE tickets ADD COLUMN IF NOT EXISTS discount NUMERIC(10, 2) NOT NULL DEFAULT 0`,
+	`ALTER TABLE tickets ADD COLUMN IF NOT EXISTS discount_code TEXT REFERENCES discount_codes (code)`,
+	`INSERT INTO discount_codes (code, kind, amount, max_uses) VALUES ('WELCOME10', 'percent', 10, 1000)
+		ON CONFLICT (code) DO NOTHING`,
+}
+
+func createFareTables() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range fareTables {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
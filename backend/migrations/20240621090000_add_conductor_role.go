@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+var conductorRole = []string{
+	`ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check`,
+	`ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('rider', 'conductor', 'admin'))`,
+	// Carol is the demo conductor, with the same password as the other demo users.
+	`INSERT INTO users (name, email, password_hash, role) VALUES
+		('Carol', 'carol@example.com', '$2a$10$0jb9Lx3GogoSf0znvsbeaO5QTlI2gkHBi4Rvbt.XgdzIc35jZixJq', 'conductor')
+	ON CONFLICT (email) DO NOTHING`,
+}
+
+func addConductorRole() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range conductorRole {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
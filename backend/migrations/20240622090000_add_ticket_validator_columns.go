@@ -0,0 +1,23 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Who validated a ticket, and on which device, so a second scan can say so.
+var addTicketValidator = []string{
+	`ALTER TABLE tickets ADD COLUMN IF NOT EXISTS validated_by INTEGER REFERENCES users (id)`,
+	`ALTER TABLE tickets ADD COLUMN IF NOT EXISTS validated_device TEXT`,
+}
+
+func addTicketValidatorColumns() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range addTicketValidator {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
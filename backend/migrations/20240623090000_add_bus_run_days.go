@@ -0,0 +1,16 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// The recurrence rule for which days of the week a bus runs; existing buses
+// keep running every day.
+const addBusRunDays = `ALTER TABLE buses ADD COLUMN IF NOT EXISTS run_days TEXT NOT NULL DEFAULT 'daily'`
+
+func addBusRunDaysColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addBusRunDays)
+			return err
+		},
+	}
+}
@@ -0,0 +1,16 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Bus updates carry the version they were made against, so concurrent edits
+// are refused rather than lost.
+const addBusVersion = `ALTER TABLE buses ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1`
+
+func addBusVersionColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addBusVersion)
+			return err
+		},
+	}
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// A payment pays for the seats of one hold; the hold itself goes once the
+// payment is confirmed, fails or expires. Provider events are recorded by
+// ID so retried webhooks are applied once.
+var createPayments = []string{
+	`CREATE TABLE IF NOT EXISTS payments (
+		id             SERIAL PRIMARY KEY,
+		intent_id      TEXT NOT NULL UNIQUE,
+		client_secret  TEXT NOT NULL,
+		user_id        INTEGER NOT NULL REFERENCES users (id),
+		hold_token     TEXT NOT NULL,
+		amount         NUMERIC(10, 2) NOT NULL CHECK (amount >= 0),
+		discount       NUMERIC(10, 2) NOT NULL DEFAULT 0,
+		discount_code  TEXT,
+		status         TEXT NOT NULL DEFAULT 'pending'
+			CHECK (status IN ('pending', 'succeeded', 'failed', 'expired')),
+		failure_reason TEXT,
+		ticket_id      INTEGER REFERENCES tickets (id),
+		expires_at     TIMESTAMPTZ NOT NULL,
+		created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS payments_pending_hold_idx ON payments (hold_token) WHERE status = 'pending'`,
+	`CREATE TABLE IF NOT EXISTS payment_events (
+		id          TEXT PRIMARY KEY,
+		intent_id   TEXT NOT NULL,
+		type        TEXT NOT NULL,
+		received_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+}
+
+func createPaymentsTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range createPayments {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
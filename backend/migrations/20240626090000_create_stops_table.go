@@ -0,0 +1,43 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Stops become shared rows that routes refer to by ID. Route stops whose
+// names differ only in case or spacing are merged into one stop, keyed as
+// models.StopKey does, keeping coordinates from whichever had them.
+var createStops = []string{
+	`CREATE TABLE IF NOT EXISTS stops (
+		id       SERIAL PRIMARY KEY,
+		name     TEXT NOT NULL,
+		name_key TEXT NOT NULL UNIQUE,
+		lat      DOUBLE PRECISION,
+		lng      DOUBLE PRECISION,
+		radius_m DOUBLE PRECISION
+	)`,
+	`INSERT INTO stops (name, name_key, lat, lng, radius_m)
+		SELECT DISTINCT ON (name_key) regexp_replace(trim(name), '\s+', ' ', 'g'), name_key, lat, lng, radius_m
+		FROM (SELECT *, lower(regexp_replace(trim(name), '\s+', ' ', 'g')) AS name_key FROM route_stops) rs
+		ORDER BY name_key, lat IS NULL, name
+		ON CONFLICT (name_key) DO NOTHING`,
+	`ALTER TABLE route_stops ADD COLUMN IF NOT EXISTS stop_id INTEGER REFERENCES stops (id)`,
+	`UPDATE route_stops rs SET stop_id = s.id FROM stops s
+		WHERE s.name_key = lower(regexp_replace(trim(rs.name), '\s+', ' ', 'g'))`,
+	`ALTER TABLE route_stops ALTER COLUMN stop_id SET NOT NULL`,
+	`ALTER TABLE route_stops DROP COLUMN IF EXISTS name, DROP COLUMN IF EXISTS lat,
+		DROP COLUMN IF EXISTS lng, DROP COLUMN IF EXISTS radius_m`,
+	`CREATE INDEX IF NOT EXISTS route_stops_stop_idx ON route_stops (stop_id)`,
+}
+
+func createStopsTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range createStops {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
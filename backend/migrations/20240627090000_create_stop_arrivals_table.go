@@ -0,0 +1,32 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Stop arrivals record when buses reached each stop on their route against
+// when their timetable had them due, one per stop per trip.
+var createStopArrivals = []string{
+	`CREATE TABLE IF NOT EXISTS stop_arrivals (
+		id           BIGSERIAL PRIMARY KEY,
+		bus_id       INTEGER NOT NULL REFERENCES buses (id),
+		route_id     INTEGER NOT NULL REFERENCES routes (id),
+		stop_id      INTEGER NOT NULL REFERENCES stops (id),
+		scheduled_at TIMESTAMPTZ NOT NULL,
+		arrived_at   TIMESTAMPTZ NOT NULL,
+		UNIQUE (bus_id, stop_id, scheduled_at)
+	)`,
+	`CREATE INDEX IF NOT EXISTS stop_arrivals_route_idx ON stop_arrivals (route_id, arrived_at)`,
+}
+
+func createStopArrivalsTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range createStopArrivals {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
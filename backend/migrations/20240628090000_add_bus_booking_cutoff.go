@@ -0,0 +1,17 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// A bus may close bookings earlier or later before departure than the
+// configured default; NULL keeps the default.
+const addBusBookingCutoff = `ALTER TABLE buses ADD COLUMN IF NOT EXISTS booking_cutoff_minutes INTEGER
+	CHECK (booking_cutoff_minutes >= 0)`
+
+func addBusBookingCutoffColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addBusBookingCutoff)
+			return err
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+const createBusModels = `CREATE TABLE IF NOT EXISTS bus_models (
+	id          SERIAL PRIMARY KEY,
+	name        TEXT NOT NULL UNIQUE,
+	seats_left  INTEGER NOT NULL CHECK (seats_left >= 1),
+	seats_right INTEGER NOT NULL CHECK (seats_right >= 0),
+	decks       INTEGER NOT NULL DEFAULT 1 CHECK (decks IN (1, 2)),
+	berths      BOOLEAN NOT NULL DEFAULT false
+)`
+
+// The common layouts, then one per seat_columns value existing buses use
+// ("2+2 seater" for 4), so every bus keeps its layout. Sleepers move to
+// double-deck berths.
+var moveBusLayouts = []string{
+	`INSERT INTO bus_models (name, seats_left, seats_right, decks, berths) VALUES
+		('2+2 seater', 2, 2, 1, false),
+		('2+1 seater', 2, 1, 1, false),
+		('2+1 sleeper', 2, 1, 2, true)
+	ON CONFLICT (name) DO NOTHING`,
+	`INSERT INTO bus_models (name, seats_left, seats_right)
+		SELECT DISTINCT (n + 1) / 2 || '+' || n / 2 || ' seater', (n + 1) / 2, n / 2
+		FROM (SELECT GREATEST(seat_columns, 1) AS n FROM buses WHERE class <> 'sleeper') c
+	ON CONFLICT (name) DO NOTHING`,
+	`ALTER TABLE buses ADD COLUMN IF NOT EXISTS model_id INTEGER REFERENCES bus_models (id)`,
+	`UPDATE buses b SET model_id = m.id FROM bus_models m
+		WHERE b.class <> 'sleeper' AND m.name = (GREATEST(b.seat_columns, 1) + 1) / 2 || '+' || GREATEST(b.seat_columns, 1) / 2 || ' seater'`,
+	`UPDATE buses SET model_id = (SELECT id FROM bus_models WHERE name = '2+1 sleeper') WHERE model_id IS NULL`,
+	`ALTER TABLE buses ALTER COLUMN model_id SET NOT NULL`,
+	`ALTER TABLE buses DROP COLUMN IF EXISTS seat_columns`,
+}
+
+func createBusModelsTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range append([]string{createBusModels}, moveBusLayouts...) {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
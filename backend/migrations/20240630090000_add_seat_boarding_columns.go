@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// When, where and by whom each seat's rider was seen boarding, apart from
+// the ticket being validated.
+var addSeatBoarding = []string{
+	`ALTER TABLE ticket_seats ADD COLUMN IF NOT EXISTS boarded_at TIMESTAMPTZ`,
+	`ALTER TABLE ticket_seats ADD COLUMN IF NOT EXISTS boarded_by INTEGER REFERENCES users (id)`,
+	`ALTER TABLE ticket_seats ADD COLUMN IF NOT EXISTS boarded_lat DOUBLE PRECISION`,
+	`ALTER TABLE ticket_seats ADD COLUMN IF NOT EXISTS boarded_lng DOUBLE PRECISION`,
+}
+
+func addSeatBoardingColumns() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range addSeatBoarding {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// A seat whose rider never boarded. Released seats have been removed from
+// ticket_seats, so the row is all that is left of them.
+var createNoShows = []string{
+	`CREATE TABLE IF NOT EXISTS no_shows (
+		ticket_id   INTEGER NOT NULL REFERENCES tickets (id),
+		seat_number INTEGER NOT NULL,
+		bus_id      INTEGER NOT NULL REFERENCES buses (id),
+		travel_date TIMESTAMPTZ NOT NULL,
+		flagged_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+		released    BOOLEAN NOT NULL DEFAULT false,
+		PRIMARY KEY (ticket_id, seat_number)
+	)`,
+	`CREATE INDEX IF NOT EXISTS no_shows_bus_date_idx ON no_shows (bus_id, travel_date)`,
+}
+
+func createNoShowsTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range createNoShows {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
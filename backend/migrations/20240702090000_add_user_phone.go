@@ -0,0 +1,15 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// A phone number riders may add to their profile; NULL until they do.
+const addUserPhone = `ALTER TABLE users ADD COLUMN IF NOT EXISTS phone TEXT`
+
+func addUserPhoneColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addUserPhone)
+			return err
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Third-party subscriptions to booking events, and every delivery of an
+// event to one of them. A delivery stays pending, retried with backoff,
+// until it is answered with a 2xx or has failed too many times. Unsubscribing
+// keeps the subscription, and the record of its deliveries, but stops any
+// still pending.
+var createWebhooks = []string{
+	`CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+		id         SERIAL PRIMARY KEY,
+		url        TEXT NOT NULL,
+		events     TEXT[] NOT NULL,
+		secret     TEXT NOT NULL,
+		created_by INTEGER NOT NULL REFERENCES users (id),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		deleted_at TIMESTAMPTZ
+	)`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id              SERIAL PRIMARY KEY,
+		subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions (id),
+		event           TEXT NOT NULL,
+		payload         JSONB NOT NULL,
+		status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		last_error      TEXT,
+		next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+		delivered_at    TIMESTAMPTZ
+	)`,
+	`CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at)
+		WHERE status = 'pending'`,
+}
+
+func createWebhookTables() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range createWebhooks {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Keys for server-to-server clients. Only a hash of each key is kept; a
+// revoked key stays, so its requests can still be traced to it.
+const createAPIKeys = `CREATE TABLE IF NOT EXISTS api_keys (
+	id         SERIAL PRIMARY KEY,
+	name       TEXT NOT NULL,
+	prefix     TEXT NOT NULL,
+	key_hash   TEXT NOT NULL UNIQUE,
+	scopes     TEXT[] NOT NULL,
+	created_by INTEGER NOT NULL REFERENCES users (id),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	revoked_at TIMESTAMPTZ
+)`
+
+func createAPIKeysTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(createAPIKeys)
+			return err
+		},
+	}
+}
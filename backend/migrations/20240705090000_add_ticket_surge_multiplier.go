@@ -0,0 +1,16 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// The surge multiplier a ticket's fare was raised by when it was booked,
+// kept so the fare it was sold at can be explained after the bus fills.
+const addTicketSurge = `ALTER TABLE tickets ADD COLUMN IF NOT EXISTS surge_multiplier NUMERIC(4, 2) NOT NULL DEFAULT 1`
+
+func addTicketSurgeMultiplier() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addTicketSurge)
+			return err
+		},
+	}
+}
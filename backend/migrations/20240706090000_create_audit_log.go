@@ -0,0 +1,33 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// The audit trail of changes made through the API. Entries are only ever
+// added; changes holds the fields each made as {"field": {"from", "to"}}.
+var createAuditLog = []string{
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		id          BIGSERIAL PRIMARY KEY,
+		actor_id    INTEGER REFERENCES users (id),
+		api_key_id  INTEGER REFERENCES api_keys (id),
+		action      TEXT NOT NULL,
+		resource    TEXT NOT NULL,
+		resource_id INTEGER NOT NULL,
+		changes     JSONB NOT NULL DEFAULT '{}',
+		created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_resource_idx ON audit_log (resource, resource_id, id)`,
+}
+
+func createAuditLogTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			for _, q := range createAuditLog {
+				if _, err := d.SQL.Exec(q); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,16 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// The amenities each bus has, such as wifi or wheelchair access, which
+// GET /buses filters by.
+const addBusAmenities = `ALTER TABLE buses ADD COLUMN IF NOT EXISTS amenities TEXT[] NOT NULL DEFAULT '{}'`
+
+func addBusAmenitiesColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addBusAmenities)
+			return err
+		},
+	}
+}
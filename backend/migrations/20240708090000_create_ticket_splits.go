@@ -0,0 +1,24 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// The shares of a ticket's fare its co-riders owe, one per user, and
+// whether each has been settled.
+const createTicketSplits = `CREATE TABLE IF NOT EXISTS ticket_splits (
+	ticket_id  INTEGER NOT NULL REFERENCES tickets (id),
+	user_id    INTEGER NOT NULL REFERENCES users (id),
+	amount     NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+	status     TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'settled')),
+	settled_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (ticket_id, user_id)
+)`
+
+func createTicketSplitsTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(createTicketSplits)
+			return err
+		},
+	}
+}
@@ -0,0 +1,16 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// The other leg of a round trip, set on both tickets so that either can find
+// its pair.
+const addTicketReturnLink = `ALTER TABLE tickets ADD COLUMN IF NOT EXISTS return_ticket_id INTEGER REFERENCES tickets(id)`
+
+func addTicketReturnTicket() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addTicketReturnLink)
+			return err
+		},
+	}
+}
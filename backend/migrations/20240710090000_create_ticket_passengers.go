@@ -0,0 +1,23 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// Riders named per seat of a ticket. They outlive the seat reservation, so
+// a cancelled ticket still shows who it was for.
+const createTicketPassengers = `CREATE TABLE IF NOT EXISTS ticket_passengers (
+	ticket_id   INTEGER NOT NULL REFERENCES tickets(id),
+	seat_number INTEGER NOT NULL,
+	name        TEXT NOT NULL,
+	age         INTEGER NOT NULL CHECK (age > 0),
+	gender      TEXT,
+	PRIMARY KEY (ticket_id, seat_number)
+)`
+
+func createTicketPassengersTable() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(createTicketPassengers)
+			return err
+		},
+	}
+}
@@ -0,0 +1,16 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// qr_version is signed into a ticket's QR codes; raising it, as a transfer
+// does, stops every code signed before from validating.
+const addTicketQRVersion = `ALTER TABLE tickets ADD COLUMN IF NOT EXISTS qr_version INTEGER NOT NULL DEFAULT 0`
+
+func addTicketQRVersionColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addTicketQRVersion)
+			return err
+		},
+	}
+}
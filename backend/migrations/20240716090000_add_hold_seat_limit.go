@@ -0,0 +1,16 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// The per-user seat limit a hold was made under, checked again when a
+// payment confirms it; 0 is no limit.
+const addHoldSeatLimit = `ALTER TABLE seat_holds ADD COLUMN IF NOT EXISTS seat_limit INTEGER NOT NULL DEFAULT 0`
+
+func addHoldSeatLimitColumn() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addHoldSeatLimit)
+			return err
+		},
+	}
+}
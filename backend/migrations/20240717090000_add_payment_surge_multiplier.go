@@ -0,0 +1,16 @@
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// The surge multiplier a payment's amount was priced with, kept for the
+// ticket the payment books.
+const addPaymentSurge = `ALTER TABLE payments ADD COLUMN IF NOT EXISTS surge_multiplier NUMERIC(4, 2) NOT NULL DEFAULT 1`
+
+func addPaymentSurgeMultiplier() migration.Migrate {
+	return migration.Migrate{
+		UP: func(d migration.Datasource) error {
+			_, err := d.SQL.Exec(addPaymentSurge)
+			return err
+		},
+	}
+}
@@ -0,0 +1,55 @@
+// Package migrations holds the schema migrations applied at startup.
+package migrations
+
+import "github.com/abhinav/gofr/migration"
+
+// All returns every migration keyed by the timestamp it was written at.
+func All() map[int64]migration.Migrate {
+	return map[int64]migration.Migrate{
+		20240601100000: createBusesTable(),
+		20240601100100: createTicketsTable(),
+		20240601100200: seedDemoBuses(),
+		20240602090000: addBusCapacityColumn(),
+		20240603090000: addTicketCancelledAtColumn(),
+		20240604090000: createUsersTable(),
+		20240605090000: createRoutesTable(),
+		20240606090000: addStopCoordinatesColumns(),
+		20240607090000: addTicketIdempotencyKeyColumn(),
+		20240608090000: addFareColumns(),
+		20240609090000: addStopRadiusColumn(),
+		20240610090000: addBusSeatColumnsColumn(),
+		20240611090000: addBusClassColumn(),
+		20240612090000: addBusDepartureTimeColumn(),
+		20240613090000: createBusPositionsTable(),
+		20240614090000: addBusDeletedAtColumn(),
+		20240615090000: createSeatHoldsTable(),
+		20240616090000: addUserRoleColumn(),
+		20240617090000: createWaitlistTable(),
+		20240618090000: createJourneysTable(),
+		20240619090000: addBusTimezoneColumn(),
+		20240620090000: createFareTables(),
+		20240621090000: addConductorRole(),
+		20240622090000: addTicketValidatorColumns(),
+		20240623090000: addBusRunDaysColumn(),
+		20240624090000: addBusVersionColumn(),
+		20240625090000: createPaymentsTable(),
+		20240626090000: createStopsTable(),
+		20240627090000: createStopArrivalsTable(),
+		20240628090000: addBusBookingCutoffColumn(),
+		20240629090000: createBusModelsTable(),
+		20240630090000: addSeatBoardingColumns(),
+		20240701090000: createNoShowsTable(),
+		20240702090000: addUserPhoneColumn(),
+		20240703090000: createWebhookTables(),
+		20240704090000: createAPIKeysTable(),
+		20240705090000: addTicketSurgeMultiplier(),
+		20240706090000: createAuditLogTable(),
+		20240707090000: addBusAmenitiesColumn(),
+		20240708090000: createTicketSplitsTable(),
+		20240709090000: addTicketReturnTicket(),
+		20240710090000: createTicketPassengersTable(),
+		20240715090000: addTicketQRVersionColumn(),
+		20240716090000: addHoldSeatLimitColumn(),
+		20240717090000: addPaymentSurgeMultiplier(),
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// APIKey lets a server-to-server client, such as a fleet's GPS units, call
+// the routes its Scopes allow. Key is only shown when the key is created;
+// Prefix, its first characters, tells keys apart afterwards.
+type APIKey struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Key       string     `json:"key,omitempty"`
+	Scopes    []string   `json:"scopes"`
+	CreatedBy int        `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// Hash is what the key is stored and looked up by.
+	Hash string `json:"-"`
+}
+
+// NewAPIKey is the body accepted by POST /api-keys.
+type NewAPIKey struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,unique,dive,oneof=location:write"`
+}
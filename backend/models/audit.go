@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Audited actions.
+const (
+	AuditCreate   = "create"
+	AuditUpdate   = "update"
+	AuditCancel   = "cancel"
+	AuditDelete   = "delete"
+	AuditValidate = "validate"
+	AuditBoard    = "board"
+	AuditTransfer = "transfer"
+)
+
+// Audited resources, which GET /audit filters by.
+const (
+	ResourceBus                 = "bus"
+	ResourceRouteFare           = "route_fare"
+	ResourceUser                = "user"
+	ResourceTicket              = "ticket"
+	ResourceWaitlistEntry       = "waitlist_entry"
+	ResourceAPIKey              = "api_key"
+	ResourceWebhookSubscription = "webhook_subscription"
+)
+
+// AuditResources lists every audited resource.
+var AuditResources = []string{
+	ResourceBus, ResourceRouteFare, ResourceUser, ResourceTicket, ResourceWaitlistEntry, ResourceAPIKey,
+	ResourceWebhookSubscription,
+}
+
+// AuditEntry records one change to a resource. ActorID is the user who made
+// it and APIKeyID the key it was made with; neither is set for changes the
+// service made on its own, such as promoting the waitlist.
+type AuditEntry struct {
+	ID         int                    `json:"id"`
+	ActorID    *int                   `json:"actor_id"`
+	APIKeyID   *int                   `json:"api_key_id,omitempty"`
+	Action     string                 `json:"action"`
+	Resource   string                 `json:"resource"`
+	ResourceID int                    `json:"resource_id"`
+	Changes    map[string]FieldChange `json:"changes"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// FieldChange is the value of one field before and after a change; From is
+// null for a field the change created, and To for one it removed.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
@@ -0,0 +1,229 @@
+// Package models holds the typed values the API accepts and returns.
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/schedule"
+)
+
+// Route is the ordered list of stops a bus calls at.
+type Route struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Stops []string `json:"stops"`
+}
+
+// Stop is a place buses call at, shared by every route through it. Names
+// are unique once normalized by StopKey.
+type Stop struct {
+	ID   int      `json:"id"`
+	Name string   `json:"name"`
+	Lat  *float64 `json:"lat"`
+	Lng  *float64 `json:"lng"`
+	// RadiusM is how close a bus must be to count as approaching the stop;
+	// nil means the geofence default.
+	RadiusM *float64 `json:"radius_m,omitempty"`
+}
+
+// RouteStop is a stop on a route with its coordinates, if known.
+type RouteStop struct {
+	StopID int      `json:"stop_id"`
+	Name   string   `json:"name"`
+	Lat    *float64 `json:"lat"`
+	Lng    *float64 `json:"lng"`
+	// RadiusM is how close a bus must be to count as approaching the stop;
+	// nil means the geofence default.
+	RadiusM *float64 `json:"radius_m,omitempty"`
+}
+
+// StopKey normalizes a stop name for matching, so "Airport", "airport" and
+// " Airport " are the same stop: it trims the name, collapses runs of
+// whitespace to single spaces and lowercases it. The stops migration keys
+// existing names the same way.
+func StopKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Amenities a bus may have.
+const (
+	AmenityWiFi       = "wifi"
+	AmenityCharging   = "charging"
+	AmenityAC         = "ac"
+	AmenityWheelchair = "wheelchair"
+)
+
+// Amenities lists every amenity, in the order clients show them.
+var Amenities = []string{AmenityWiFi, AmenityCharging, AmenityAC, AmenityWheelchair}
+
+// Bus is a single bus and the route it runs.
+type Bus struct {
+	ID       int   `json:"id"`
+	Route    Route `json:"route"`
+	Capacity int   `json:"capacity"`
+	// Model is the layout of the bus's seats.
+	Model BusModel `json:"model"`
+	// SeatFare is the price of one seat.
+	SeatFare money.Money `json:"seat_fare"`
+	// Class is one of standard, ac or sleeper and sets the per-km fare rate.
+	Class string `json:"class"`
+	// Amenities are those of Amenities the bus has.
+	Amenities []string `json:"amenities"`
+	// DepartureTime is when the bus leaves its first stop each day, as "HH:MM"
+	// in Timezone.
+	DepartureTime string `json:"departure_time"`
+	// RunDays is the recurrence rule for the days DepartureTime applies to:
+	// daily, weekdays, weekends or a list of days such as "mon,wed,fri".
+	RunDays string `json:"run_days"`
+	// Timezone is the IANA time zone the bus runs in.
+	Timezone string `json:"timezone"`
+	// Version counts updates to the bus; PUT requests must send the version
+	// they read.
+	Version int `json:"version"`
+	// DeletedAt is set once the bus has been decommissioned.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// AvgSpeedKmh is nil when the bus uses the service-wide default.
+	AvgSpeedKmh *float64 `json:"avg_speed_kmh,omitempty"`
+	// BookingCutoffMinutes is how long before departure bookings close; nil
+	// when the bus uses the service-wide default.
+	BookingCutoffMinutes *int `json:"booking_cutoff_minutes,omitempty"`
+}
+
+// Location returns the bus's time zone, or UTC if Timezone is not a known
+// zone.
+func (b Bus) Location() *time.Location {
+	loc, err := time.LoadLocation(b.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// Timetable returns the bus's recurring schedule. An unreadable RunDays is
+// taken as daily.
+func (b Bus) Timetable() schedule.Schedule {
+	days, err := schedule.ParseDays(b.RunDays)
+	if err != nil {
+		days, _ = schedule.ParseDays(schedule.Daily)
+	}
+
+	return schedule.Schedule{Departure: b.DepartureTime, Days: days, Loc: b.Location()}
+}
+
+// BusDetail is returned by GET /buses/{id}: the bus and how full it is on
+// OccupancyDate. Occupancy is the booked share of capacity as a percentage,
+// or null when the bus has no known capacity. DepartsAt is null when the bus
+// does not run that day.
+type BusDetail struct {
+	Bus
+	OccupancyDate string     `json:"occupancy_date"`
+	Runs          bool       `json:"runs"`
+	DepartsAt     *time.Time `json:"departs_at"`
+	BookedSeats   int        `json:"booked_seats"`
+	Occupancy     *float64   `json:"occupancy"`
+}
+
+// OccupancyPercent returns booked as a percentage of capacity to two
+// decimal places, or nil when the capacity is unknown.
+func OccupancyPercent(booked, capacity int) *float64 {
+	if capacity <= 0 {
+		return nil
+	}
+
+	pct := math.Round(float64(booked)/float64(capacity)*10000) / 100
+
+	return &pct
+}
+
+// NewBus is the body accepted by POST /buses.
+type NewBus struct {
+	// ID is the bus's fleet number.
+	ID      int `json:"id" validate:"required,min=1"`
+	RouteID int `json:"route_id" validate:"required,min=1"`
+	// ModelID is the bus model whose seat layout the bus has.
+	ModelID  int         `json:"model_id" validate:"required,min=1"`
+	Capacity int         `json:"capacity" validate:"required,min=1"`
+	SeatFare money.Money `json:"seat_fare" validate:"min=0"`
+	// Class defaults to standard.
+	Class         string   `json:"class,omitempty" validate:"omitempty,oneof=standard ac sleeper"`
+	Amenities     []string `json:"amenities,omitempty" validate:"omitempty,unique,dive,oneof=wifi charging ac wheelchair"`
+	DepartureTime string   `json:"departure_time" validate:"required,datetime=15:04"`
+	// RunDays is a recurrence rule as in Bus.RunDays; it defaults to daily.
+	RunDays string `json:"run_days,omitempty" validate:"omitempty,rundays"`
+	// Timezone defaults to UTC.
+	Timezone             string   `json:"timezone,omitempty" validate:"omitempty,timezone"`
+	AvgSpeedKmh          *float64 `json:"avg_speed_kmh,omitempty" validate:"omitempty,min=1"`
+	BookingCutoffMinutes *int     `json:"booking_cutoff_minutes,omitempty" validate:"omitempty,min=0"`
+}
+
+// Schedule is the body accepted by PUT /buses/{id}/schedule.
+type Schedule struct {
+	DepartureTime string `json:"departure_time" validate:"required,datetime=15:04"`
+	// RunDays is a recurrence rule as in Bus.RunDays; it defaults to daily.
+	RunDays string `json:"run_days,omitempty" validate:"omitempty,rundays"`
+	RouteID int    `json:"route_id" validate:"min=1"`
+	// Version is the bus version the change was made against.
+	Version int `json:"version" validate:"required,min=1"`
+	// BookingCutoffMinutes overrides the service-wide booking cutoff for
+	// the bus; omitting it restores the default.
+	BookingCutoffMinutes *int `json:"booking_cutoff_minutes,omitempty" validate:"omitempty,min=0"`
+}
+
+// ScheduleChange is returned by PUT /buses/{id}/schedule. AffectedTicketIDs
+// lists upcoming bookings made against the old schedule, which riders may
+// need to be told about; Warning explains them when there are any.
+type ScheduleChange struct {
+	Bus               Bus    `json:"bus"`
+	AffectedTicketIDs []int  `json:"affected_ticket_ids"`
+	Warning           string `json:"warning,omitempty"`
+}
+
+// BusSubstitution is the body accepted by POST /buses/{id}/substitute.
+// Date, as YYYY-MM-DD in the bus's time zone, limits the substitution to
+// that day's departures; without it every upcoming booking is moved.
+type BusSubstitution struct {
+	ReplacementBusID int    `json:"replacement_bus_id" validate:"required,min=1"`
+	Date             string `json:"date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// BusSubstitutionReport is returned by POST /buses/{id}/substitute.
+// Remapped lists the tickets moved to the replacement; Unmapped those it
+// had no room for, which were left on the original bus to be resolved by
+// hand.
+type BusSubstitutionReport struct {
+	BusID            int             `json:"bus_id"`
+	ReplacementBusID int             `json:"replacement_bus_id"`
+	Remapped         []SeatRemapping `json:"remapped"`
+	Unmapped         []SeatRemapping `json:"unmapped"`
+	Warning          string          `json:"warning,omitempty"`
+}
+
+// SeatRemapping is one ticket in a BusSubstitutionReport. ToSeats pairs
+// with FromSeats, seat for seat, and is empty for an unmapped ticket.
+type SeatRemapping struct {
+	TicketID   int       `json:"ticket_id"`
+	UserID     int       `json:"user_id"`
+	TravelDate time.Time `json:"travel_date"`
+	FromSeats  []int     `json:"from_seats"`
+	ToSeats    []int     `json:"to_seats,omitempty"`
+}
+
+// FareQuote is returned by GET /buses/{id}/fare.
+type FareQuote struct {
+	BusID      int     `json:"bus_id"`
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Class      string  `json:"class"`
+	DistanceKm float64 `json:"distance_km"`
+	RatePerKm  float64 `json:"rate_per_km"`
+	// Date is the departure priced; BaseFare is raised by SurgeMultiplier,
+	// for how full it is, to give Fare.
+	Date            string      `json:"date"`
+	BaseFare        money.Money `json:"base_fare"`
+	SurgeMultiplier float64     `json:"surge_multiplier"`
+	Fare            money.Money `json:"fare"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+// RouteFare fixes the seat fare of every bus on a route.
+type RouteFare struct {
+	RouteID  int         `json:"route_id"`
+	SeatFare money.Money `json:"seat_fare" validate:"min=0"`
+}
+
+// DiscountCode takes Percent per cent or a Flat sum off a booking, according
+// to Kind, until ExpiresAt or until it has been used MaxUses times. Nil
+// ExpiresAt and MaxUses mean no limit.
+type DiscountCode struct {
+	Code      string      `json:"code"`
+	Kind      string      `json:"kind"`
+	Percent   float64     `json:"percent,omitempty"`
+	Flat      money.Money `json:"flat,omitempty"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+	MaxUses   *int        `json:"max_uses,omitempty"`
+	Uses      int         `json:"uses"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+// JourneyLeg is one bus ride of a JourneyBooking, boarding at From and
+// leaving at To.
+type JourneyLeg struct {
+	BusID       int    `json:"bus_id" validate:"min=1"`
+	From        string `json:"from" validate:"required"`
+	To          string `json:"to" validate:"required"`
+	SeatNumbers []int  `json:"seat_numbers" validate:"min=1,unique,dive,min=1"`
+	TravelDate  string `json:"travel_date" validate:"required,traveldate"`
+	// Timezone reads a TravelDate without a UTC offset, as for a Booking.
+	Timezone string `json:"timezone,omitempty" validate:"omitempty,timezone"`
+}
+
+// JourneyBooking is the body accepted by POST /journeys/book: up to five
+// legs, each starting where the one before it ends.
+type JourneyBooking struct {
+	Legs []JourneyLeg `json:"legs" validate:"min=1,max=5,dive"`
+}
+
+// JourneyTicket is the ticket booked for one leg of a Journey.
+type JourneyTicket struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Ticket
+}
+
+// Journey groups the tickets of a trip that changes buses, in travel order.
+type Journey struct {
+	ID        int             `json:"journey_id"`
+	UserID    int             `json:"user_id"`
+	Legs      []JourneyTicket `json:"legs"`
+	TotalFare money.Money     `json:"total_fare"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,189 @@
+package models
+
+import "time"
+
+// LocationUpdate is a single reported position of a bus. It is also one
+// entry of the body of POST /bus/location/batch, where Timestamp defaults to
+// the time the batch is received.
+type LocationUpdate struct {
+	BusID     int       `json:"bus_id" validate:"min=1"`
+	Lat       float64   `json:"lat" validate:"min=-90,max=90"`
+	Lng       float64   `json:"lng" validate:"min=-180,max=180"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Signals of a bus's live location.
+const (
+	// SignalLive is a bus whose latest position arrived recently.
+	SignalLive = "live"
+	// SignalNone is a bus that has not reported a position for longer than
+	// the staleness threshold, so its latest one may be far behind it.
+	SignalNone = "no_recent_signal"
+)
+
+// EstimateSchedule is the Method of a LiveLocation placed along the bus's
+// route as far as its timetable and speed say it has got since departing.
+const EstimateSchedule = "schedule"
+
+// LiveLocation is a bus's latest position, returned by GET
+// /bus/location/{id}. LastSeen is when it was received, which Signal is
+// judged from.
+//
+// For a bus without a recent signal GET /bus/location/{id} may estimate the
+// position instead, if the bus should be on a trip. Estimated is then set,
+// Method says how it was worked out, LastSeen and the timestamp are when it
+// was, and LastReport is the last position the bus did report, if any.
+type LiveLocation struct {
+	LocationUpdate
+	LastSeen   time.Time       `json:"last_seen"`
+	Signal     string          `json:"signal"`
+	Estimated  bool            `json:"estimated"`
+	Method     string          `json:"method,omitempty"`
+	LastReport *LocationUpdate `json:"last_report,omitempty"`
+}
+
+// LocationReport is the body a bus's GPS unit sends to POST /bus/location/{id}.
+type LocationReport struct {
+	Lat float64 `json:"lat" validate:"min=-90,max=90"`
+	Lng float64 `json:"lng" validate:"min=-180,max=180"`
+	// Timestamp defaults to the time the report is received.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// LocationBatchResult is returned by POST /bus/location/batch.
+type LocationBatchResult struct {
+	Accepted   int             `json:"accepted"`
+	Rejected   int             `json:"rejected"`
+	Rejections []RejectedPoint `json:"rejections"`
+}
+
+// RejectedPoint says why the point at Index of a batch was not stored.
+type RejectedPoint struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
+
+// NearbyBus is one entry of GET /buses/nearby: a bus's latest position and
+// how far it is from the caller.
+type NearbyBus struct {
+	LiveLocation
+	DistanceMeters float64 `json:"distance_meters"`
+}
+
+// ActiveBus is one entry of GET /buses/active: a bus that has reported its
+// position lately, with today's occupancy and the next stop on its route.
+// NextStop is nil when the route has stops without coordinates.
+type ActiveBus struct {
+	LiveLocation
+	RouteID     int      `json:"route_id"`
+	RouteName   string   `json:"route_name"`
+	Capacity    int      `json:"capacity"`
+	BookedSeats int      `json:"booked_seats"`
+	Occupancy   *float64 `json:"occupancy"`
+	NextStop    *string  `json:"next_stop"`
+}
+
+// ETA is returned by GET /bus/{id}/eta for a stop the bus has yet to reach.
+type ETA struct {
+	BusID      int       `json:"bus_id"`
+	Stop       string    `json:"stop"`
+	Upcoming   bool      `json:"upcoming"`
+	DistanceKm float64   `json:"distance_km"`
+	SpeedKmh   float64   `json:"speed_kmh"`
+	ETAMinutes float64   `json:"eta_minutes"`
+	AsOf       time.Time `json:"as_of"`
+}
+
+// StopPassed is returned by GET /bus/{id}/eta when the bus has already
+// passed the stop on its current trip.
+type StopPassed struct {
+	BusID    int    `json:"bus_id"`
+	Stop     string `json:"stop"`
+	Upcoming bool   `json:"upcoming"`
+	Message  string `json:"message"`
+}
+
+// StopArrival records a bus reaching a stop on its route, against when its
+// timetable had it due.
+type StopArrival struct {
+	BusID       int
+	RouteID     int
+	StopID      int
+	Stop        string
+	ScheduledAt time.Time
+	ArrivedAt   time.Time
+}
+
+// StopDelay is how late buses on a route have reached one of its stops. The
+// average is null when too few arrivals have been recorded to say.
+type StopDelay struct {
+	Stop                string   `json:"stop"`
+	Samples             int      `json:"samples"`
+	AverageDelayMinutes *float64 `json:"average_delay_minutes"`
+}
+
+// RouteDelays is returned by GET /routes/{id}/delays, listing the route's
+// stops in travel order. Message explains a route without enough history.
+type RouteDelays struct {
+	RouteID int         `json:"route_id"`
+	Since   time.Time   `json:"since"`
+	Stops   []StopDelay `json:"stops"`
+	Message string      `json:"message,omitempty"`
+}
+
+// Types of BusEvent.
+const (
+	BusEventLocation  = "location"
+	BusEventOccupancy = "occupancy"
+)
+
+// BusEvent is one message on the GET /ws/bus/location/{id} WebSocket. Type
+// says which of Location and Occupancy it carries.
+type BusEvent struct {
+	Type      string           `json:"type"`
+	Location  *LocationUpdate  `json:"location,omitempty"`
+	Occupancy *OccupancyUpdate `json:"occupancy,omitempty"`
+}
+
+// OccupancyUpdate is how full a bus's departure on Date (YYYY-MM-DD, in the
+// bus's time zone) is, as in BusDetail, and how many of its riders have
+// boarded. The changes are since the previous update for the departure,
+// and zero in the first the server sends for it.
+type OccupancyUpdate struct {
+	BusID         int       `json:"bus_id"`
+	Date          string    `json:"date"`
+	Capacity      int       `json:"capacity"`
+	BookedSeats   int       `json:"booked_seats"`
+	BoardedSeats  int       `json:"boarded_seats"`
+	Occupancy     *float64  `json:"occupancy"`
+	BookedChange  int       `json:"booked_change"`
+	BoardedChange int       `json:"boarded_change"`
+	At            time.Time `json:"at"`
+}
+
+// Types of SeatEvent.
+const (
+	SeatEventSnapshot = "snapshot"
+	SeatEventUpdate   = "update"
+)
+
+// SeatEvent is one message on the GET /ws/buses/{id}/seats WebSocket. The
+// first is a snapshot carrying the departure's whole SeatMap; every one
+// after it is an update to apply to it.
+type SeatEvent struct {
+	Type    string      `json:"type"`
+	SeatMap *SeatMap    `json:"seat_map,omitempty"`
+	Update  *SeatUpdate `json:"update,omitempty"`
+}
+
+// SeatUpdate lists the seats of a bus's departure on Date (YYYY-MM-DD, in
+// the bus's time zone) that changed since the previous message: Taken have
+// been booked or held, and Freed released by a cancellation or a hold
+// running out.
+type SeatUpdate struct {
+	BusID int       `json:"bus_id"`
+	Date  string    `json:"date"`
+	Taken []int     `json:"taken"`
+	Freed []int     `json:"freed"`
+	At    time.Time `json:"at"`
+}
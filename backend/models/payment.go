@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+// Payment statuses. A pending payment becomes succeeded, with a booked
+// ticket, or failed when the provider reports on it, and expired if its hold
+// runs out first.
+const (
+	PaymentPending   = "pending"
+	PaymentSucceeded = "succeeded"
+	PaymentFailed    = "failed"
+	PaymentExpired   = "expired"
+)
+
+// Payment event types sent to POST /payments/webhook. Others are
+// acknowledged and ignored.
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+)
+
+// PaymentIntentRequest is the body accepted by POST /payments/intent.
+type PaymentIntentRequest struct {
+	HoldToken string `json:"hold_token" validate:"required"`
+	// DiscountCode names a DiscountCode to take off the amount.
+	DiscountCode string `json:"discount_code,omitempty" validate:"omitempty,max=64"`
+}
+
+// Payment pays for the seats of a SeatHold. ClientSecret is what the client
+// completes the payment with at the provider; TicketID is set once the
+// payment has succeeded and the seats are booked.
+type Payment struct {
+	ID            int         `json:"payment_id"`
+	IntentID      string      `json:"intent_id"`
+	ClientSecret  string      `json:"client_secret,omitempty"`
+	UserID        int         `json:"user_id"`
+	HoldToken     string      `json:"hold_token"`
+	Amount        money.Money `json:"amount"`
+	Discount      money.Money `json:"discount,omitempty"`
+	DiscountCode  string      `json:"discount_code,omitempty"`
+	Status        string      `json:"status"`
+	FailureReason string      `json:"failure_reason,omitempty"`
+	TicketID      *int        `json:"ticket_id,omitempty"`
+	ExpiresAt     time.Time   `json:"expires_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+	// SurgeMultiplier is what Amount was raised by, which the ticket the
+	// payment books keeps.
+	SurgeMultiplier float64 `json:"-"`
+}
+
+// PaymentEvent is the body the payment provider sends to POST
+// /payments/webhook. ID is unique per event and repeated on retries.
+type PaymentEvent struct {
+	ID       string `json:"id" validate:"required"`
+	Type     string `json:"type" validate:"required"`
+	IntentID string `json:"intent_id" validate:"required"`
+	// Reason explains a failed payment.
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,127 @@
+package models
+
+// Seat types. A booking may also ask for SeatAny.
+const (
+	SeatWindow = "window"
+	SeatAisle  = "aisle"
+	SeatAny    = "any"
+)
+
+// BusModel is a seat layout shared by every bus built to it.
+type BusModel struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// SeatsLeft and SeatsRight are how many seats or berths each row has
+	// either side of the aisle: 2 and 2 for a 2+2 layout.
+	SeatsLeft  int `json:"seats_left"`
+	SeatsRight int `json:"seats_right"`
+	// Decks is 2 for a bus with a lower and an upper deck.
+	Decks int `json:"decks"`
+	// Berths is set for sleepers, which have berths rather than seats.
+	Berths bool `json:"berths"`
+}
+
+// Columns returns how many seats make up a row.
+func (m BusModel) Columns() int {
+	if c := m.SeatsLeft + m.SeatsRight; c > 0 {
+		return c
+	}
+
+	return 1
+}
+
+// PerDeck returns how many of capacity seats are on each deck.
+func (m BusModel) PerDeck(capacity int) int {
+	decks := m.Decks
+	if decks < 1 {
+		decks = 1
+	}
+
+	if n := (capacity + decks - 1) / decks; n > 0 {
+		return n
+	}
+
+	return 1
+}
+
+// Place returns the deck, row and column of seat n on a bus of capacity
+// seats built to m. Seats are numbered from 1, left to right and then front
+// to back, filling the lower deck before the upper one.
+func (m BusModel) Place(n, capacity int) (deck, row, column int) {
+	perDeck, columns := m.PerDeck(capacity), m.Columns()
+	i := (n - 1) % perDeck
+
+	return (n-1)/perDeck + 1, i/columns + 1, i%columns + 1
+}
+
+// SeatType returns whether seat n on a bus of capacity seats built to m is
+// a window or an aisle seat. The outermost columns are window seats; the
+// rest are aisle seats.
+func (m BusModel) SeatType(n, capacity int) string {
+	if _, _, col := m.Place(n, capacity); col == 1 || col == m.Columns() {
+		return SeatWindow
+	}
+
+	return SeatAisle
+}
+
+// Seat is one seat on a bus and whether it can be booked for a travel date.
+type Seat struct {
+	Number    int    `json:"number"`
+	Deck      int    `json:"deck"`
+	Row       int    `json:"row"`
+	Column    int    `json:"column"`
+	Type      string `json:"type"`
+	Available bool   `json:"available"`
+}
+
+// SeatMap is the layout of a bus for a travel date, laid out by its model.
+// Rows counts the rows of each deck, and the aisle runs after column
+// AisleAfter.
+type SeatMap struct {
+	BusID      int    `json:"bus_id"`
+	TravelDate string `json:"travel_date"`
+	Model      string `json:"model"`
+	Decks      int    `json:"decks"`
+	Berths     bool   `json:"berths"`
+	Rows       int    `json:"rows"`
+	Columns    int    `json:"columns"`
+	AisleAfter int    `json:"aisle_after"`
+	Seats      []Seat `json:"seats"`
+}
+
+// NewSeatMap lays out capacity seats as model places them, marking those in
+// booked as unavailable. The last row of a deck may be partly empty.
+func NewSeatMap(capacity int, model BusModel, booked []int) SeatMap {
+	taken := make(map[int]bool, len(booked))
+	for _, n := range booked {
+		taken[n] = true
+	}
+
+	perDeck, columns := model.PerDeck(capacity), model.Columns()
+
+	m := SeatMap{
+		Model:      model.Name,
+		Decks:      (capacity + perDeck - 1) / perDeck,
+		Berths:     model.Berths,
+		Rows:       (perDeck + columns - 1) / columns,
+		Columns:    columns,
+		AisleAfter: model.SeatsLeft,
+		Seats:      make([]Seat, 0, capacity),
+	}
+
+	for n := 1; n <= capacity; n++ {
+		deck, row, col := model.Place(n, capacity)
+
+		m.Seats = append(m.Seats, Seat{
+			Number:    n,
+			Deck:      deck,
+			Row:       row,
+			Column:    col,
+			Type:      model.SeatType(n, capacity),
+			Available: !taken[n],
+		})
+	}
+
+	return m
+}
@@ -0,0 +1,484 @@
+package models
+
+import (
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+// Ticket statuses.
+const (
+	StatusBooked    = "booked"
+	StatusValidated = "validated"
+	StatusCancelled = "cancelled"
+)
+
+// StatusBoarded is never stored. GET /buses/{id}/tickets reports a booked
+// or validated ticket as boarded once any of its riders has boarded.
+const StatusBoarded = "boarded"
+
+// Ticket is a booking of one or more seats on a bus for a travel date.
+type Ticket struct {
+	ID          int   `json:"ticket_id"`
+	UserID      int   `json:"user_id"`
+	BusID       int   `json:"bus_id"`
+	SeatNumbers []int `json:"seat_numbers"`
+	// TravelDate is stored in UTC and shown in Timezone, the bus's zone.
+	TravelDate time.Time `json:"travel_date"`
+	Timezone   string    `json:"timezone,omitempty"`
+	Status     string    `json:"status"`
+	// Fare is the total paid for all seats on the ticket, after Discount.
+	Fare         money.Money `json:"fare"`
+	OriginalFare money.Money `json:"original_fare"`
+	Discount     money.Money `json:"discount"`
+	// BaseFare is the seats' fare before SurgeMultiplier, fixed when the
+	// ticket was booked, raised it to OriginalFare.
+	BaseFare        money.Money `json:"base_fare"`
+	SurgeMultiplier float64     `json:"surge_multiplier"`
+	DiscountCode    string      `json:"discount_code,omitempty"`
+	// CancelledAt is set once the ticket has been cancelled.
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	// IdempotencyKey is the client-supplied key the ticket was booked with.
+	IdempotencyKey string `json:"-"`
+	// HoldToken is the seat hold the ticket confirms, if any.
+	HoldToken string `json:"-"`
+	// SeatCount seats of SeatPreference are picked when booking a ticket
+	// without SeatNumbers. Unless StrictSeats is set, other free seats make
+	// up any shortfall, which SeatWarning then explains.
+	SeatCount      int    `json:"-"`
+	SeatPreference string `json:"-"`
+	StrictSeats    bool   `json:"-"`
+	// SeatLimit caps the seats UserID may hold on the departure, counting
+	// those of their other tickets; zero lifts the cap.
+	SeatLimit   int    `json:"-"`
+	SeatWarning string `json:"seat_warning,omitempty"`
+	// Passengers are who rides the seats, when the booking named them.
+	Passengers []Passenger `json:"passengers,omitempty"`
+	// ReturnTicketID is the other leg of the round trip the ticket was
+	// booked as part of, if any.
+	ReturnTicketID *int `json:"return_ticket_id,omitempty"`
+	// Message confirms a booking to the rider, in their language.
+	Message string `json:"message,omitempty"`
+	// QRVersion is signed into the ticket's QR codes. A transfer raises
+	// it, so codes signed before no longer validate.
+	QRVersion int `json:"-"`
+}
+
+// InLocalTime returns t with TravelDate in t's Timezone, for display.
+func (t Ticket) InLocalTime() Ticket {
+	if loc, err := time.LoadLocation(t.Timezone); err == nil {
+		t.TravelDate = t.TravelDate.In(loc)
+	}
+
+	return t
+}
+
+// TripTicket is one entry of a user's booking history: a ticket with the
+// bus and route it is for.
+type TripTicket struct {
+	Ticket
+	RouteName     string `json:"route_name"`
+	BusClass      string `json:"bus_class"`
+	DepartureTime string `json:"departure_time"`
+}
+
+// Booking is the body accepted by POST /tickets/book. UserID may be omitted,
+// in which case the authenticated user is booked. A booking either names
+// the bus, seats and date itself or gives the HoldToken of a SeatHold, whose
+// seats it then confirms. Instead of SeatNumbers it may give a SeatCount to
+// have that many free seats picked, of SeatPreference if given; Strict turns
+// a shortage of such seats into a failure rather than a warning. A
+// TravelDate without a UTC offset is read in Timezone, or in the bus's zone
+// when that is omitted too.
+type Booking struct {
+	UserID         int    `json:"user_id" validate:"omitempty,min=1"`
+	BusID          int    `json:"bus_id" validate:"required_without=HoldToken,excluded_with=HoldToken,omitempty,min=1"`
+	SeatNumbers    []int  `json:"seat_numbers" validate:"required_without_all=HoldToken SeatCount,excluded_with=HoldToken SeatCount,omitempty,min=1,unique,dive,min=1"`
+	SeatCount      int    `json:"seat_count,omitempty" validate:"excluded_with=HoldToken,omitempty,min=1"`
+	SeatPreference string `json:"seat_preference,omitempty" validate:"excluded_without=SeatCount,omitempty,oneof=window aisle any"`
+	Strict         bool   `json:"strict,omitempty" validate:"excluded_without=SeatCount"`
+	TravelDate     string `json:"travel_date" validate:"required_without=HoldToken,excluded_with=HoldToken,omitempty,traveldate"`
+	Timezone       string `json:"timezone,omitempty" validate:"excluded_with=HoldToken,omitempty,timezone"`
+	HoldToken      string `json:"hold_token,omitempty"`
+	// DiscountCode names a DiscountCode to take off the fare.
+	DiscountCode string `json:"discount_code,omitempty" validate:"omitempty,max=64"`
+	// Passengers, if given, name one rider per seat, in the order of
+	// SeatNumbers or of the seats picked for SeatCount.
+	Passengers []Passenger `json:"passengers,omitempty" validate:"omitempty,dive"`
+}
+
+// Passenger is who rides one seat of a ticket, which need not be the user
+// who booked it. SeatNumber is filled in when the ticket is booked.
+type Passenger struct {
+	SeatNumber int    `json:"seat_number,omitempty"`
+	Name       string `json:"name" validate:"required,max=100"`
+	Age        int    `json:"age" validate:"gt=0,max=120"`
+	Gender     string `json:"gender,omitempty" validate:"omitempty,oneof=female male other"`
+}
+
+// Layouts accepted for a travel date given without a UTC offset.
+var localTravelLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// ParseTravelDate parses an RFC3339 travel date, or one without a UTC offset
+// as local time in loc, and returns it in UTC.
+func ParseTravelDate(s string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t.UTC(), nil
+	}
+
+	for _, layout := range localTravelLayouts {
+		if local, lerr := time.ParseInLocation(layout, s, loc); lerr == nil {
+			return local.UTC(), nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// Ticket returns the booked ticket the booking asks for, reading a local
+// TravelDate in loc. It assumes the booking has passed validation.
+func (b Booking) Ticket(loc *time.Location) Ticket {
+	travel, _ := ParseTravelDate(b.TravelDate, loc)
+
+	return Ticket{
+		UserID:      b.UserID,
+		BusID:       b.BusID,
+		SeatNumbers: b.SeatNumbers,
+		TravelDate:  travel,
+		Status:      StatusBooked,
+		HoldToken:   b.HoldToken,
+		// Seats are picked, if asked for, once the bus is locked.
+		SeatCount:      b.SeatCount,
+		SeatPreference: b.SeatPreference,
+		StrictSeats:    b.Strict,
+		// The code is checked, and the discount worked out, when pricing.
+		DiscountCode: b.DiscountCode,
+		Passengers:   b.Passengers,
+	}
+}
+
+// Hold is the body accepted by POST /tickets/hold.
+type Hold struct {
+	BusID       int    `json:"bus_id" validate:"min=1"`
+	SeatNumbers []int  `json:"seat_numbers" validate:"min=1,unique,dive,min=1"`
+	TravelDate  string `json:"travel_date" validate:"required,rfc3339"`
+}
+
+// SeatHold reserves seats for a user until ExpiresAt, when they are released
+// unless a booking has confirmed them with Token.
+type SeatHold struct {
+	Token       string    `json:"hold_token"`
+	UserID      int       `json:"user_id"`
+	BusID       int       `json:"bus_id"`
+	SeatNumbers []int     `json:"seat_numbers"`
+	TravelDate  time.Time `json:"travel_date"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// SeatLimit caps the seats UserID may hold and book on the departure,
+	// as Ticket.SeatLimit does.
+	SeatLimit int `json:"-"`
+}
+
+// SeatConflict accompanies a 409 from POST /tickets/book. Waitlist is set
+// when the bus is fully booked, pointing at where to queue for seats.
+type SeatConflict struct {
+	UnavailableSeats []int  `json:"unavailable_seats"`
+	Waitlist         string `json:"waitlist,omitempty"`
+}
+
+// BulkBooking is returned by a successful POST /tickets/book/bulk.
+type BulkBooking struct {
+	TicketIDs []int    `json:"ticket_ids"`
+	Tickets   []Ticket `json:"tickets"`
+}
+
+// ReturnBooking is the body accepted by POST /tickets/book/return: a
+// booking each way, the inbound one departing no earlier than the outbound.
+// Neither may give a HoldToken.
+type ReturnBooking struct {
+	Outbound Booking `json:"outbound"`
+	Inbound  Booking `json:"inbound"`
+}
+
+// ReturnTrip is returned by a successful POST /tickets/book/return. Each
+// ticket already has ReturnDiscount, the round-trip discount, taken off its
+// fare; TotalFare is what the two cost together.
+type ReturnTrip struct {
+	Outbound       Ticket      `json:"outbound"`
+	Inbound        Ticket      `json:"inbound"`
+	TotalFare      money.Money `json:"total_fare"`
+	ReturnDiscount money.Money `json:"return_discount"`
+}
+
+// BulkFailure accompanies the error when one entry of a bulk booking fails;
+// no tickets are created in that case.
+type BulkFailure struct {
+	Index            int    `json:"index"`
+	Reason           string `json:"reason"`
+	UnavailableSeats []int  `json:"unavailable_seats,omitempty"`
+}
+
+// Cancellation is returned by POST /tickets/{id}/cancel and, with the seats
+// given up, by POST /tickets/{id}/cancel-seats. Ticket is what remains.
+// Return is the cancellation of the other leg of a round trip, when that
+// was asked for too.
+type Cancellation struct {
+	Ticket
+	CancelledSeats []int         `json:"cancelled_seats,omitempty"`
+	RefundAmount   money.Money   `json:"refund_amount"`
+	RefundReason   string        `json:"refund_reason"`
+	Return         *Cancellation `json:"return,omitempty"`
+}
+
+// TicketChange is the body accepted by POST /tickets/{id}/change: the
+// seats, bus or travel date to move the ticket to. Whatever it leaves out
+// stays as it is, so a ticket moved to another departure keeps its seat
+// numbers unless new ones are given. A TravelDate without a UTC offset is
+// read in Timezone, or in the bus's zone when that is omitted too.
+// Passengers, if given, replace those the ticket names, one per seat; they
+// must be given to change the number of seats of a ticket that names them.
+type TicketChange struct {
+	BusID       int         `json:"bus_id,omitempty" validate:"required_without_all=SeatNumbers TravelDate,omitempty,min=1"`
+	SeatNumbers []int       `json:"seat_numbers,omitempty" validate:"omitempty,min=1,unique,dive,min=1"`
+	TravelDate  string      `json:"travel_date,omitempty" validate:"omitempty,traveldate"`
+	Timezone    string      `json:"timezone,omitempty" validate:"omitempty,timezone"`
+	Passengers  []Passenger `json:"passengers,omitempty" validate:"omitempty,dive"`
+}
+
+// TicketChangeResult is returned by POST /tickets/{id}/change: the ticket
+// as it now is, and FareDelta, what the rider owes for the change, or is
+// refunded when negative.
+type TicketChangeResult struct {
+	Ticket
+	FareDelta money.Money `json:"fare_delta"`
+}
+
+// TicketTransfer is the body accepted by POST /tickets/{id}/transfer: the
+// user to give the ticket to, by ID or by email.
+type TicketTransfer struct {
+	UserID int    `json:"user_id,omitempty" validate:"required_without=Email,excluded_with=Email,omitempty,min=1"`
+	Email  string `json:"email,omitempty" validate:"omitempty,email,max=254"`
+}
+
+// TicketTransferResult is returned by POST /tickets/{id}/transfer: the
+// ticket, now the new owner's, and who it was taken from. The new owner
+// gets a fresh QR code from GET /tickets/{id}/qr; the old one is refused.
+type TicketTransferResult struct {
+	Ticket
+	FromUserID int `json:"from_user_id"`
+}
+
+// SeatCancellation is the body accepted by POST /tickets/{id}/cancel-seats.
+type SeatCancellation struct {
+	SeatNumbers []int `json:"seat_numbers" validate:"min=1,unique,dive,min=1"`
+}
+
+// Validation is the body accepted by POST /tickets/validate. Payload is the
+// signed text decoded from the ticket's QR code.
+type Validation struct {
+	Payload string `json:"payload" validate:"required"`
+	// DeviceID identifies the scanner, if it reports one.
+	DeviceID string `json:"device_id,omitempty" validate:"omitempty,max=100"`
+}
+
+// Boarding is the body accepted by POST /tickets/{id}/board.
+type Boarding struct {
+	// SeatNumbers are the seats whose riders boarded; omitting them boards
+	// every seat on the ticket.
+	SeatNumbers []int `json:"seat_numbers,omitempty" validate:"omitempty,unique,dive,min=1"`
+	// BoardedAt defaults to now.
+	BoardedAt time.Time `json:"boarded_at,omitempty"`
+	// Lat and Lng are where the bus was; omitting them takes its latest
+	// reported position, if any.
+	Lat *float64 `json:"lat,omitempty" validate:"required_with=Lng,omitempty,min=-90,max=90"`
+	Lng *float64 `json:"lng,omitempty" validate:"required_with=Lat,omitempty,min=-180,max=180"`
+}
+
+// BoardedSeat is a seat whose rider has boarded, and when, where and by
+// whom that was recorded.
+type BoardedSeat struct {
+	SeatNumber int       `json:"seat_number"`
+	BoardedAt  time.Time `json:"boarded_at"`
+	BoardedBy  *int      `json:"boarded_by,omitempty"`
+	Lat        *float64  `json:"lat,omitempty"`
+	Lng        *float64  `json:"lng,omitempty"`
+}
+
+// BoardingResult is returned by POST /tickets/{id}/board. Boarded lists the
+// seats the request boarded and AlreadyBoarded those it asked for that had
+// been already, which keep their first boarding. Seats lists every boarded
+// seat on the ticket.
+type BoardingResult struct {
+	TicketID       int           `json:"ticket_id"`
+	Boarded        []int         `json:"boarded"`
+	AlreadyBoarded []int         `json:"already_boarded"`
+	Seats          []BoardedSeat `json:"seats"`
+}
+
+// Ride is a boarded seat of a departure, with the stops its ticket was
+// booked between when it is a leg of a journey, and "" otherwise.
+type Ride struct {
+	TicketID int `json:"ticket_id"`
+	BoardedSeat
+	FromStop string `json:"from_stop,omitempty"`
+	ToStop   string `json:"to_stop,omitempty"`
+}
+
+// StopCount is how many riders boarded and alighted at a stop.
+type StopCount struct {
+	StopID   int    `json:"stop_id"`
+	Name     string `json:"name"`
+	Boarded  int    `json:"boarded"`
+	Alighted int    `json:"alighted"`
+}
+
+// StopCounts is returned by GET /buses/{id}/stop-counts: the riders who
+// boarded and alighted at each stop of the route, in travel order, on the
+// bus's departure on Date. Riders who could not be placed at a stop are
+// counted apart: UnplacedBoardings boarded with no known position, and
+// UnknownAlightings were not booked to a stop.
+type StopCounts struct {
+	BusID             int         `json:"bus_id"`
+	Date              string      `json:"date"`
+	Stops             []StopCount `json:"stops"`
+	UnplacedBoardings int         `json:"unplaced_boardings"`
+	UnknownAlightings int         `json:"unknown_alightings"`
+}
+
+// NoShow is a booked seat whose rider had not boarded by the end of the
+// grace period after departure. Released is set when the seat was then
+// freed for riders boarding further along the route, and taken off the
+// ticket.
+type NoShow struct {
+	TicketID   int       `json:"ticket_id"`
+	UserID     int       `json:"user_id"`
+	BusID      int       `json:"bus_id"`
+	SeatNumber int       `json:"seat_number"`
+	TravelDate time.Time `json:"travel_date"`
+	// Validated is set when the ticket had been validated.
+	Validated bool      `json:"validated"`
+	FlaggedAt time.Time `json:"flagged_at"`
+	Released  bool      `json:"released"`
+}
+
+// NoShowReport is returned by GET /buses/{id}/no-shows: the no-shows on the
+// bus's departure on Date, in its time zone.
+type NoShowReport struct {
+	BusID    int      `json:"bus_id"`
+	Date     string   `json:"date"`
+	Total    int      `json:"total"`
+	Released int      `json:"released"`
+	NoShows  []NoShow `json:"no_shows"`
+}
+
+// TripTickets is returned by GET /buses/{id}/tickets: the tickets for a
+// departure, only those of Status if one was asked for, and Summary
+// counting all of them by status.
+type TripTickets struct {
+	BusID   int                `json:"bus_id"`
+	Date    string             `json:"date"`
+	Status  string             `json:"status,omitempty"`
+	Summary TicketStatusCounts `json:"summary"`
+	Tickets []Ticket           `json:"tickets"`
+}
+
+// TicketStatusCounts counts the tickets of a departure in each status, a
+// ticket being boarded rather than booked or validated once any of its
+// riders has boarded.
+type TicketStatusCounts struct {
+	Total     int `json:"total"`
+	Booked    int `json:"booked"`
+	Validated int `json:"validated"`
+	Boarded   int `json:"boarded"`
+	Cancelled int `json:"cancelled"`
+}
+
+// BookingBucket counts the bookings made in [Start, End) and, in
+// Cumulative, all those made up to End.
+type BookingBucket struct {
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Bookings   int       `json:"bookings"`
+	Cumulative int       `json:"cumulative"`
+}
+
+// BookingRate is returned by GET /buses/{id}/booking-rate: how the bookings
+// for the bus's departure on Date, in its time zone, were made over time.
+// DepartsAt is null when the bus does not run that day.
+type BookingRate struct {
+	BusID     int             `json:"bus_id"`
+	Date      string          `json:"date"`
+	Bucket    string          `json:"bucket"`
+	DepartsAt *time.Time      `json:"departs_at"`
+	Total     int             `json:"total"`
+	Series    []BookingBucket `json:"series"`
+}
+
+// ValidationResult is returned by POST /tickets/validate. A ticket scanned
+// again once validated is not valid: AlreadyValidated is set, and
+// ValidatedAt, ValidatedBy and DeviceID describe the first scan.
+type ValidationResult struct {
+	TicketID         int        `json:"ticket_id"`
+	Valid            bool       `json:"valid"`
+	Status           string     `json:"status"`
+	AlreadyValidated bool       `json:"already_validated"`
+	ValidatedAt      *time.Time `json:"validated_at,omitempty"`
+	ValidatedBy      *int       `json:"validated_by,omitempty"`
+	DeviceID         string     `json:"device_id,omitempty"`
+	// Passengers are those the scanned code names, for the conductor to
+	// check riders against.
+	Passengers []Passenger `json:"passengers,omitempty"`
+	// Revoked is set for a code the ticket no longer accepts, such as one
+	// printed before the ticket was transferred.
+	Revoked bool   `json:"revoked,omitempty"`
+	Message string `json:"message"`
+}
+
+// Fare split statuses.
+const (
+	SplitPending = "pending"
+	SplitSettled = "settled"
+)
+
+// FareSplit is the share of a ticket's fare that one user owes. The
+// booker's own share, if they take one, is settled from the start, since
+// they paid for the ticket.
+type FareSplit struct {
+	UserID    int         `json:"user_id"`
+	Amount    money.Money `json:"amount"`
+	Status    string      `json:"status"`
+	SettledAt *time.Time  `json:"settled_at,omitempty"`
+}
+
+// SplitRequest is the body accepted by POST /tickets/{id}/split. Its
+// portions must add up to the ticket's fare; they replace any split made
+// before, which none of the other riders may have settled yet.
+type SplitRequest struct {
+	Portions []SplitPortion `json:"portions" validate:"required,min=1,max=50,unique=UserID,dive"`
+}
+
+// SplitPortion assigns Amount of a ticket's fare to a user.
+type SplitPortion struct {
+	UserID int         `json:"user_id" validate:"required,min=1"`
+	Amount money.Money `json:"amount" validate:"gt=0"`
+}
+
+// TicketDetail is returned by GET /tickets/{id}: the ticket with how its
+// fare is split, if it is. Paid is set once every share is settled, and
+// always for a ticket that is not split.
+type TicketDetail struct {
+	Ticket
+	Splits []FareSplit `json:"splits"`
+	Paid   bool        `json:"paid"`
+}
+
+// SplitsPaid reports whether every one of splits is settled.
+func SplitsPaid(splits []FareSplit) bool {
+	for _, s := range splits {
+		if s.Status != SplitSettled {
+			return false
+		}
+	}
+
+	return true
+}
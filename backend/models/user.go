@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// User roles. Conductors may also validate tickets, and admins may do that
+// and manage buses and their schedules.
+const (
+	RoleRider     = "rider"
+	RoleConductor = "conductor"
+	RoleAdmin     = "admin"
+)
+
+// User is an account that can sign in and book tickets.
+type User struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	Phone        string `json:"phone,omitempty"`
+	PasswordHash string `json:"-"`
+}
+
+// Registration is the body accepted by POST /users. Password is capped at
+// the 72 bytes bcrypt looks at.
+type Registration struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8,max=72" sanitize:"notrim"`
+}
+
+// ProfileUpdate is the body accepted by PATCH /users/{id}. Only the fields
+// given are changed; a phone given as "" is removed.
+type ProfileUpdate struct {
+	Name  *string `json:"name" validate:"omitempty,notblank,max=100"`
+	Email *string `json:"email" validate:"omitempty,email"`
+	Phone *string `json:"phone" validate:"omitempty,phone"`
+}
+
+// Login is the body accepted by POST /auth/login.
+type Login struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required" sanitize:"notrim"`
+}
+
+// Token is returned by a successful login.
+type Token struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
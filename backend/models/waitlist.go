@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Waitlist entry statuses.
+const (
+	WaitlistWaiting  = "waiting"
+	WaitlistPromoted = "promoted"
+)
+
+// WaitlistRequest is the body accepted by POST /tickets/waitlist. Unlike a
+// Booking it asks for a number of seats, which are assigned on promotion.
+type WaitlistRequest struct {
+	BusID      int    `json:"bus_id" validate:"min=1"`
+	Seats      int    `json:"seats" validate:"min=1"`
+	TravelDate string `json:"travel_date" validate:"required,rfc3339"`
+}
+
+// WaitlistEntry is a user's place in the queue for seats on a fully booked
+// bus. Once seats free up it is promoted to the booked ticket TicketID.
+type WaitlistEntry struct {
+	ID         int       `json:"waitlist_id"`
+	UserID     int       `json:"user_id"`
+	BusID      int       `json:"bus_id"`
+	Seats      int       `json:"seats"`
+	TravelDate time.Time `json:"travel_date"`
+	Status     string    `json:"status"`
+	// Position is the entry's place in its queue, 1 being next, while it is
+	// waiting.
+	Position int       `json:"position,omitempty"`
+	TicketID *int      `json:"ticket_id,omitempty"`
+	JoinedAt time.Time `json:"joined_at"`
+}
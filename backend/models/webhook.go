@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Booking events third parties may subscribe to.
+const (
+	EventBookingCreated    = "booking.created"
+	EventTicketCancelled   = "ticket.cancelled"
+	EventTicketValidated   = "ticket.validated"
+	EventTicketChanged     = "ticket.changed"
+	EventTicketTransferred = "ticket.transferred"
+)
+
+// WebhookSubscription is a third party's request to be sent events to URL.
+// Secret signs every delivery; it is only shown when the subscription is
+// created.
+type WebhookSubscription struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedBy int       `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewWebhookSubscription is the body accepted by POST /webhooks/subscriptions.
+type NewWebhookSubscription struct {
+	URL    string   `json:"url" validate:"required,http_url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,unique,dive,oneof=booking.created ticket.cancelled ticket.validated ticket.changed ticket.transferred"`
+}
+
+// WebhookEvent is the JSON body delivered to subscribers. ID is the same for
+// every attempt at delivering it, so receivers can ignore repeats.
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookDelivery is one event due to be sent to a subscription, with
+// Attempts made so far.
+type WebhookDelivery struct {
+	ID             int
+	SubscriptionID int
+	URL            string
+	Secret         string
+	Event          string
+	Payload        json.RawMessage
+	Attempts       int
+}
@@ -0,0 +1,251 @@
+// Package money holds amounts of money as whole minor units of a currency,
+// such as paise or cents, so that pricing, splitting and refunding fares is
+// exact, and formats them for display.
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency: its code, the symbol amounts in it are
+// shown with and how many decimal places its minor unit takes.
+type Currency struct {
+	Code     string
+	Symbol   string
+	Exponent int
+}
+
+// currencies are those Lookup knows the symbol and minor unit of.
+var currencies = map[string]Currency{
+	"INR": {Code: "INR", Symbol: "₹", Exponent: 2},
+	"USD": {Code: "USD", Symbol: "$", Exponent: 2},
+	"EUR": {Code: "EUR", Symbol: "€", Exponent: 2},
+	"GBP": {Code: "GBP", Symbol: "£", Exponent: 2},
+	"JPY": {Code: "JPY", Symbol: "¥", Exponent: 0},
+}
+
+// DefaultCurrency is the currency amounts are in unless SetCurrency says
+// otherwise.
+var DefaultCurrency = currencies["INR"]
+
+var current = DefaultCurrency
+
+// Lookup returns the currency with the given code, or false if it is not
+// one the package knows.
+func Lookup(code string) (Currency, bool) {
+	c, ok := currencies[strings.ToUpper(code)]
+	return c, ok
+}
+
+// SetCurrency makes c the currency of every Money. The service deals in one
+// currency, so this is called once, at startup, before any amounts are
+// handled.
+func SetCurrency(c Currency) {
+	current = c
+}
+
+// Current returns the currency amounts are in.
+func Current() Currency {
+	return current
+}
+
+// ErrInvalid is returned for text that is not a decimal amount.
+var ErrInvalid = errors.New("money: invalid amount")
+
+// Money is an amount in minor units of the current currency. It is stored
+// as a decimal of major units, and rendered in JSON as
+// {"minor_units": 12550, "currency": "INR", "display": "₹125.50"}.
+type Money int64
+
+// FromMajor returns v major units, rounded to the nearest minor unit. It is
+// for rates and amounts configured as plain numbers.
+func FromMajor(v float64) Money {
+	return Money(math.Round(v * scale()))
+}
+
+// Major returns m in major units.
+func (m Money) Major() float64 {
+	return float64(m) / scale()
+}
+
+// Times returns m multiplied by f, rounded to the nearest minor unit.
+func (m Money) Times(f float64) Money {
+	return Money(math.Round(float64(m) * f))
+}
+
+// Min returns the smaller of m and o.
+func (m Money) Min(o Money) Money {
+	if o < m {
+		return o
+	}
+
+	return m
+}
+
+// String formats m as a decimal of major units with the currency's places,
+// such as "125.50", the form it is stored and exported in.
+func (m Money) String() string {
+	exp := current.Exponent
+	sign, n := "", int64(m)
+
+	if n < 0 {
+		sign, n = "-", -n
+	}
+
+	if exp == 0 {
+		return sign + strconv.FormatInt(n, 10)
+	}
+
+	unit := int64(math.Pow10(exp))
+
+	return fmt.Sprintf("%s%d.%0*d", sign, n/unit, exp, n%unit)
+}
+
+// Display formats m for people, with the currency's symbol, such as
+// "₹125.50". A currency without a symbol is shown by its code.
+func (m Money) Display() string {
+	symbol := current.Symbol
+	if symbol == "" {
+		symbol = current.Code + " "
+	}
+
+	if m < 0 {
+		return "-" + symbol + (-m).String()
+	}
+
+	return symbol + m.String()
+}
+
+// Parse reads a decimal of major units, such as "125.5", rounding any
+// places beyond the currency's to the nearest minor unit.
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, ErrInvalid
+	}
+
+	for _, r := range whole + frac {
+		if r < '0' || r > '9' {
+			return 0, ErrInvalid
+		}
+	}
+
+	exp := current.Exponent
+	roundUp := len(frac) > exp && frac[exp] >= '5'
+
+	if len(frac) > exp {
+		frac = frac[:exp]
+	}
+
+	digits := strings.TrimLeft(whole+frac+strings.Repeat("0", exp-len(frac)), "0")
+	if digits == "" {
+		digits = "0"
+	}
+
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, ErrInvalid
+	}
+
+	if roundUp {
+		n++
+	}
+
+	if neg {
+		n = -n
+	}
+
+	return Money(n), nil
+}
+
+// jsonMoney is how Money is written in JSON.
+type jsonMoney struct {
+	MinorUnits int64  `json:"minor_units"`
+	Currency   string `json:"currency"`
+	Display    string `json:"display"`
+}
+
+// MarshalJSON writes m with its currency and display form.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{MinorUnits: int64(m), Currency: current.Code, Display: m.Display()})
+}
+
+// UnmarshalJSON reads either a bare integer of minor units or the object
+// MarshalJSON writes, whose currency, if given, must be the current one.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] != '{' {
+		var n int64
+		if err := json.Unmarshal(b, &n); err != nil {
+			return fmt.Errorf("an amount must be a whole number of minor units")
+		}
+
+		*m = Money(n)
+
+		return nil
+	}
+
+	var v jsonMoney
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	if v.Currency != "" && !strings.EqualFold(v.Currency, current.Code) {
+		return fmt.Errorf("amounts must be in %s, not %s", current.Code, v.Currency)
+	}
+
+	*m = Money(v.MinorUnits)
+
+	return nil
+}
+
+// Scan reads m from a NUMERIC column of major units.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v * int64(scale()))
+	case float64:
+		*m = FromMajor(v)
+	case []byte:
+		return m.scanText(string(v))
+	case string:
+		return m.scanText(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+
+	return nil
+}
+
+func (m *Money) scanText(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*m = v
+
+	return nil
+}
+
+// Value writes m as a decimal of major units.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func scale() float64 {
+	return math.Pow10(current.Exponent)
+}
@@ -0,0 +1,91 @@
+// Package noshow flags the seats of riders who never boarded.
+package noshow
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/tracking"
+)
+
+// Lookback bounds how long before the end of the grace period a pass looks
+// for departures, so that seats booked before boarding was recorded are not
+// all flagged the first time detection runs.
+const Lookback = 24 * time.Hour
+
+// Config sets when seats are flagged and what happens to them.
+type Config struct {
+	// Grace is how long after departure a rider has to board.
+	Grace time.Duration
+	// Release frees the seats of no-shows, which then show as available on
+	// the seat map for riders boarding further along the route.
+	Release bool
+	// Interval is how often to look for no-shows.
+	Interval time.Duration
+}
+
+// Detector flags every interval the seats of booked and validated tickets
+// whose riders had not boarded by the end of the grace period.
+type Detector struct {
+	store     store.Store
+	occupancy *tracking.Occupancy
+	logger    *slog.Logger
+	cfg       Config
+}
+
+// New returns a Detector that recounts released departures with occ, which
+// may be nil, and logs to logger.
+func New(st store.Store, occ *tracking.Occupancy, logger *slog.Logger, cfg Config) *Detector {
+	return &Detector{store: st, occupancy: occ, logger: logger, cfg: cfg}
+}
+
+// Run looks for no-shows until ctx is cancelled, which also rolls back a
+// pass in progress.
+func (d *Detector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.detect(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Detector) detect(ctx context.Context) {
+	before := time.Now().Add(-d.cfg.Grace)
+
+	flagged, err := d.store.FlagNoShows(ctx, before.Add(-Lookback), before, d.cfg.Release)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.ErrorContext(ctx, "flagging no-shows failed", slog.String("error", err.Error()))
+		}
+
+		return
+	}
+
+	level := slog.LevelDebug
+	if len(flagged) > 0 {
+		level = slog.LevelInfo
+	}
+
+	d.logger.Log(ctx, level, "flagged no-shows", slog.Int("seats", len(flagged)), slog.Bool("released", d.cfg.Release))
+
+	if !d.cfg.Release {
+		return
+	}
+
+	tickets := make([]models.Ticket, 0, len(flagged))
+	for _, n := range flagged {
+		tickets = append(tickets, models.Ticket{ID: n.TicketID, BusID: n.BusID, TravelDate: n.TravelDate})
+	}
+
+	d.occupancy.Refresh(ctx, tickets...)
+}
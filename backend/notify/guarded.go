@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/breaker"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+// DefaultMaxDeferred bounds how many confirmations a Guarded keeps while its
+// breaker is open.
+const DefaultMaxDeferred = 1000
+
+// Guarded sends confirmations through a Notifier behind a circuit breaker.
+// While the breaker is open nothing is sent: confirmations are kept, up to
+// a limit, and sent by Run once the provider is tried again. Bookings never
+// wait on, or fail because of, a provider that is down.
+type Guarded struct {
+	notifier    Notifier
+	breaker     *breaker.Breaker
+	logger      *slog.Logger
+	maxDeferred int
+
+	mu       sync.Mutex
+	deferred []models.Ticket
+}
+
+// NewGuarded returns a Guarded sending through n behind b, keeping at most
+// maxDeferred confirmations, or DefaultMaxDeferred if it is not positive.
+func NewGuarded(n Notifier, b *breaker.Breaker, logger *slog.Logger, maxDeferred int) *Guarded {
+	if maxDeferred <= 0 {
+		maxDeferred = DefaultMaxDeferred
+	}
+
+	return &Guarded{notifier: n, breaker: b, logger: logger, maxDeferred: maxDeferred}
+}
+
+// SendBookingConfirmation sends ticket's confirmation or, while the breaker
+// is open, defers it and reports success.
+func (g *Guarded) SendBookingConfirmation(ticket models.Ticket) error {
+	err := g.breaker.Do(func() error { return g.notifier.SendBookingConfirmation(ticket) })
+	if errors.Is(err, breaker.ErrOpen) {
+		g.hold(ticket)
+		return nil
+	}
+
+	return err
+}
+
+// hold defers ticket's confirmation, dropping the oldest if too many are
+// waiting already.
+func (g *Guarded) hold(ticket models.Ticket) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.deferred) >= g.maxDeferred {
+		dropped := g.deferred[0]
+		g.deferred = g.deferred[1:]
+
+		g.logger.Warn("dropping deferred booking confirmation", slog.Int("ticket_id", dropped.ID))
+	}
+
+	g.deferred = append(g.deferred, ticket)
+}
+
+// Deferred returns how many confirmations are waiting to be sent.
+func (g *Guarded) Deferred() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return len(g.deferred)
+}
+
+// Run sends deferred confirmations every interval, for as long as the
+// breaker lets them through, until ctx is cancelled.
+func (g *Guarded) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		g.flush(ctx)
+	}
+}
+
+func (g *Guarded) flush(ctx context.Context) {
+	for ctx.Err() == nil {
+		g.mu.Lock()
+		if len(g.deferred) == 0 {
+			g.mu.Unlock()
+			return
+		}
+
+		ticket := g.deferred[0]
+		g.deferred = g.deferred[1:]
+		g.mu.Unlock()
+
+		err := g.breaker.Do(func() error { return g.notifier.SendBookingConfirmation(ticket) })
+
+		switch {
+		case errors.Is(err, breaker.ErrOpen):
+			g.requeue(ticket)
+			return
+		case err != nil:
+			// The failure may have tripped the breaker; either way the
+			// confirmation gets another go on the next pass.
+			g.requeue(ticket)
+			g.logger.Error("sending deferred booking confirmation failed",
+				slog.Int("ticket_id", ticket.ID), slog.String("error", err.Error()))
+
+			return
+		}
+	}
+}
+
+// requeue puts ticket back at the head of the deferred confirmations.
+func (g *Guarded) requeue(ticket models.Ticket) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.deferred = append([]models.Ticket{ticket}, g.deferred...)
+}
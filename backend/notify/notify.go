@@ -0,0 +1,76 @@
+// Package notify tells riders about their bookings through a pluggable
+// Notifier.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+// Notifier delivers booking confirmations, by email, SMS or anything else.
+type Notifier interface {
+	SendBookingConfirmation(ticket models.Ticket) error
+}
+
+// Nop is the Notifier used when notifications are disabled.
+type Nop struct{}
+
+// SendBookingConfirmation does nothing.
+func (Nop) SendBookingConfirmation(models.Ticket) error { return nil }
+
+// Webhook posts each confirmation as JSON to URL, leaving delivery to the
+// mail or SMS gateway behind it.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhook returns a Webhook posting to url with the given request timeout.
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	return &Webhook{URL: url, Client: &http.Client{Timeout: timeout}}
+}
+
+type webhookEvent struct {
+	Event  string        `json:"event"`
+	Ticket models.Ticket `json:"ticket"`
+}
+
+// SendBookingConfirmation posts a booking.confirmed event for ticket. Any
+// non-2xx response is an error.
+func (w *Webhook) SendBookingConfirmation(ticket models.Ticket) error {
+	body, err := json.Marshal(webhookEvent{Event: "booking.confirmed", Ticket: ticket})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification webhook responded %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Async sends a confirmation for each ticket in the background, logging any
+// that fail to logger. The caller never waits on, or sees errors from, n.
+func Async(logger *slog.Logger, n Notifier, tickets ...models.Ticket) {
+	go func() {
+		for _, t := range tickets {
+			if err := n.SendBookingConfirmation(t); err != nil {
+				logger.Error("sending booking confirmation failed",
+					slog.Int("ticket_id", t.ID), slog.String("error", err.Error()))
+			}
+		}
+	}()
+}
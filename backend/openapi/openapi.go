@@ -0,0 +1,255 @@
+// Package openapi builds an OpenAPI 3 document for the API from the routes
+// as they are registered, deriving schemas from the request and response
+// types so the document cannot drift from the code.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Query describes a query parameter. Type is an OpenAPI primitive type and
+// defaults to string.
+type Query struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// Operation documents one route. Request and Response are zero values of the
+// body types, or nil for none.
+type Operation struct {
+	Summary string
+	// Auth is set for routes that need a bearer token.
+	Auth bool
+	// Scopes are set for routes that need an API key granted them.
+	Scopes []string
+	Query  []Query
+	// Headers are request headers the route reads.
+	Headers  []Query
+	Request  interface{}
+	Response interface{}
+	// Paged wraps Response, which should be a slice, in the list envelope.
+	Paged bool
+	// ContentType is set for responses that are not JSON; Response is then
+	// ignored.
+	ContentType string
+	// Status is the success status; it defaults to 201 for POST, 204 for
+	// DELETE and 200 otherwise.
+	Status int
+}
+
+// Spec is an OpenAPI document under construction. It is safe for concurrent
+// use.
+type Spec struct {
+	mu  sync.Mutex
+	doc document
+	gen *generator
+}
+
+// New returns an empty Spec.
+func New(title, version string) *Spec {
+	return &Spec{
+		doc: document{
+			OpenAPI: "3.0.3",
+			Info:    info{Title: title, Version: version},
+			Paths:   make(map[string]map[string]*operation),
+			Components: components{
+				Schemas: map[string]*Schema{"Error": errorSchema()},
+				SecuritySchemes: map[string]securityScheme{
+					"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+					"apiKeyAuth": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+				},
+			},
+		},
+		gen: newGenerator(),
+	}
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Add documents method and path. Path parameters are taken from path and
+// documented as integer IDs.
+func (s *Spec) Add(method, path string, op Operation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := &operation{
+		Summary:   op.Summary,
+		Responses: map[string]response{"default": {Description: "Error", Content: jsonContent(ref("Error"))}},
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		o.Parameters = append(o.Parameters, parameter{
+			Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "integer"},
+		})
+	}
+
+	for _, q := range op.Query {
+		o.Parameters = append(o.Parameters, queryParameter("query", q))
+	}
+
+	for _, h := range op.Headers {
+		o.Parameters = append(o.Parameters, queryParameter("header", h))
+	}
+
+	if op.Auth {
+		o.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	if len(op.Scopes) > 0 {
+		o.Security = append(o.Security, map[string][]string{"apiKeyAuth": op.Scopes})
+	}
+
+	if op.Request != nil {
+		o.RequestBody = &requestBody{Required: true, Content: jsonContent(s.schemaOf(op.Request))}
+	}
+
+	status := op.Status
+	if status == 0 {
+		switch method {
+		case http.MethodPost:
+			status = http.StatusCreated
+		case http.MethodDelete:
+			status = http.StatusNoContent
+		default:
+			status = http.StatusOK
+		}
+	}
+
+	ok := response{Description: http.StatusText(status)}
+
+	switch {
+	case op.ContentType != "":
+		ok.Content = map[string]mediaType{op.ContentType: {Schema: &Schema{Type: "string", Format: "binary"}}}
+	case op.Response != nil && op.Paged:
+		ok.Content = jsonContent(envelope(pageSchema(s.schemaOf(op.Response))))
+	case op.Response != nil:
+		ok.Content = jsonContent(envelope(s.schemaOf(op.Response)))
+	}
+
+	o.Responses[strconv.Itoa(status)] = ok
+
+	if s.doc.Paths[path] == nil {
+		s.doc.Paths[path] = make(map[string]*operation)
+	}
+
+	s.doc.Paths[path][strings.ToLower(method)] = o
+}
+
+// Document returns the document built so far.
+func (s *Spec) Document() interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.doc
+}
+
+func (s *Spec) schemaOf(v interface{}) *Schema {
+	schema := s.gen.schema(v)
+	for name, def := range s.gen.defs {
+		s.doc.Components.Schemas[name] = def
+	}
+
+	return schema
+}
+
+func queryParameter(in string, q Query) parameter {
+	typ := q.Type
+	if typ == "" {
+		typ = "string"
+	}
+
+	return parameter{Name: q.Name, In: in, Description: q.Description, Required: q.Required, Schema: &Schema{Type: typ}}
+}
+
+func jsonContent(s *Schema) map[string]mediaType {
+	return map[string]mediaType{"application/json": {Schema: s}}
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func errorSchema() *Schema {
+	return &Schema{Type: "object", Properties: map[string]*Schema{
+		"error": {Type: "object", Properties: map[string]*Schema{
+			"code":    {Type: "string"},
+			"message": {Type: "string"},
+		}},
+	}}
+}
+
+// envelope wraps a handler's result the way gofr renders it.
+func envelope(data *Schema) *Schema {
+	return &Schema{Type: "object", Properties: map[string]*Schema{"data": data}}
+}
+
+// pageSchema mirrors the handler package's list envelope.
+func pageSchema(items *Schema) *Schema {
+	return &Schema{Type: "object", Properties: map[string]*Schema{
+		"data":   items,
+		"total":  {Type: "integer"},
+		"limit":  {Type: "integer"},
+		"offset": {Type: "integer"},
+	}}
+}
+
+type document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+type info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+type operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
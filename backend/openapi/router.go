@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/abhinav/gofr"
+)
+
+// Registrar is the part of *gofr.App that registers routes.
+type Registrar interface {
+	GET(path string, h gofr.Handler)
+	POST(path string, h gofr.Handler)
+	PUT(path string, h gofr.Handler)
+	PATCH(path string, h gofr.Handler)
+	DELETE(path string, h gofr.Handler)
+}
+
+// Router registers each route with the app and documents it in the spec in
+// the same call, so every route served is described.
+type Router struct {
+	app    Registrar
+	spec   *Spec
+	prefix string
+	wraps  []func(gofr.Handler) gofr.Handler
+}
+
+// NewRouter returns a Router registering on app and documenting in spec.
+func NewRouter(app Registrar, spec *Spec) *Router {
+	return &Router{app: app, spec: spec}
+}
+
+// Group returns a Router that registers and documents every route under
+// prefix, such as "/v1", on top of r's own prefix.
+func (r *Router) Group(prefix string) *Router {
+	return &Router{app: r.app, spec: r.spec, prefix: r.prefix + prefix, wraps: r.wraps}
+}
+
+// Use returns a Router that wraps every handler it registers with wraps,
+// the first outermost, inside any r already applies. Groups of the Router
+// returned apply them too.
+func (r *Router) Use(wraps ...func(gofr.Handler) gofr.Handler) *Router {
+	all := append(append([]func(gofr.Handler) gofr.Handler{}, r.wraps...), wraps...)
+	return &Router{app: r.app, spec: r.spec, prefix: r.prefix, wraps: all}
+}
+
+// wrap applies r's wraps to h.
+func (r *Router) wrap(h gofr.Handler) gofr.Handler {
+	for i := len(r.wraps) - 1; i >= 0; i-- {
+		h = r.wraps[i](h)
+	}
+
+	return h
+}
+
+// Path returns path as r registers it, under r's prefix.
+func (r *Router) Path(path string) string {
+	if path == "/" && r.prefix != "" {
+		return r.prefix
+	}
+
+	return r.prefix + path
+}
+
+// Document describes a route served outside the router, such as by a
+// middleware, under r's prefix.
+func (r *Router) Document(method, path string, op Operation) {
+	r.spec.Add(method, r.Path(path), op)
+}
+
+func (r *Router) GET(path string, h gofr.Handler, op Operation) {
+	r.Document(http.MethodGet, path, op)
+	r.app.GET(r.Path(path), r.wrap(h))
+}
+
+func (r *Router) POST(path string, h gofr.Handler, op Operation) {
+	r.Document(http.MethodPost, path, op)
+	r.app.POST(r.Path(path), r.wrap(h))
+}
+
+func (r *Router) PUT(path string, h gofr.Handler, op Operation) {
+	r.Document(http.MethodPut, path, op)
+	r.app.PUT(r.Path(path), r.wrap(h))
+}
+
+func (r *Router) PATCH(path string, h gofr.Handler, op Operation) {
+	r.Document(http.MethodPatch, path, op)
+	r.app.PATCH(r.Path(path), r.wrap(h))
+}
+
+func (r *Router) DELETE(path string, h gofr.Handler, op Operation) {
+	r.Document(http.MethodDelete, path, op)
+	r.app.DELETE(r.Path(path), r.wrap(h))
+}
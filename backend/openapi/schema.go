@@ -0,0 +1,222 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+// Schema is an OpenAPI schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	moneyType = reflect.TypeOf(money.Money(0))
+)
+
+// moneySchema describes money.Money as it is written; requests may also
+// give a bare integer of minor units.
+var moneySchema = Schema{
+	Type: "object",
+	Properties: map[string]*Schema{
+		"minor_units": {Type: "integer", Format: "int64"},
+		"currency":    {Type: "string"},
+		"display":     {Type: "string"},
+	},
+	Required: []string{"minor_units", "currency", "display"},
+}
+
+// generator derives schemas from Go types by reflection, following json
+// tags and the validate tags the validation package checks. Named structs
+// become components referenced by name.
+type generator struct {
+	defs map[string]*Schema
+}
+
+func newGenerator() *generator {
+	return &generator{defs: make(map[string]*Schema)}
+}
+
+func (g *generator) schema(v interface{}) *Schema {
+	return g.typeSchema(reflect.TypeOf(v))
+}
+
+func (g *generator) typeSchema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == moneyType:
+		g.defs["Money"] = &moneySchema
+		return ref("Money")
+	case t.Kind() == reflect.Ptr:
+		s := *g.typeSchema(t.Elem())
+		if s.Ref != "" {
+			// $ref siblings are ignored, so nullability is lost for
+			// referenced types; the field is simply optional.
+			return &s
+		}
+
+		s.Nullable = true
+
+		return &s
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		if _, seen := g.defs[t.Name()]; !seen {
+			// Reserve the name first so self-referencing types terminate.
+			g.defs[t.Name()] = &Schema{}
+			*g.defs[t.Name()] = *g.structSchema(t)
+		}
+
+		return ref(t.Name())
+	}
+
+	return &Schema{}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(s, t)
+
+	return s
+}
+
+func (g *generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(s, embedded)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := g.typeSchema(f.Type)
+		if applyRules(fs, f.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+
+		s.Properties[name] = fs
+	}
+}
+
+// applyRules copies the constraints of a validate tag onto s and reports
+// whether the field is required. Rules after "dive" apply to elements.
+func applyRules(s *Schema, tag string) bool {
+	if tag == "" || s.Ref != "" {
+		return false
+	}
+
+	var required bool
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "dive":
+			if s.Items != nil {
+				applyRules(s.Items, strings.SplitN(tag, "dive,", 2)[1])
+			}
+
+			return required
+		case "required":
+			required = true
+		case "min", "gte":
+			setBound(s, param, true)
+		case "max", "lte":
+			setBound(s, param, false)
+		case "unique":
+			s.UniqueItems = true
+		case "email":
+			s.Format = "email"
+		case "rfc3339":
+			s.Format = "date-time"
+		}
+	}
+
+	return required
+}
+
+func setBound(s *Schema, param string, lower bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	i := int(n)
+
+	switch s.Type {
+	case "array":
+		if lower {
+			s.MinItems = &i
+		} else {
+			s.MaxItems = &i
+		}
+	case "string":
+		if lower {
+			s.MinLength = &i
+		} else {
+			s.MaxLength = &i
+		}
+	default:
+		if lower {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	}
+}
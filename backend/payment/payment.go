@@ -0,0 +1,98 @@
+// Package payment creates the payment intents clients pay against and
+// verifies the webhooks the payment provider sends about them.
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries a webhook's signature, as "t=<unix time>,v1=<hex>"
+// where the hex is an HMAC-SHA256 of "<unix time>.<body>" under the shared
+// webhook secret.
+const SignatureHeader = "Payment-Signature"
+
+// DefaultTolerance is how old a webhook signature may be before it is
+// refused as a possible replay.
+const DefaultTolerance = 5 * time.Minute
+
+// ErrInvalidSignature is returned for webhooks whose signature is missing,
+// malformed, stale or does not match.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Verifier checks webhook signatures under a shared secret.
+type Verifier struct {
+	secret    []byte
+	tolerance time.Duration
+}
+
+// NewVerifier returns a Verifier for secret that accepts signatures up to
+// tolerance old.
+func NewVerifier(secret string, tolerance time.Duration) *Verifier {
+	return &Verifier{secret: []byte(secret), tolerance: tolerance}
+}
+
+// Sign returns the SignatureHeader value for body sent at now.
+func (v *Verifier) Sign(body []byte, now time.Time) string {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	return "t=" + ts + ",v1=" + v.mac(ts, body)
+}
+
+// Verify checks that header is a signature of body made within the
+// tolerance of now.
+func (v *Verifier) Verify(header string, body []byte, now time.Time) error {
+	var ts, sig string
+
+	for _, part := range strings.Split(header, ",") {
+		k, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		switch k {
+		case "t":
+			ts = val
+		case "v1":
+			sig = val
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return ErrInvalidSignature
+	}
+
+	if age := now.Sub(time.Unix(unix, 0)); age > v.tolerance || age < -v.tolerance {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(v.mac(ts, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (v *Verifier) mac(ts string, body []byte) string {
+	m := hmac.New(sha256.New, v.secret)
+	m.Write([]byte(ts + "."))
+	m.Write(body)
+
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// NewIntent returns a fresh intent ID and the client secret the client pays
+// with, which embeds it.
+func NewIntent() (id, clientSecret string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	id = "pi_" + hex.EncodeToString(b[:12])
+
+	return id, id + "_secret_" + hex.EncodeToString(b[12:]), nil
+}
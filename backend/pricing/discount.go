@@ -0,0 +1,25 @@
+package pricing
+
+import "github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+
+// Discount kinds: a percentage off the fare, or a fixed amount off it.
+const (
+	DiscountPercent = "percent"
+	DiscountFlat    = "flat"
+)
+
+// PercentOff returns how much percent per cent takes off fare, rounded to
+// the nearest minor unit. It never exceeds the fare itself.
+func PercentOff(fare money.Money, percent float64) money.Money {
+	return FlatOff(fare, fare.Times(percent/100))
+}
+
+// FlatOff returns how much a flat discount of off takes off fare: off
+// itself, unless the fare is less.
+func FlatOff(fare, off money.Money) money.Money {
+	if off < 0 {
+		return 0
+	}
+
+	return off.Min(fare)
+}
@@ -0,0 +1,72 @@
+package pricing
+
+import (
+	"errors"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+// Bus classes, from cheapest to dearest.
+const (
+	ClassStandard = "standard"
+	ClassAC       = "ac"
+	ClassSleeper  = "sleeper"
+)
+
+// DefaultRatesPerKm are the per-kilometre rates, in major units of the
+// currency, used for classes the
+// calculator is not given a rate for.
+var DefaultRatesPerKm = map[string]float64{
+	ClassStandard: 1.5,
+	ClassAC:       2.5,
+	ClassSleeper:  3.5,
+}
+
+// ErrUnknownClass is returned for a bus class with no rate.
+var ErrUnknownClass = errors.New("pricing: unknown bus class")
+
+// Fare is a computed fare and how it was arrived at.
+type Fare struct {
+	Class      string
+	DistanceKm float64
+	RatePerKm  float64
+	Total      money.Money
+}
+
+// FareCalculator prices a journey by its distance and the bus's class.
+type FareCalculator struct {
+	ratesPerKm map[string]float64
+}
+
+// NewFareCalculator returns a FareCalculator using ratesPerKm, falling back
+// to DefaultRatesPerKm for any class it leaves out.
+func NewFareCalculator(ratesPerKm map[string]float64) *FareCalculator {
+	rates := make(map[string]float64, len(DefaultRatesPerKm))
+	for class, rate := range DefaultRatesPerKm {
+		rates[class] = rate
+	}
+
+	for class, rate := range ratesPerKm {
+		rates[class] = rate
+	}
+
+	return &FareCalculator{ratesPerKm: rates}
+}
+
+// Calculate prices a journey of distanceMeters on a bus of the given class.
+// The total is rounded to the nearest minor unit.
+func (c *FareCalculator) Calculate(class string, distanceMeters float64) (Fare, error) {
+	rate, ok := c.ratesPerKm[class]
+	if !ok {
+		return Fare{}, ErrUnknownClass
+	}
+
+	km := distanceMeters / 1000
+
+	return Fare{
+		Class:      class,
+		DistanceKm: km,
+		RatePerKm:  rate,
+		Total:      money.FromMajor(km * rate),
+	}, nil
+}
@@ -0,0 +1,33 @@
+// Package pricing computes what tickets cost and what cancelling them refunds.
+package pricing
+
+import (
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+// Refund windows, measured back from departure.
+const (
+	FullRefundBefore = 24 * time.Hour
+	HalfRefundBefore = 2 * time.Hour
+)
+
+// RefundPolicy returns how much of fare is refunded when a ticket is cancelled
+// untilDeparture ahead of the bus leaving, and why:
+//
+//   - more than 24h before departure: the full fare
+//   - between 24h and 2h before: half the fare
+//   - less than 2h before, or after departure: nothing
+func RefundPolicy(fare money.Money, untilDeparture time.Duration) (amount money.Money, reason string) {
+	switch {
+	case untilDeparture <= 0:
+		return 0, "the bus has already departed; no refund is due"
+	case untilDeparture > FullRefundBefore:
+		return fare, "cancelled more than 24 hours before departure; full refund"
+	case untilDeparture > HalfRefundBefore:
+		return fare.Times(0.5), "cancelled between 2 and 24 hours before departure; 50% refund"
+	default:
+		return 0, "cancelled less than 2 hours before departure; no refund"
+	}
+}
@@ -0,0 +1,96 @@
+package pricing
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+// SurgeTier raises fares by Multiplier once a departure is at least
+// Occupancy full, as a fraction of its capacity.
+type SurgeTier struct {
+	Occupancy  float64
+	Multiplier float64
+}
+
+// DefaultSurgeTiers add a fifth to fares once a departure is 70% full and
+// half again once it is 90% full.
+var DefaultSurgeTiers = []SurgeTier{{Occupancy: 0.7, Multiplier: 1.2}, {Occupancy: 0.9, Multiplier: 1.5}}
+
+// SurgePricer raises fares as departures fill up, by the multiplier of the
+// highest tier their occupancy has reached. A nil SurgePricer, or one
+// without tiers, never raises them.
+type SurgePricer struct {
+	tiers []SurgeTier
+}
+
+// NewSurgePricer returns a SurgePricer for tiers, in any order.
+func NewSurgePricer(tiers []SurgeTier) *SurgePricer {
+	sorted := append([]SurgeTier(nil), tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Occupancy < sorted[j].Occupancy })
+
+	return &SurgePricer{tiers: sorted}
+}
+
+// Multiplier returns the multiplier for a departure with booked of its
+// capacity seats taken: 1 below the lowest tier or when the capacity is
+// unknown.
+func (p *SurgePricer) Multiplier(booked, capacity int) float64 {
+	if p == nil || capacity <= 0 {
+		return 1
+	}
+
+	occupancy := float64(booked) / float64(capacity)
+	multiplier := 1.0
+
+	for _, t := range p.tiers {
+		if occupancy >= t.Occupancy {
+			multiplier = t.Multiplier
+		}
+	}
+
+	return multiplier
+}
+
+// Surge returns base raised by multiplier, rounded to the nearest minor
+// unit.
+func Surge(base money.Money, multiplier float64) money.Money {
+	return base.Times(multiplier)
+}
+
+// ParseSurgeTiers parses tiers written as "occupancy:multiplier" pairs
+// separated by commas, such as "0.7:1.2,0.9:1.5". Occupancies are fractions
+// of capacity from 0 to 1 and multipliers are at least 1. An empty string
+// is no tiers.
+func ParseSurgeTiers(s string) ([]SurgeTier, error) {
+	var tiers []SurgeTier
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		occ, mult, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("surge tier %q is not occupancy:multiplier", part)
+		}
+
+		o, err := strconv.ParseFloat(strings.TrimSpace(occ), 64)
+		if err != nil || o < 0 || o > 1 {
+			return nil, fmt.Errorf("surge tier %q: occupancy must be between 0 and 1", part)
+		}
+
+		m, err := strconv.ParseFloat(strings.TrimSpace(mult), 64)
+		if err != nil || m < 1 {
+			return nil, fmt.Errorf("surge tier %q: multiplier must be at least 1", part)
+		}
+
+		tiers = append(tiers, SurgeTier{Occupancy: o, Multiplier: m})
+	}
+
+	return tiers, nil
+}
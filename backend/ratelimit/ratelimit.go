@@ -0,0 +1,121 @@
+// Package ratelimit caps how often a caller may hit selected routes.
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/i18n"
+)
+
+// Limiter allows at most limit events per key in any sliding window. It is
+// safe for concurrent use.
+type Limiter struct {
+	limit  int
+	window time.Duration
+
+	mu        sync.Mutex
+	events    map[string][]time.Time
+	lastSweep time.Time
+}
+
+// New returns a Limiter allowing limit events per key per window.
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: window, events: make(map[string][]time.Time)}
+}
+
+// Allow records an event for key at now if the key is under its limit. When
+// it is not, Allow reports how long until the next event would be allowed.
+func (l *Limiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	cutoff := now.Add(-l.window)
+	recent := l.events[key]
+
+	for len(recent) > 0 && !recent[0].After(cutoff) {
+		recent = recent[1:]
+	}
+
+	if len(recent) >= l.limit {
+		l.events[key] = recent
+		return false, recent[0].Sub(cutoff)
+	}
+
+	l.events[key] = append(recent, now)
+
+	return true, 0
+}
+
+// sweep drops keys with no events in the current window, at most once per
+// window, so idle callers do not accumulate.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+
+	l.lastSweep = now
+	cutoff := now.Add(-l.window)
+
+	for key, events := range l.events {
+		if len(events) == 0 || !events[len(events)-1].After(cutoff) {
+			delete(l.events, key)
+		}
+	}
+}
+
+// Middleware applies l to requests whose "METHOD /path" is one of routes, and
+// lets every other request through. Callers are told apart by the user ID
+// auth.Middleware stored, falling back to the client IP, so it must be
+// installed after auth.Middleware. Rejected requests get a 429 with a
+// Retry-After header.
+func Middleware(l *Limiter, routes ...string) func(http.Handler) http.Handler {
+	limited := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		limited[r] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limited[r.Method+" "+r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ok, retryAfter := l.Allow(callerKey(r), time.Now())
+			if ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+			w.Header().Set("Content-Language", lang)
+			apierror.Write(w, apierror.New(apierror.ErrRateLimited, "rate_limited",
+				"too many requests; retry in %d seconds", seconds).Localize(lang))
+		})
+	}
+}
+
+func callerKey(r *http.Request) string {
+	if id, ok := auth.UserID(r.Context()); ok {
+		return "user:" + strconv.Itoa(id)
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
@@ -0,0 +1,79 @@
+// Package reaper releases seat holds that expired without being confirmed.
+package reaper
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/tracking"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/waitlist"
+)
+
+// Reaper deletes expired seat holds every interval, first marking the
+// payments left pending on them as abandoned, and kicks the waitlist and
+// refreshes the departures' seats when it releases any.
+type Reaper struct {
+	store     store.Store
+	waitlist  *waitlist.Promoter
+	occupancy *tracking.Occupancy
+	logger    *slog.Logger
+	interval  time.Duration
+}
+
+// New returns a Reaper that kicks promoter and refreshes departures with
+// occ, either of which may be nil, and logs to logger.
+func New(st store.Store, promoter *waitlist.Promoter, occ *tracking.Occupancy, logger *slog.Logger, interval time.Duration) *Reaper {
+	return &Reaper{store: st, waitlist: promoter, occupancy: occ, logger: logger, interval: interval}
+}
+
+// Run reaps until ctx is cancelled. Cancelling ctx also cancels a pass in
+// progress, whose deletes are then rolled back, so once Run returns it no
+// longer uses the store.
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.reap(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reaper) reap(ctx context.Context) {
+	now := time.Now()
+
+	payments, err := r.store.ExpirePayments(ctx, now)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.ErrorContext(ctx, "expiring abandoned payments failed", slog.String("error", err.Error()))
+		}
+
+		// Reaping the holds now would strand their payments as pending.
+		return
+	}
+
+	holds, err := r.store.DeleteExpiredHolds(ctx, now)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.ErrorContext(ctx, "reaping expired seat holds failed", slog.String("error", err.Error()))
+		}
+
+		return
+	}
+
+	level := slog.LevelDebug
+	if len(holds) > 0 || payments > 0 {
+		level = slog.LevelInfo
+		r.waitlist.Kick()
+		r.occupancy.RefreshHolds(ctx, holds...)
+	}
+
+	r.logger.Log(ctx, level, "reaped expired seat holds", slog.Int("holds", len(holds)), slog.Int64("payments", payments))
+}
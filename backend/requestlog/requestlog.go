@@ -0,0 +1,144 @@
+// Package requestlog tags every request with a correlation ID and writes one
+// structured log line per request.
+package requestlog
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Header carries the correlation ID on requests and responses.
+const Header = "X-Correlation-ID"
+
+// maxIDLen bounds correlation IDs accepted from clients; longer ones, or ones
+// with characters outside printable ASCII, are replaced with a fresh ID.
+const maxIDLen = 128
+
+type contextKey struct{}
+
+type requestInfo struct {
+	id     string
+	logger *slog.Logger
+}
+
+// Middleware reads the correlation ID from the request, generating one if it
+// is missing, echoes it in the response and makes it available to ID and
+// Logger. When the request completes it logs its method, path, status and
+// latency to logger.
+func Middleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(Header)
+			if !validID(id) {
+				id = newID()
+			}
+
+			info := &requestInfo{id: id, logger: logger.With(slog.String("correlation_id", id))}
+			w.Header().Set(Header, id)
+
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), contextKey{}, info)))
+
+			if sw.status == 0 {
+				sw.status = http.StatusOK
+			}
+
+			info.logger.LogAttrs(r.Context(), slog.LevelInfo, "request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", sw.status),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			)
+		})
+	}
+}
+
+// ID returns the correlation ID of the request ctx belongs to, or "" outside
+// a request.
+func ID(ctx context.Context) string {
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		return info.id
+	}
+
+	return ""
+}
+
+// Logger returns a logger that tags every line with the request's
+// correlation ID, or slog.Default outside a request.
+func Logger(ctx context.Context) *slog.Logger {
+	if info, ok := ctx.Value(contextKey{}).(*requestInfo); ok {
+		return info.logger
+	}
+
+	return slog.Default()
+}
+
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// statusWriter records the status code written to the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack keeps WebSocket upgrades working; the upgrade is logged as 101.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	w.status = http.StatusSwitchingProtocols
+
+	return h.Hijack()
+}
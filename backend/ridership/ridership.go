@@ -0,0 +1,81 @@
+// Package ridership counts where along a route riders board and alight.
+package ridership
+
+import (
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/geo"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+// Count tallies rides against route, the stops of their bus in travel
+// order, with a StopCount for every stop, including those no one used. A
+// ride boards at the stop its journey leg starts from, or else the stop
+// with coordinates nearest where it was boarded, and alights where its leg
+// ends. Rides placed at neither are counted in UnplacedBoardings and
+// UnknownAlightings instead; so are leg stops not on route.
+func Count(route []models.RouteStop, rides []models.Ride) models.StopCounts {
+	counts := models.StopCounts{Stops: make([]models.StopCount, len(route))}
+
+	for i, rs := range route {
+		counts.Stops[i] = models.StopCount{StopID: rs.StopID, Name: rs.Name}
+	}
+
+	for _, r := range rides {
+		if i := boardingStop(route, r); i >= 0 {
+			counts.Stops[i].Boarded++
+		} else {
+			counts.UnplacedBoardings++
+		}
+
+		if i := stopNamed(route, r.ToStop); i >= 0 {
+			counts.Stops[i].Alighted++
+		} else {
+			counts.UnknownAlightings++
+		}
+	}
+
+	return counts
+}
+
+// boardingStop returns the index in route of the stop r boarded at, or -1.
+func boardingStop(route []models.RouteStop, r models.Ride) int {
+	if r.FromStop != "" {
+		return stopNamed(route, r.FromStop)
+	}
+
+	if r.Lat == nil || r.Lng == nil {
+		return -1
+	}
+
+	at := geo.Point{Lat: *r.Lat, Lng: *r.Lng}
+	nearest, best := -1, 0.0
+
+	for i, rs := range route {
+		if rs.Lat == nil || rs.Lng == nil {
+			continue
+		}
+
+		if d := geo.Distance(at, geo.Point{Lat: *rs.Lat, Lng: *rs.Lng}); nearest < 0 || d < best {
+			nearest, best = i, d
+		}
+	}
+
+	return nearest
+}
+
+// stopNamed returns the index in route of the stop called name, however it
+// is cased or spaced, or -1.
+func stopNamed(route []models.RouteStop, name string) int {
+	if name == "" {
+		return -1
+	}
+
+	key := models.StopKey(name)
+
+	for i, rs := range route {
+		if models.StopKey(rs.Name) == key {
+			return i
+		}
+	}
+
+	return -1
+}
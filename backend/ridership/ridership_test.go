@@ -0,0 +1,145 @@
+package ridership
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+func ptr(f float64) *float64 { return &f }
+
+// route is three stops a kilometre or so apart, the middle one without
+// coordinates.
+var route = []models.RouteStop{
+	{StopID: 1, Name: "Central", Lat: ptr(13.0827), Lng: ptr(80.2707)},
+	{StopID: 2, Name: "Egmore"},
+	{StopID: 3, Name: "Anna Nagar", Lat: ptr(13.0850), Lng: ptr(80.2101)},
+}
+
+func ride(from, to string) models.Ride {
+	return models.Ride{FromStop: from, ToStop: to}
+}
+
+func rideAt(lat, lng float64, to string) models.Ride {
+	return models.Ride{BoardedSeat: models.BoardedSeat{Lat: &lat, Lng: &lng}, ToStop: to}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		route    []models.RouteStop
+		rides    []models.Ride
+		boarded  []int
+		alighted []int
+		unplaced int
+		unknown  int
+	}{
+		{
+			name:     "no rides",
+			route:    route,
+			boarded:  []int{0, 0, 0},
+			alighted: []int{0, 0, 0},
+		},
+		{
+			name:     "by journey leg",
+			route:    route,
+			rides:    []models.Ride{ride("Central", "Egmore"), ride("Central", "Anna Nagar"), ride("Egmore", "Anna Nagar")},
+			boarded:  []int{2, 1, 0},
+			alighted: []int{0, 1, 2},
+		},
+		{
+			name:     "by where boarded",
+			route:    route,
+			rides:    []models.Ride{rideAt(13.0830, 80.2700, "Anna Nagar"), rideAt(13.0849, 80.2110, "")},
+			boarded:  []int{1, 0, 1},
+			alighted: []int{0, 0, 1},
+			unknown:  1,
+		},
+		{
+			name:     "stops not on the route",
+			route:    route,
+			rides:    []models.Ride{ride("Guindy", "Egmore"), ride("Central", "Tambaram"), {}},
+			boarded:  []int{1, 0, 0},
+			alighted: []int{0, 1, 0},
+			unplaced: 2,
+			unknown:  2,
+		},
+		{
+			name:     "names cased and spaced differently",
+			route:    route,
+			rides:    []models.Ride{ride("  central ", "ANNA   nagar"), ride("EGMORE", "anna nagar\t")},
+			boarded:  []int{1, 1, 0},
+			alighted: []int{0, 0, 2},
+		},
+		{
+			name:     "empty route",
+			rides:    []models.Ride{ride("Central", "Egmore"), rideAt(13.0830, 80.2700, "Egmore")},
+			boarded:  []int{},
+			alighted: []int{},
+			unplaced: 2,
+			unknown:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Count(tt.route, tt.rides)
+
+			if len(got.Stops) != len(tt.route) {
+				t.Fatalf("got %d stops, want %d", len(got.Stops), len(tt.route))
+			}
+
+			boarded, alighted := []int{}, []int{}
+
+			for i, sc := range got.Stops {
+				if sc.StopID != tt.route[i].StopID || sc.Name != tt.route[i].Name {
+					t.Errorf("stop %d is %d %q, want %d %q", i, sc.StopID, sc.Name, tt.route[i].StopID, tt.route[i].Name)
+				}
+
+				boarded = append(boarded, sc.Boarded)
+				alighted = append(alighted, sc.Alighted)
+			}
+
+			if !reflect.DeepEqual(boarded, tt.boarded) {
+				t.Errorf("boarded %v, want %v", boarded, tt.boarded)
+			}
+
+			if !reflect.DeepEqual(alighted, tt.alighted) {
+				t.Errorf("alighted %v, want %v", alighted, tt.alighted)
+			}
+
+			if got.UnplacedBoardings != tt.unplaced || got.UnknownAlightings != tt.unknown {
+				t.Errorf("unplaced %d, unknown %d; want %d, %d",
+					got.UnplacedBoardings, got.UnknownAlightings, tt.unplaced, tt.unknown)
+			}
+		})
+	}
+}
+
+func TestStopNamed(t *testing.T) {
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"Central", 0},
+		{"central", 0},
+		{"  CENTRAL  ", 0},
+		{"Anna Nagar", 2},
+		{"anna\t  nagar", 2},
+		{"AnnaNagar", -1},
+		{"Guindy", -1},
+		{"", -1},
+		{"   ", -1},
+	}
+
+	for _, tt := range tests {
+		if got := stopNamed(route, tt.name); got != tt.want {
+			t.Errorf("stopNamed(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	if got := stopNamed(nil, "Central"); got != -1 {
+		t.Errorf("stopNamed on an empty route = %d, want -1", got)
+	}
+}
@@ -0,0 +1,128 @@
+// Package schedule models a bus's recurring timetable and resolves it to the
+// departure on a given date.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Recurrence rules naming common sets of days. Any other set is written as
+// a comma-separated list of days, such as "mon,wed,fri".
+const (
+	Daily    = "daily"
+	Weekdays = "weekdays"
+	Weekends = "weekends"
+)
+
+// ErrInvalidRule is returned by ParseDays for a rule it cannot read.
+var ErrInvalidRule = errors.New("schedule: invalid recurrence rule")
+
+// Days is a set of days of the week.
+type Days uint8
+
+const (
+	allDays     Days = 1<<7 - 1
+	weekendDays Days = 1<<time.Sunday | 1<<time.Saturday
+	weekdayDays      = allDays &^ weekendDays
+)
+
+var dayNames = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseDays parses a recurrence rule: Daily, Weekdays, Weekends or a list of
+// three-letter day names. It is case-insensitive and ignores spaces.
+func ParseDays(rule string) (Days, error) {
+	rule = strings.ToLower(strings.ReplaceAll(rule, " ", ""))
+
+	switch rule {
+	case Daily:
+		return allDays, nil
+	case Weekdays:
+		return weekdayDays, nil
+	case Weekends:
+		return weekendDays, nil
+	}
+
+	var d Days
+
+	for _, name := range strings.Split(rule, ",") {
+		i := indexOf(dayNames[:], name)
+		if i < 0 {
+			return 0, fmt.Errorf("%w %q", ErrInvalidRule, rule)
+		}
+
+		d |= 1 << i
+	}
+
+	return d, nil
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Has reports whether w is one of the days.
+func (d Days) Has(w time.Weekday) bool { return d&(1<<w) != 0 }
+
+// String returns the days as the shortest rule ParseDays accepts, listing
+// days from Monday.
+func (d Days) String() string {
+	switch d {
+	case allDays:
+		return Daily
+	case weekdayDays:
+		return Weekdays
+	case weekendDays:
+		return Weekends
+	}
+
+	var names []string
+
+	for i := 1; i <= 7; i++ {
+		if w := time.Weekday(i % 7); d.Has(w) {
+			names = append(names, dayNames[w])
+		}
+	}
+
+	return strings.Join(names, ",")
+}
+
+// Schedule is a bus's timetable: it leaves at Departure, as "HH:MM" in Loc,
+// on each of Days.
+type Schedule struct {
+	Departure string
+	Days      Days
+	Loc       *time.Location
+}
+
+// Resolve reports whether the bus runs on the calendar day date falls on in
+// s.Loc, and if so when it departs that day. A schedule without a departure
+// time runs at midnight.
+func (s Schedule) Resolve(date time.Time) (time.Time, bool) {
+	loc := s.Loc
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	local := date.In(loc)
+	if !s.Days.Has(local.Weekday()) {
+		return time.Time{}, false
+	}
+
+	var hour, minute int
+	if clock, err := time.Parse("15:04", s.Departure); err == nil {
+		hour, minute = clock.Hour(), clock.Minute()
+	}
+
+	y, m, d := local.Date()
+
+	return time.Date(y, m, d, hour, minute, 0, 0, loc), true
+}
@@ -0,0 +1,146 @@
+// Package stopdist caches the distances between stops, which fares, ETAs
+// and delay statistics otherwise work out afresh on every request.
+package stopdist
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/geo"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// ErrNoCoordinates is returned for a stop that is unknown or has no
+// coordinates.
+var ErrNoCoordinates = errors.New("stop has no coordinates")
+
+// pair is an unordered pair of stop IDs, lower first.
+type pair struct{ a, b int }
+
+func newPair(a, b int) pair {
+	if a > b {
+		a, b = b, a
+	}
+
+	return pair{a, b}
+}
+
+// Matrix is a lazily filled matrix of great-circle distances between stops.
+// It loads the coordinates of every stop on first use and works out each
+// distance the first time it is asked for. Stops only change through
+// migrations, which run before the service starts, so one load lasts the
+// process; Invalidate forces another.
+type Matrix struct {
+	store store.Store
+
+	mu     sync.RWMutex
+	points map[int]geo.Point
+	dist   map[pair]float64
+}
+
+// New returns a Matrix over the stops in st.
+func New(st store.Store) *Matrix {
+	return &Matrix{store: st}
+}
+
+// Invalidate drops every cached coordinate and distance, so the next lookup
+// reloads the stops.
+func (m *Matrix) Invalidate() {
+	m.mu.Lock()
+	m.points, m.dist = nil, nil
+	m.mu.Unlock()
+}
+
+// DistanceBetween returns the distance in meters between stops a and b, or
+// ErrNoCoordinates if either cannot be placed.
+func (m *Matrix) DistanceBetween(ctx context.Context, a, b int) (float64, error) {
+	key := newPair(a, b)
+
+	m.mu.RLock()
+	d, ok := m.dist[key]
+	loaded := m.points != nil
+	m.mu.RUnlock()
+
+	if ok {
+		return d, nil
+	}
+
+	if !loaded {
+		if err := m.load(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pa, okA := m.points[a]
+	pb, okB := m.points[b]
+
+	if !okA || !okB {
+		return 0, ErrNoCoordinates
+	}
+
+	d = geo.Distance(pa, pb)
+	if m.dist != nil {
+		m.dist[key] = d
+	}
+
+	return d, nil
+}
+
+// Warm loads the stops now, unless they already are, so that the first
+// request to need a distance does not wait for them.
+func (m *Matrix) Warm(ctx context.Context) error {
+	m.mu.RLock()
+	loaded := m.points != nil
+	m.mu.RUnlock()
+
+	if loaded {
+		return nil
+	}
+
+	return m.load(ctx)
+}
+
+// Along returns the distance in meters from the first to the last of stops,
+// calling at each in turn.
+func (m *Matrix) Along(ctx context.Context, stops []int) (float64, error) {
+	var total float64
+
+	for i := 1; i < len(stops); i++ {
+		d, err := m.DistanceBetween(ctx, stops[i-1], stops[i])
+		if err != nil {
+			return 0, err
+		}
+
+		total += d
+	}
+
+	return total, nil
+}
+
+// load reads the coordinates of every stop that has them.
+func (m *Matrix) load(ctx context.Context) error {
+	stops, err := m.store.GetStops(ctx)
+	if err != nil {
+		return err
+	}
+
+	points := make(map[int]geo.Point, len(stops))
+
+	for _, s := range stops {
+		if s.Lat != nil && s.Lng != nil {
+			points[s.ID] = geo.Point{Lat: *s.Lat, Lng: *s.Lng}
+		}
+	}
+
+	m.mu.Lock()
+	if m.points == nil {
+		m.points, m.dist = points, make(map[pair]float64)
+	}
+	m.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+func (s *sqlStore) CreateAPIKey(ctx context.Context, k models.APIKey) (models.APIKey, error) {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by) VALUES ($1, $2, $3, $4::text[], $5)
+			RETURNING id, created_at`,
+			k.Name, k.Prefix, k.Hash, "{"+strings.Join(k.Scopes, ",")+"}", k.CreatedBy).Scan(&k.ID, &k.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		// The key itself is kept out of the audit log.
+		audited := k
+		audited.Key = ""
+
+		return audit(ctx, tx, models.AuditCreate, models.ResourceAPIKey, k.ID, nil, audited)
+	})
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	return k, nil
+}
+
+func (s *sqlStore) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	k := models.APIKey{Hash: hash}
+
+	var scopes string
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, name, prefix, array_to_string(scopes, ','), created_by, created_at FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL`, hash).
+		Scan(&k.ID, &k.Name, &k.Prefix, &scopes, &k.CreatedBy, &k.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, ErrNotFound
+	} else if err != nil {
+		return models.APIKey{}, err
+	}
+
+	k.Scopes = strings.Split(scopes, ",")
+
+	return k, nil
+}
+
+func (s *sqlStore) RevokeAPIKey(ctx context.Context, id int) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var revokedAt *time.Time
+
+		err := tx.QueryRowContext(ctx, `SELECT revoked_at FROM api_keys WHERE id = $1 FOR UPDATE`, id).Scan(&revokedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil || revokedAt != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if _, err := tx.ExecContext(ctx, `UPDATE api_keys SET revoked_at = $2 WHERE id = $1`, id, now); err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, models.AuditDelete, models.ResourceAPIKey, id,
+			map[string]interface{}{"revoked_at": nil}, map[string]interface{}{"revoked_at": now})
+	})
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/auth"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+// AuditFilter picks the entries GET /audit lists: those of Resource and,
+// unless it is zero, of the one with ResourceID.
+type AuditFilter struct {
+	Resource   string
+	ResourceID int
+}
+
+// audit records action on the resource with id within the transaction q of
+// the change itself, so that the two commit or fail together. The change is
+// the difference between the JSON renderings of before and after, either of
+// which is nil for a resource created or removed. The actor is whoever
+// authenticated the request ctx belongs to.
+func audit(ctx context.Context, q querier, action, resource string, id int, before, after interface{}) error {
+	changes, err := diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	var actor, key *int
+	if userID, ok := auth.UserID(ctx); ok {
+		actor = &userID
+	}
+
+	if k, ok := auth.APIKey(ctx); ok {
+		key = &k.ID
+	}
+
+	_, err = q.ExecContext(ctx,
+		`INSERT INTO audit_log (actor_id, api_key_id, action, resource, resource_id, changes)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		actor, key, action, resource, id, body)
+
+	return err
+}
+
+// diff returns the fields whose values differ between before and after.
+func diff(before, after interface{}) (map[string]models.FieldChange, error) {
+	from, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.FieldChange)
+
+	for name, v := range to {
+		if old, ok := from[name]; !ok || !reflect.DeepEqual(old, v) {
+			changes[name] = models.FieldChange{From: old, To: v}
+		}
+	}
+
+	for name, old := range from {
+		if _, ok := to[name]; !ok {
+			changes[name] = models.FieldChange{From: old}
+		}
+	}
+
+	return changes, nil
+}
+
+// fields returns v's JSON rendering as a map of its fields.
+func fields(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	err = json.Unmarshal(body, &m)
+
+	return m, err
+}
+
+func (s *sqlStore) GetAuditLog(ctx context.Context, filter AuditFilter, page Page) ([]models.AuditEntry, int, error) {
+	where := ` WHERE resource = $1 AND ($2 = 0 OR resource_id = $2)`
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`+where,
+		filter.Resource, filter.ResourceID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, actor_id, api_key_id, action, resource, resource_id, changes, created_at FROM audit_log`+where+`
+		ORDER BY id DESC LIMIT $3 OFFSET $4`,
+		filter.Resource, filter.ResourceID, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+
+	for rows.Next() {
+		var (
+			e       models.AuditEntry
+			changes []byte
+		)
+
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.APIKeyID, &e.Action, &e.Resource, &e.ResourceID, &changes,
+			&e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+
+		if err := json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, 0, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, total, rows.Err()
+}
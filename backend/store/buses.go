@@ -0,0 +1,471 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+const selectBus = `SELECT b.id, b.capacity, m.id, m.name, m.seats_left, m.seats_right, m.decks, m.berths, b.seat_fare, b.class, b.avg_speed_kmh, to_char(b.departure_time, 'HH24:MI'), b.run_days, b.timezone, b.version, b.deleted_at, b.booking_cutoff_minutes, r.id, r.name, array_to_string(b.amenities, ',') FROM buses b JOIN routes r ON r.id = b.route_id JOIN bus_models m ON m.id = b.model_id`
+
+const selectBusModel = `SELECT id, name, seats_left, seats_right, decks, berths FROM bus_models`
+
+const selectStop = `SELECT id, name, lat, lng, radius_m FROM stops`
+
+// busSortColumns maps each of BusSortFields to what it orders by.
+var busSortColumns = map[string]string{
+	SortDeparture: `b.departure_time`,
+	SortFare:      `COALESCE((SELECT f.seat_fare FROM route_fares f WHERE f.route_id = b.route_id), b.seat_fare)`,
+	SortOccupancy: `(SELECT COUNT(*) FROM ticket_seats s WHERE s.bus_id = b.id
+		AND (s.travel_date AT TIME ZONE b.timezone)::date = (now() AT TIME ZONE b.timezone)::date)::float
+		/ NULLIF(b.capacity, 0)`,
+}
+
+// busOrderBy renders order as an ORDER BY clause, breaking ties by
+// departure and then ID so pages are stable.
+func busOrderBy(order BusOrder) string {
+	column, ok := busSortColumns[order.Field]
+	if !ok {
+		column = busSortColumns[SortDeparture]
+	}
+
+	dir := "ASC"
+	if order.Desc {
+		dir = "DESC"
+	}
+
+	return fmt.Sprintf(` ORDER BY %s %s NULLS LAST, b.departure_time, b.id`, column, dir)
+}
+
+func (s *sqlStore) GetBuses(ctx context.Context, filter BusFilter, order BusOrder, page Page) ([]models.Bus, int, error) {
+	where, args := busWhere(filter)
+	query := fmt.Sprintf(`%s%s%s LIMIT $%d OFFSET $%d`, selectBus, where, busOrderBy(order), len(args)+1, len(args)+2)
+
+	var (
+		buses []models.Bus
+		total int
+	)
+
+	err := s.read(ctx, func(q querier) error {
+		if err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM buses b`+where, args...).Scan(&total); err != nil {
+			return err
+		}
+
+		rows, err := q.QueryContext(ctx, query, append(args, page.Limit, page.Offset)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		buses = []models.Bus{}
+
+		for rows.Next() {
+			b, err := scanBus(rows)
+			if err != nil {
+				return err
+			}
+
+			buses = append(buses, b)
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		return loadRouteStops(ctx, q, buses)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return buses, total, nil
+}
+
+func (s *sqlStore) GetBusByID(ctx context.Context, id int) (models.Bus, error) {
+	b, err := scanBus(s.db.QueryRowContext(ctx, selectBus+` WHERE b.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Bus{}, ErrNotFound
+	} else if err != nil {
+		return models.Bus{}, err
+	}
+
+	if b.DeletedAt != nil {
+		return b, ErrBusDeleted
+	}
+
+	buses := []models.Bus{b}
+	if err := loadRouteStops(ctx, s.db, buses); err != nil {
+		return models.Bus{}, err
+	}
+
+	return buses[0], nil
+}
+
+func (s *sqlStore) CreateBus(ctx context.Context, nb models.NewBus) (models.Bus, error) {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var route, model bool
+		if err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM routes WHERE id = $1), EXISTS (SELECT 1 FROM bus_models WHERE id = $2)`,
+			nb.RouteID, nb.ModelID).Scan(&route, &model); err != nil {
+			return err
+		}
+
+		switch {
+		case !route:
+			return ErrRouteNotFound
+		case !model:
+			return ErrBusModelNotFound
+		}
+
+		var id int
+
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO buses (id, route_id, model_id, capacity, seat_fare, class, departure_time, run_days, timezone,
+				avg_speed_kmh, booking_cutoff_minutes, amenities)
+			VALUES ($1, $2, $3, $4, $5, $6, $7::time, $8, $9, $10, $11, $12::text[])
+			ON CONFLICT (id) DO NOTHING RETURNING id`,
+			nb.ID, nb.RouteID, nb.ModelID, nb.Capacity, nb.SeatFare, nb.Class, nb.DepartureTime, nb.RunDays, nb.Timezone,
+			nb.AvgSpeedKmh, nb.BookingCutoffMinutes, "{"+strings.Join(nb.Amenities, ",")+"}").Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrBusExists
+		} else if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, models.AuditCreate, models.ResourceBus, id, nil, nb)
+	})
+	if err != nil {
+		return models.Bus{}, err
+	}
+
+	return s.GetBusByID(ctx, nb.ID)
+}
+
+func (s *sqlStore) GetBusModels(ctx context.Context) ([]models.BusModel, error) {
+	rows, err := s.db.QueryContext(ctx, selectBusModel+` ORDER BY name, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	layouts := []models.BusModel{}
+
+	for rows.Next() {
+		m, err := scanBusModel(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		layouts = append(layouts, m)
+	}
+
+	return layouts, rows.Err()
+}
+
+// getBusModel returns the model of bus busID.
+func getBusModel(ctx context.Context, q querier, busID int) (models.BusModel, error) {
+	m, err := scanBusModel(q.QueryRowContext(ctx,
+		selectBusModel+` WHERE id = (SELECT model_id FROM buses WHERE id = $1)`, busID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.BusModel{}, ErrNotFound
+	}
+
+	return m, err
+}
+
+func scanBusModel(row rowScanner) (models.BusModel, error) {
+	var m models.BusModel
+	err := row.Scan(&m.ID, &m.Name, &m.SeatsLeft, &m.SeatsRight, &m.Decks, &m.Berths)
+
+	return m, err
+}
+
+func (s *sqlStore) UpdateSchedule(ctx context.Context, busID int, sched models.Schedule) (models.Bus, []int, error) {
+	var affected []int
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var (
+			oldDeparture string
+			oldRunDays   string
+			oldRoute     int
+			oldCutoff    *int
+			version      int
+			deleted      bool
+		)
+
+		err := tx.QueryRowContext(ctx,
+			`SELECT to_char(departure_time, 'HH24:MI'), run_days, route_id, booking_cutoff_minutes, version,
+				deleted_at IS NOT NULL
+			FROM buses WHERE id = $1 FOR UPDATE`,
+			busID).Scan(&oldDeparture, &oldRunDays, &oldRoute, &oldCutoff, &version, &deleted)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrNotFound
+		case err != nil:
+			return err
+		case deleted:
+			return ErrBusDeleted
+		case version != sched.Version:
+			return &VersionConflictError{Current: version}
+		}
+
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM routes WHERE id = $1)`, sched.RouteID).Scan(&exists); err != nil {
+			return err
+		} else if !exists {
+			return ErrRouteNotFound
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE buses SET departure_time = $2::time, run_days = $3, route_id = $4, booking_cutoff_minutes = $5,
+			version = version + 1 WHERE id = $1`,
+			busID, sched.DepartureTime, sched.RunDays, sched.RouteID, sched.BookingCutoffMinutes); err != nil {
+			return err
+		}
+
+		before := models.Schedule{
+			DepartureTime: oldDeparture, RunDays: oldRunDays, RouteID: oldRoute, Version: version,
+			BookingCutoffMinutes: oldCutoff,
+		}
+		after := sched
+		after.Version = version + 1
+
+		if err := audit(ctx, tx, models.AuditUpdate, models.ResourceBus, busID, before, after); err != nil {
+			return err
+		}
+
+		affected = []int{}
+
+		if sched.DepartureTime != oldDeparture || sched.RunDays != oldRunDays || sched.RouteID != oldRoute {
+			rows, err := tx.QueryContext(ctx,
+				`SELECT id FROM tickets WHERE bus_id = $1 AND status = $2 AND travel_date > now() ORDER BY id`,
+				busID, models.StatusBooked)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var id int
+				if err := rows.Scan(&id); err != nil {
+					return err
+				}
+
+				affected = append(affected, id)
+			}
+
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Bus{}, nil, err
+	}
+
+	bus, err := s.GetBusByID(ctx, busID)
+
+	return bus, affected, err
+}
+
+func (s *sqlStore) DeleteBus(ctx context.Context, id int) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var deletedAt time.Time
+
+		err := tx.QueryRowContext(ctx,
+			`UPDATE buses SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING deleted_at`, id).
+			Scan(&deletedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, models.AuditDelete, models.ResourceBus, id,
+			map[string]interface{}{"deleted_at": nil}, map[string]interface{}{"deleted_at": deletedAt})
+	})
+}
+
+func (s *sqlStore) GetRouteStops(ctx context.Context, routeID int) ([]models.RouteStop, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT s.id, s.name, s.lat, s.lng, s.radius_m FROM route_stops rs JOIN stops s ON s.id = rs.stop_id
+		WHERE rs.route_id = $1 ORDER BY rs.position`, routeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stops []models.RouteStop
+
+	for rows.Next() {
+		var st models.RouteStop
+		if err := rows.Scan(&st.StopID, &st.Name, &st.Lat, &st.Lng, &st.RadiusM); err != nil {
+			return nil, err
+		}
+
+		stops = append(stops, st)
+	}
+
+	return stops, rows.Err()
+}
+
+func (s *sqlStore) GetStops(ctx context.Context) ([]models.Stop, error) {
+	return queryStops(ctx, s.db, selectStop+` ORDER BY name, id`)
+}
+
+func (s *sqlStore) FindStop(ctx context.Context, name string) (models.Stop, error) {
+	stops, err := queryStops(ctx, s.db, selectStop+` WHERE name_key = $1`, models.StopKey(name))
+	if err != nil {
+		return models.Stop{}, err
+	} else if len(stops) == 0 {
+		return models.Stop{}, ErrNotFound
+	}
+
+	return stops[0], nil
+}
+
+func queryStops(ctx context.Context, q querier, query string, args ...interface{}) ([]models.Stop, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stops := []models.Stop{}
+
+	for rows.Next() {
+		var st models.Stop
+		if err := rows.Scan(&st.ID, &st.Name, &st.Lat, &st.Lng, &st.RadiusM); err != nil {
+			return nil, err
+		}
+
+		stops = append(stops, st)
+	}
+
+	return stops, rows.Err()
+}
+
+// busWhere builds the WHERE clause (with a leading space) for filter, with
+// the buses table aliased as b.
+func busWhere(filter BusFilter) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	if filter.FromStop != 0 && filter.ToStop != 0 {
+		args = append(args, filter.FromStop, filter.ToStop)
+		conds = append(conds, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM route_stops f JOIN route_stops t ON t.route_id = f.route_id
+			WHERE f.route_id = b.route_id AND f.stop_id = $%d AND t.stop_id = $%d AND f.position < t.position)`,
+			len(args)-1, len(args)))
+	}
+
+	if !filter.IncludeDeleted {
+		conds = append(conds, `b.deleted_at IS NULL`)
+	}
+
+	if filter.DepartureAfter != "" {
+		args = append(args, filter.DepartureAfter)
+		conds = append(conds, fmt.Sprintf(`b.departure_time >= $%d::time`, len(args)))
+	}
+
+	if filter.DepartureBefore != "" {
+		args = append(args, filter.DepartureBefore)
+		conds = append(conds, fmt.Sprintf(`b.departure_time <= $%d::time`, len(args)))
+	}
+
+	if len(filter.Amenities) > 0 {
+		args = append(args, "{"+strings.Join(filter.Amenities, ",")+"}")
+		conds = append(conds, fmt.Sprintf(`b.amenities @> $%d::text[]`, len(args)))
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBus(row rowScanner) (models.Bus, error) {
+	var (
+		b         models.Bus
+		amenities string
+	)
+
+	err := row.Scan(&b.ID, &b.Capacity, &b.Model.ID, &b.Model.Name, &b.Model.SeatsLeft, &b.Model.SeatsRight, &b.Model.Decks, &b.Model.Berths, &b.SeatFare, &b.Class, &b.AvgSpeedKmh, &b.DepartureTime, &b.RunDays, &b.Timezone, &b.Version, &b.DeletedAt, &b.BookingCutoffMinutes, &b.Route.ID, &b.Route.Name, &amenities)
+
+	b.Amenities = []string{}
+	if amenities != "" {
+		b.Amenities = strings.Split(amenities, ",")
+	}
+
+	return b, err
+}
+
+// loadRouteStops fills in the stops of each bus's route.
+func loadRouteStops(ctx context.Context, q querier, buses []models.Bus) error {
+	if len(buses) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, 0, len(buses))
+	for _, b := range buses {
+		ids = append(ids, b.Route.ID)
+	}
+
+	rows, err := q.QueryContext(ctx,
+		`SELECT rs.route_id, s.name FROM route_stops rs JOIN stops s ON s.id = rs.stop_id
+		WHERE rs.route_id IN (`+placeholders(1, len(ids))+`) ORDER BY rs.route_id, rs.position`, ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	stops := make(map[int][]string)
+
+	for rows.Next() {
+		var (
+			routeID int
+			name    string
+		)
+
+		if err := rows.Scan(&routeID, &name); err != nil {
+			return err
+		}
+
+		stops[routeID] = append(stops[routeID], name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range buses {
+		buses[i].Route.Stops = stops[buses[i].Route.ID]
+	}
+
+	return nil
+}
+
+// placeholders returns "$start, $start+1, ..." for n query arguments.
+func placeholders(start, n int) string {
+	p := make([]string, n)
+	for i := range p {
+		p[i] = fmt.Sprintf("$%d", start+i)
+	}
+
+	return strings.Join(p, ", ")
+}
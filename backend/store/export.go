@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+func (s *sqlStore) GetTripTickets(ctx context.Context, busID int, from, to time.Time) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+
+	err := s.read(ctx, func(q querier) error {
+		rows, err := q.QueryContext(ctx,
+			`SELECT t.id, t.user_id, t.bus_id, t.travel_date, b.timezone,
+				CASE WHEN EXISTS (SELECT 1 FROM ticket_seats s WHERE s.ticket_id = t.id AND s.boarded_at IS NOT NULL)
+					THEN $4 ELSE t.status END,
+				t.fare, t.discount, COALESCE(t.discount_code, ''), t.cancelled_at,
+				array_to_string(ARRAY(SELECT seat_number FROM ticket_seats s WHERE s.ticket_id = t.id ORDER BY seat_number), ',')
+			FROM tickets t JOIN buses b ON b.id = t.bus_id WHERE t.bus_id = $1 AND t.travel_date >= $2 AND t.travel_date < $3
+			ORDER BY t.travel_date, t.id`, busID, from, to, models.StatusBoarded)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		tickets = []models.Ticket{}
+
+		for rows.Next() {
+			var (
+				t     models.Ticket
+				seats string
+			)
+
+			err := rows.Scan(&t.ID, &t.UserID, &t.BusID, &t.TravelDate, &t.Timezone, &t.Status,
+				&t.Fare, &t.Discount, &t.DiscountCode, &t.CancelledAt, &seats)
+			if err != nil {
+				return err
+			}
+
+			t.OriginalFare = t.Fare + t.Discount
+
+			t.SeatNumbers, err = parseSeatList(seats)
+			if err != nil {
+				return err
+			}
+
+			tickets = append(tickets, t.InLocalTime())
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tickets, nil
+}
+
+func (s *sqlStore) EachTicket(ctx context.Context, busID int, from, to time.Time, fn func(models.Ticket) error) error {
+	return s.read(ctx, func(q querier) (err error) {
+		sent := false
+
+		// Once tickets have gone to fn, reading them again from the primary
+		// would send them twice, so read must not retry a failure after it.
+		defer func() {
+			if sent && unreachable(err) {
+				err = fmt.Errorf("reading tickets was cut off: %v", err)
+			}
+		}()
+
+		rows, err := q.QueryContext(ctx,
+			`SELECT t.id, t.user_id, t.bus_id, t.travel_date, b.timezone, t.status, t.fare, t.discount,
+				COALESCE(t.discount_code, ''), t.cancelled_at,
+				array_to_string(ARRAY(SELECT seat_number FROM ticket_seats s WHERE s.ticket_id = t.id ORDER BY seat_number), ',')
+			FROM tickets t JOIN buses b ON b.id = t.bus_id WHERE t.bus_id = $1 AND t.travel_date >= $2 AND t.travel_date < $3
+			ORDER BY t.travel_date, t.id`, busID, from, to)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				t     models.Ticket
+				seats string
+			)
+
+			err := rows.Scan(&t.ID, &t.UserID, &t.BusID, &t.TravelDate, &t.Timezone, &t.Status,
+				&t.Fare, &t.Discount, &t.DiscountCode, &t.CancelledAt, &seats)
+			if err != nil {
+				return err
+			}
+
+			t.OriginalFare = t.Fare + t.Discount
+
+			t.SeatNumbers, err = parseSeatList(seats)
+			if err != nil {
+				return err
+			}
+
+			sent = true
+
+			if err := fn(t.InLocalTime()); err != nil {
+				return err
+			}
+		}
+
+		return rows.Err()
+	})
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+func (s *sqlStore) GetRouteFare(ctx context.Context, routeID int) (money.Money, error) {
+	var fare money.Money
+
+	err := s.db.QueryRowContext(ctx, `SELECT seat_fare FROM route_fares WHERE route_id = $1`, routeID).Scan(&fare)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+
+	return fare, err
+}
+
+func (s *sqlStore) SetRouteFare(ctx context.Context, f models.RouteFare) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var before *models.RouteFare
+
+		old := models.RouteFare{RouteID: f.RouteID}
+
+		err := tx.QueryRowContext(ctx, `SELECT seat_fare FROM route_fares WHERE route_id = $1 FOR UPDATE`, f.RouteID).
+			Scan(&old.SeatFare)
+		if err == nil {
+			before = &old
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO route_fares (route_id, seat_fare) SELECT id, $2 FROM routes WHERE id = $1
+			ON CONFLICT (route_id) DO UPDATE SET seat_fare = EXCLUDED.seat_fare`, f.RouteID, f.SeatFare)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrRouteNotFound
+		}
+
+		if before == nil {
+			return audit(ctx, tx, models.AuditCreate, models.ResourceRouteFare, f.RouteID, nil, f)
+		}
+
+		return audit(ctx, tx, models.AuditUpdate, models.ResourceRouteFare, f.RouteID, before, f)
+	})
+}
+
+func (s *sqlStore) GetDiscountCode(ctx context.Context, code string) (models.DiscountCode, error) {
+	d := models.DiscountCode{Code: code}
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT kind, CASE kind WHEN 'percent' THEN amount ELSE 0 END, CASE kind WHEN 'flat' THEN amount ELSE 0 END,
+			expires_at, max_uses, uses
+		FROM discount_codes WHERE code = $1`, code).
+		Scan(&d.Kind, &d.Percent, &d.Flat, &d.ExpiresAt, &d.MaxUses, &d.Uses)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.DiscountCode{}, ErrNotFound
+	}
+
+	return d, err
+}
+
+// redeemDiscount records one use of code within tx, provided it has neither
+// expired nor run out of uses.
+func redeemDiscount(ctx context.Context, tx *sql.Tx, code string) error {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE discount_codes SET uses = uses + 1
+		WHERE code = $1 AND (expires_at IS NULL OR expires_at > now()) AND (max_uses IS NULL OR uses < max_uses)`, code)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrDiscountUnavailable
+	}
+
+	return nil
+}
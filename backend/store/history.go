@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+func (s *sqlStore) GetUserTickets(ctx context.Context, userID int, when string, page Page) ([]models.TripTicket, int, error) {
+	where, order := ` WHERE t.user_id = $1`, `t.travel_date DESC, t.id DESC`
+
+	switch when {
+	case TicketsUpcoming:
+		where, order = where+` AND t.travel_date >= now()`, `t.travel_date, t.id`
+	case TicketsPast:
+		where += ` AND t.travel_date < now()`
+	}
+
+	query := fmt.Sprintf(
+		`SELECT t.id, t.user_id, t.bus_id, t.travel_date, b.timezone, t.status, t.fare, t.discount,
+			COALESCE(t.discount_code, ''), t.cancelled_at,
+			array_to_string(ARRAY(SELECT seat_number FROM ticket_seats s WHERE s.ticket_id = t.id ORDER BY seat_number), ','),
+			r.name, b.class, to_char(b.departure_time, 'HH24:MI')
+		FROM tickets t JOIN buses b ON b.id = t.bus_id JOIN routes r ON r.id = b.route_id%s
+		ORDER BY %s LIMIT $2 OFFSET $3`, where, order)
+
+	var (
+		trips []models.TripTicket
+		total int
+	)
+
+	err := s.read(ctx, func(q querier) error {
+		if err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM tickets t`+where, userID).Scan(&total); err != nil {
+			return err
+		}
+
+		rows, err := q.QueryContext(ctx, query, userID, page.Limit, page.Offset)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		trips = []models.TripTicket{}
+
+		for rows.Next() {
+			var (
+				trip  models.TripTicket
+				seats string
+			)
+
+			t := &trip.Ticket
+
+			err := rows.Scan(&t.ID, &t.UserID, &t.BusID, &t.TravelDate, &t.Timezone, &t.Status,
+				&t.Fare, &t.Discount, &t.DiscountCode, &t.CancelledAt, &seats,
+				&trip.RouteName, &trip.BusClass, &trip.DepartureTime)
+			if err != nil {
+				return err
+			}
+
+			if t.SeatNumbers, err = parseSeatList(seats); err != nil {
+				return err
+			}
+
+			t.OriginalFare = t.Fare + t.Discount
+			trip.Ticket = t.InLocalTime()
+			trips = append(trips, trip)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return trips, total, nil
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+func (s *sqlStore) CreateHold(ctx context.Context, h models.SeatHold) (models.SeatHold, error) {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		capacity, err := lockBus(ctx, tx, h.BusID)
+		if err != nil {
+			return err
+		}
+
+		t := models.Ticket{
+			UserID: h.UserID, BusID: h.BusID, SeatNumbers: h.SeatNumbers, TravelDate: h.TravelDate,
+			SeatLimit: h.SeatLimit,
+		}
+		if err := checkSeats(ctx, tx, t, capacity); err != nil {
+			return err
+		}
+
+		if err := checkSeatLimit(ctx, tx, t); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO seat_holds (token, user_id, bus_id, travel_date, seat_numbers, expires_at, seat_limit)
+			VALUES ($1, $2, $3, $4, $5::integer[], $6, $7)`,
+			h.Token, h.UserID, h.BusID, h.TravelDate, seatArray(h.SeatNumbers), h.ExpiresAt, h.SeatLimit)
+
+		return err
+	})
+	if err != nil {
+		return models.SeatHold{}, err
+	}
+
+	return h, nil
+}
+
+func (s *sqlStore) GetHold(ctx context.Context, token string) (models.SeatHold, error) {
+	h := models.SeatHold{Token: token}
+
+	var seats string
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT user_id, bus_id, travel_date, array_to_string(seat_numbers, ','), expires_at
+		FROM seat_holds WHERE token = $1`, token).
+		Scan(&h.UserID, &h.BusID, &h.TravelDate, &seats, &h.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SeatHold{}, ErrNotFound
+	} else if err != nil {
+		return models.SeatHold{}, err
+	}
+
+	h.SeatNumbers, err = parseSeatList(seats)
+
+	return h, err
+}
+
+func (s *sqlStore) DeleteExpiredHolds(ctx context.Context, now time.Time) ([]models.SeatHold, error) {
+	// A hold being confirmed at this moment is locked by the booking's
+	// transaction. Skip it rather than wait: the booking either consumes it
+	// or fails, leaving it for the next pass.
+	rows, err := s.db.QueryContext(ctx,
+		`DELETE FROM seat_holds WHERE token IN (
+			SELECT token FROM seat_holds WHERE expires_at <= $1 FOR UPDATE SKIP LOCKED)
+		RETURNING token, user_id, bus_id, travel_date, array_to_string(seat_numbers, ','), expires_at`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []models.SeatHold
+
+	for rows.Next() {
+		var (
+			h     models.SeatHold
+			seats string
+		)
+
+		if err := rows.Scan(&h.Token, &h.UserID, &h.BusID, &h.TravelDate, &seats, &h.ExpiresAt); err != nil {
+			return nil, err
+		}
+
+		if h.SeatNumbers, err = parseSeatList(seats); err != nil {
+			return nil, err
+		}
+
+		holds = append(holds, h)
+	}
+
+	return holds, rows.Err()
+}
+
+// consumeHold deletes the hold t confirms so that its seats pass checkSeats
+// and it cannot be used twice.
+func consumeHold(ctx context.Context, tx *sql.Tx, t models.Ticket) error {
+	var expires time.Time
+
+	err := tx.QueryRowContext(ctx,
+		`DELETE FROM seat_holds WHERE token = $1 AND user_id = $2 AND bus_id = $3 RETURNING expires_at`,
+		t.HoldToken, t.UserID, t.BusID).Scan(&expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrHoldNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if !expires.After(time.Now()) {
+		return ErrHoldExpired
+	}
+
+	return nil
+}
+
+// seatArray formats seats as a Postgres array literal.
+func seatArray(seats []int) string {
+	p := make([]string, len(seats))
+	for i, n := range seats {
+		p[i] = strconv.Itoa(n)
+	}
+
+	return "{" + strings.Join(p, ",") + "}"
+}
+
+// parseSeatList parses the comma-separated seats produced by array_to_string.
+func parseSeatList(list string) ([]int, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	var seats []int
+
+	for _, s := range strings.Split(list, ",") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+
+		seats = append(seats, n)
+	}
+
+	return seats, nil
+}
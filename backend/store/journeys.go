@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+func (s *sqlStore) CreateJourney(ctx context.Context, j models.Journey) (models.Journey, error) {
+	ts := make([]models.Ticket, len(j.Legs))
+	for i, leg := range j.Legs {
+		ts[i] = leg.Ticket
+	}
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		created, err := bookAll(ctx, tx, ts)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO journeys (user_id) VALUES ($1) RETURNING id, created_at`, j.UserID).Scan(&j.ID, &j.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		j.TotalFare = 0
+
+		for i, t := range created {
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO journey_legs (journey_id, leg, ticket_id, from_stop, to_stop) VALUES ($1, $2, $3, $4, $5)`,
+				j.ID, i, t.ID, j.Legs[i].From, j.Legs[i].To); err != nil {
+				return err
+			}
+
+			j.Legs[i].Ticket = t
+			j.TotalFare += t.Fare
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Journey{}, err
+	}
+
+	return j, nil
+}
+
+func (s *sqlStore) GetJourney(ctx context.Context, id int) (models.Journey, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return models.Journey{}, err
+	}
+	defer tx.Rollback()
+
+	j := models.Journey{ID: id}
+
+	err = tx.QueryRowContext(ctx, `SELECT user_id, created_at FROM journeys WHERE id = $1`, id).
+		Scan(&j.UserID, &j.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Journey{}, ErrNotFound
+	} else if err != nil {
+		return models.Journey{}, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT ticket_id, from_stop, to_stop FROM journey_legs WHERE journey_id = $1 ORDER BY leg`, id)
+	if err != nil {
+		return models.Journey{}, err
+	}
+
+	for rows.Next() {
+		var leg models.JourneyTicket
+		if err := rows.Scan(&leg.ID, &leg.From, &leg.To); err != nil {
+			rows.Close()
+			return models.Journey{}, err
+		}
+
+		j.Legs = append(j.Legs, leg)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return models.Journey{}, err
+	}
+
+	// The tickets are loaded once the legs are read, as tx carries one query
+	// at a time.
+	for i := range j.Legs {
+		t, err := getTicket(ctx, tx, j.Legs[i].ID, false)
+		if err != nil {
+			return models.Journey{}, err
+		}
+
+		j.Legs[i].Ticket = t
+		j.TotalFare += t.Fare
+	}
+
+	return j, nil
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+// seatDeparture is when the bus of ticket seat s, joined to buses b, leaves
+// on the day its travel date falls on in the bus's time zone, or the travel
+// date itself for a bus without a departure time.
+const seatDeparture = `COALESCE(((s.travel_date AT TIME ZONE b.timezone)::date + b.departure_time) AT TIME ZONE b.timezone,
+	s.travel_date)`
+
+const selectNoShow = `SELECT n.ticket_id, t.user_id, n.bus_id, n.seat_number, n.travel_date, b.timezone,
+	t.status = '` + models.StatusValidated + `', n.flagged_at, n.released
+	FROM no_shows n JOIN tickets t ON t.id = n.ticket_id JOIN buses b ON b.id = n.bus_id`
+
+func (s *sqlStore) FlagNoShows(ctx context.Context, since, before time.Time, release bool) ([]models.NoShow, error) {
+	var flagged []models.NoShow
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		// Seats being boarded right now are left for the next pass.
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO no_shows (ticket_id, seat_number, bus_id, travel_date, released)
+			SELECT s.ticket_id, s.seat_number, s.bus_id, s.travel_date, $5
+			FROM ticket_seats s JOIN tickets t ON t.id = s.ticket_id JOIN buses b ON b.id = s.bus_id
+			WHERE t.status IN ($3, $4) AND s.boarded_at IS NULL
+				AND `+seatDeparture+` >= $1 AND `+seatDeparture+` < $2
+			FOR UPDATE OF s SKIP LOCKED
+			ON CONFLICT (ticket_id, seat_number) DO NOTHING`,
+			since, before, models.StatusBooked, models.StatusValidated, release); err != nil {
+			return err
+		}
+
+		// now() is the same for the whole transaction, so it picks out the
+		// rows flagged above.
+		var err error
+		if flagged, err = queryNoShows(ctx, tx, selectNoShow+` WHERE n.flagged_at = now() ORDER BY n.ticket_id, n.seat_number`); err != nil {
+			return err
+		}
+
+		if !release {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM ticket_seats s USING no_shows n
+			WHERE n.ticket_id = s.ticket_id AND n.seat_number = s.seat_number AND n.released AND n.flagged_at = now()`)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return flagged, nil
+}
+
+func (s *sqlStore) GetNoShows(ctx context.Context, busID int, from, to time.Time) ([]models.NoShow, error) {
+	return queryNoShows(ctx, s.db,
+		selectNoShow+` WHERE n.bus_id = $1 AND n.travel_date >= $2 AND n.travel_date < $3 ORDER BY n.seat_number, n.ticket_id`,
+		busID, from, to)
+}
+
+func queryNoShows(ctx context.Context, q querier, query string, args ...interface{}) ([]models.NoShow, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	noShows := []models.NoShow{}
+
+	for rows.Next() {
+		var (
+			n        models.NoShow
+			timezone string
+		)
+
+		if err := rows.Scan(&n.TicketID, &n.UserID, &n.BusID, &n.SeatNumber, &n.TravelDate, &timezone,
+			&n.Validated, &n.FlaggedAt, &n.Released); err != nil {
+			return nil, err
+		}
+
+		n.TravelDate = *localTime(n.TravelDate, timezone)
+		noShows = append(noShows, n)
+	}
+
+	return noShows, rows.Err()
+}
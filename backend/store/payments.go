@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+const selectPayment = `SELECT id, intent_id, client_secret, user_id, hold_token, amount, discount,
+	COALESCE(discount_code, ''), surge_multiplier, status, COALESCE(failure_reason, ''), ticket_id, expires_at,
+	created_at FROM payments`
+
+func scanPayment(row rowScanner) (models.Payment, error) {
+	var p models.Payment
+	err := row.Scan(&p.ID, &p.IntentID, &p.ClientSecret, &p.UserID, &p.HoldToken, &p.Amount, &p.Discount,
+		&p.DiscountCode, &p.SurgeMultiplier, &p.Status, &p.FailureReason, &p.TicketID, &p.ExpiresAt, &p.CreatedAt)
+
+	return p, err
+}
+
+func (s *sqlStore) CreatePayment(ctx context.Context, p models.Payment) (models.Payment, bool, error) {
+	var (
+		payment models.Payment
+		created bool
+	)
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var expires time.Time
+
+		err := tx.QueryRowContext(ctx,
+			`SELECT expires_at FROM seat_holds WHERE token = $1 AND user_id = $2 FOR UPDATE`, p.HoldToken, p.UserID).
+			Scan(&expires)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrHoldNotFound
+		case err != nil:
+			return err
+		case !expires.After(time.Now()):
+			return ErrHoldExpired
+		}
+
+		existing, err := scanPayment(tx.QueryRowContext(ctx,
+			selectPayment+` WHERE hold_token = $1 AND status = $2`, p.HoldToken, models.PaymentPending))
+		if err == nil {
+			payment, created = existing, false
+
+			return nil
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		var code *string
+		if p.DiscountCode != "" {
+			code = &p.DiscountCode
+		}
+
+		p.Status = models.PaymentPending
+		p.ExpiresAt = expires
+
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO payments (intent_id, client_secret, user_id, hold_token, amount, discount, discount_code,
+				surge_multiplier, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at`,
+			p.IntentID, p.ClientSecret, p.UserID, p.HoldToken, p.Amount, p.Discount, code, p.SurgeMultiplier,
+			p.ExpiresAt).
+			Scan(&p.ID, &p.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		payment, created = p, true
+
+		return nil
+	})
+	if err != nil {
+		return models.Payment{}, false, err
+	}
+
+	return payment, created, nil
+}
+
+func (s *sqlStore) ApplyPaymentEvent(ctx context.Context, e models.PaymentEvent) (models.Payment, bool, error) {
+	var (
+		payment models.Payment
+		changed bool
+	)
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		p, err := scanPayment(tx.QueryRowContext(ctx, selectPayment+` WHERE intent_id = $1 FOR UPDATE`, e.IntentID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO payment_events (id, intent_id, type) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING`,
+			e.ID, e.IntentID, e.Type)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			payment, changed = p, false
+
+			return err
+		}
+
+		known := e.Type == models.EventPaymentSucceeded || e.Type == models.EventPaymentFailed
+		if !known || p.Status != models.PaymentPending {
+			payment, changed = p, false
+
+			return nil
+		}
+
+		if e.Type == models.EventPaymentSucceeded {
+			if _, err := tx.ExecContext(ctx, `SAVEPOINT confirm_payment`); err != nil {
+				return err
+			}
+
+			ticketID, err := bookPayment(ctx, tx, p)
+			if err == nil {
+				p.Status = models.PaymentSucceeded
+				p.TicketID = &ticketID
+			} else if !bookingRefused(err) {
+				return err
+			} else {
+				if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT confirm_payment`); err != nil {
+					return err
+				}
+
+				p.Status = models.PaymentFailed
+				p.FailureReason = "paid, but the seats could not be booked: " + err.Error()
+			}
+		} else {
+			p.Status = models.PaymentFailed
+			p.FailureReason = e.Reason
+		}
+
+		if p.Status == models.PaymentFailed {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM seat_holds WHERE token = $1`, p.HoldToken); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE payments SET status = $2, failure_reason = NULLIF($3, ''), ticket_id = $4 WHERE id = $1`,
+			p.ID, p.Status, p.FailureReason, p.TicketID); err != nil {
+			return err
+		}
+
+		payment, changed = p, true
+
+		return nil
+	})
+	if err != nil {
+		return models.Payment{}, false, err
+	}
+
+	return payment, changed, nil
+}
+
+// bookPayment books the seats held for p within tx, consuming the hold, and
+// returns the new ticket's ID.
+func bookPayment(ctx context.Context, tx *sql.Tx, p models.Payment) (int, error) {
+	t := models.Ticket{
+		UserID:          p.UserID,
+		Fare:            p.Amount,
+		Discount:        p.Discount,
+		DiscountCode:    p.DiscountCode,
+		HoldToken:       p.HoldToken,
+		SurgeMultiplier: p.SurgeMultiplier,
+	}
+
+	var seats string
+
+	err := tx.QueryRowContext(ctx,
+		`SELECT bus_id, travel_date, array_to_string(seat_numbers, ','), seat_limit FROM seat_holds WHERE token = $1`,
+		p.HoldToken).
+		Scan(&t.BusID, &t.TravelDate, &seats, &t.SeatLimit)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrHoldNotFound
+	} else if err != nil {
+		return 0, err
+	}
+
+	if t.SeatNumbers, err = parseSeatList(seats); err != nil {
+		return 0, err
+	}
+
+	capacity, err := lockBus(ctx, tx, t.BusID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := consumeHold(ctx, tx, t); err != nil {
+		return 0, err
+	}
+
+	if err := checkSeats(ctx, tx, t, capacity); err != nil {
+		return 0, err
+	}
+
+	// The user may have booked other seats on the departure since holding
+	// these.
+	if err := checkSeatLimit(ctx, tx, t); err != nil {
+		return 0, err
+	}
+
+	t, err = insertTicket(ctx, tx, t)
+
+	return t.ID, err
+}
+
+// bookingRefused reports whether err is why a booking could not be made, as
+// opposed to a failure to make it.
+func bookingRefused(err error) bool {
+	var (
+		unavailable *SeatsUnavailableError
+		overLimit   *SeatLimitError
+	)
+
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrHoldNotFound) || errors.Is(err, ErrHoldExpired) ||
+		errors.Is(err, ErrDiscountUnavailable) || errors.As(err, &unavailable) || errors.As(err, &overLimit)
+}
+
+func (s *sqlStore) ExpirePayments(ctx context.Context, now time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE payments SET status = $1 WHERE status = $2 AND expires_at <= $3`,
+		models.PaymentExpired, models.PaymentPending, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
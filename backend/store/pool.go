@@ -0,0 +1,32 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PoolConfig bounds the connections a Store keeps to the database.
+type PoolConfig struct {
+	// MaxOpenConns caps connections in use or idle; requests beyond it wait
+	// for one to be released.
+	MaxOpenConns int
+	// MaxIdleConns is how many released connections are kept for reuse.
+	MaxIdleConns int
+	// ConnMaxLifetime retires connections after this long, so that they are
+	// spread again after a database failover.
+	ConnMaxLifetime time.Duration
+}
+
+// DefaultPool keeps the service well inside Postgres's default limit of 100
+// connections even with a few instances running.
+var DefaultPool = PoolConfig{
+	MaxOpenConns:    25,
+	MaxIdleConns:    10,
+	ConnMaxLifetime: 30 * time.Minute,
+}
+
+func (p PoolConfig) apply(db *sql.DB) {
+	db.SetMaxOpenConns(p.MaxOpenConns)
+	db.SetMaxIdleConns(p.MaxIdleConns)
+	db.SetConnMaxLifetime(p.ConnMaxLifetime)
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+func (s *sqlStore) RecordPosition(ctx context.Context, u models.LocationUpdate) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO bus_positions (bus_id, lat, lng, recorded_at) VALUES ($1, $2, $3, $4)`,
+		u.BusID, u.Lat, u.Lng, u.Timestamp)
+
+	return err
+}
+
+func (s *sqlStore) RecordPositions(ctx context.Context, us []models.LocationUpdate) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx,
+			`INSERT INTO bus_positions (bus_id, lat, lng, recorded_at) VALUES ($1, $2, $3, $4)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, u := range us {
+			if _, err := stmt.ExecContext(ctx, u.BusID, u.Lat, u.Lng, u.Timestamp); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *sqlStore) GetTrail(ctx context.Context, busID int, since time.Time, limit int) ([]models.LocationUpdate, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT lat, lng, recorded_at FROM bus_positions WHERE bus_id = $1 AND recorded_at >= $2
+		ORDER BY recorded_at, id LIMIT $3`, busID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trail := []models.LocationUpdate{}
+
+	for rows.Next() {
+		u := models.LocationUpdate{BusID: busID}
+		if err := rows.Scan(&u.Lat, &u.Lng, &u.Timestamp); err != nil {
+			return nil, err
+		}
+
+		trail = append(trail, u)
+	}
+
+	return trail, rows.Err()
+}
+
+func (s *sqlStore) PrunePositions(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM bus_positions WHERE recorded_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *sqlStore) RecordArrival(ctx context.Context, a models.StopArrival) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO stop_arrivals (bus_id, route_id, stop_id, scheduled_at, arrived_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (bus_id, stop_id, scheduled_at) DO NOTHING`,
+		a.BusID, a.RouteID, a.StopID, a.ScheduledAt, a.ArrivedAt)
+
+	return err
+}
+
+func (s *sqlStore) GetArrivals(ctx context.Context, routeID int, since time.Time) ([]models.StopArrival, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM routes WHERE id = $1)`, routeID).Scan(&exists); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrRouteNotFound
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT a.bus_id, a.stop_id, s.name, a.scheduled_at, a.arrived_at FROM stop_arrivals a
+		JOIN stops s ON s.id = a.stop_id WHERE a.route_id = $1 AND a.arrived_at >= $2
+		ORDER BY a.arrived_at, a.id`, routeID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	arrivals := []models.StopArrival{}
+
+	for rows.Next() {
+		a := models.StopArrival{RouteID: routeID}
+		if err := rows.Scan(&a.BusID, &a.StopID, &a.Stop, &a.ScheduledAt, &a.ArrivedAt); err != nil {
+			return nil, err
+		}
+
+		arrivals = append(arrivals, a)
+	}
+
+	return arrivals, rows.Err()
+}
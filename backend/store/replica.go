@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/breaker"
+)
+
+// Databases a Store reads from, as ReadFrom reports them.
+const (
+	DBPrimary = "primary"
+	DBReplica = "replica"
+)
+
+// Replica is a read replica of the primary database. Listing buses, users'
+// ticket history and exports read from it; everything else, and those too
+// while Breaker judges the replica unreachable, uses the primary.
+type Replica struct {
+	DB      *sql.DB
+	Breaker *breaker.Breaker
+}
+
+type readsKey struct{}
+
+// reads records which databases served the reads of one request.
+type reads struct {
+	mu      sync.Mutex
+	primary bool
+	replica bool
+}
+
+// TrackReads returns a copy of ctx in which the Store notes which database
+// each read that may go to the replica was served from, for ReadFrom.
+func TrackReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readsKey{}, &reads{})
+}
+
+// ReadFrom reports which databases served the reads made with a ctx from
+// TrackReads: DBReplica, DBPrimary, or both, replica first, when the replica
+// failed part way. Reads that never go to the replica count as DBPrimary.
+func ReadFrom(ctx context.Context) string {
+	r, ok := ctx.Value(readsKey{}).(*reads)
+	if !ok {
+		return ""
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var from []string
+
+	if r.replica {
+		from = append(from, DBReplica)
+	}
+
+	if r.primary || !r.replica {
+		from = append(from, DBPrimary)
+	}
+
+	return strings.Join(from, ", ")
+}
+
+func noteRead(ctx context.Context, db string) {
+	r, ok := ctx.Value(readsKey{}).(*reads)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if db == DBReplica {
+		r.replica = true
+	} else {
+		r.primary = true
+	}
+}
+
+// read runs fn against the replica, if there is one, and otherwise, or when
+// the replica cannot be reached, against the primary. fn may run twice and
+// so must only read, keeping nothing until it returns nil. A replica may lag
+// the primary's latest writes by a moment; only reads that can stand that
+// go through read.
+func (s *sqlStore) read(ctx context.Context, fn func(querier) error) error {
+	if s.replica == nil {
+		noteRead(ctx, DBPrimary)
+		return fn(s.db)
+	}
+
+	var err error
+
+	tried := s.replica.Breaker.Do(func() error {
+		err = fn(s.replica.DB)
+		if unreachable(err) && ctx.Err() == nil {
+			return err
+		}
+
+		return nil
+	})
+	if tried == nil {
+		noteRead(ctx, DBReplica)
+		return err
+	}
+
+	noteRead(ctx, DBPrimary)
+
+	return fn(s.db)
+}
+
+// unreachable reports whether err means the database could not serve the
+// query just then, rather than that the query was at fault: the connection
+// was refused or lost, or the server is starting up or replaying the
+// primary's changes over the rows being read.
+func unreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// 57P03 is a server that cannot accept connections yet.
+	if transient(err) || sqlState(err) == "57P03" || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr)
+}
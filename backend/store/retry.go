@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryConfig bounds how often a transaction that fails transiently is run
+// again.
+type RetryConfig struct {
+	// MaxAttempts counts the first run; 1 turns retries off.
+	MaxAttempts int
+	// Backoff is how long to wait before the first retry; each later retry
+	// waits twice as long as the one before, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetry rides out a deadlock or a dropped connection without holding
+// a request for more than a fraction of a second.
+var DefaultRetry = RetryConfig{
+	MaxAttempts: 3,
+	Backoff:     50 * time.Millisecond,
+	MaxBackoff:  time.Second,
+}
+
+// do runs op until it succeeds, fails with an error retryable rejects, or
+// has been run MaxAttempts times, and returns op's last error. It gives up
+// early, with ctx's error, if ctx is done while waiting to retry.
+func (c RetryConfig) do(ctx context.Context, op func() error, retryable func(error) bool) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= c.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff is how long to wait before the retry that follows the given
+// failed attempt, counting from 1.
+func (c RetryConfig) backoff(attempt int) time.Duration {
+	wait := c.Backoff
+
+	for i := 1; i < attempt && wait < c.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > c.MaxBackoff {
+		wait = c.MaxBackoff
+	}
+
+	return wait
+}
+
+// withTx runs fn in a transaction as WithTx does, running it again from the
+// start in a new transaction if it fails transiently. Only whole
+// transactions are retried: fn must not have effects outside tx.
+//
+// A commit that fails because the connection was lost is not retried, since
+// the transaction may have committed before it was; Postgres does roll back
+// one it refuses to commit over a serialization failure or deadlock, so those
+// are.
+func (s *sqlStore) withTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	var committing bool
+
+	return s.retry.do(ctx, func() error {
+		committing = false
+
+		return WithTx(ctx, s.db, func(tx *sql.Tx) error {
+			if err := fn(tx); err != nil {
+				return err
+			}
+
+			committing = true
+
+			return nil
+		})
+	}, func(err error) bool {
+		if committing {
+			return rolledBack(err)
+		}
+
+		return transient(err)
+	})
+}
+
+// transient reports whether err is one that running the same transaction
+// again may well not meet: a deadlock, a serialization failure or a lost
+// connection. Constraint violations and the like are not.
+func transient(err error) bool {
+	if rolledBack(err) {
+		return true
+	}
+
+	// Class 08 covers the ways a connection can fail.
+	if strings.HasPrefix(sqlState(err), "08") {
+		return true
+	}
+
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// rolledBack reports whether err is a serialization failure or a deadlock,
+// on either of which Postgres rolls the transaction back.
+func rolledBack(err error) bool {
+	switch sqlState(err) {
+	case "40001", "40P01":
+		return true
+	}
+
+	return false
+}
+
+// sqlState returns the SQLSTATE code the driver reported err with, if any.
+func sqlState(err error) string {
+	var e interface{ SQLState() string }
+	if errors.As(err, &e) {
+		return e.SQLState()
+	}
+
+	return ""
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// sqlStateError is a driver error carrying a SQLSTATE code, as pq's and
+// pgx's errors do.
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{sqlStateError("40001"), true},
+		{sqlStateError("40P01"), true},
+		{sqlStateError("08006"), true},
+		{sqlStateError("08001"), true},
+		{fmt.Errorf("booking: %w", sqlStateError("40001")), true},
+		{driver.ErrBadConn, true},
+		{sqlStateError("23505"), false},
+		{sqlStateError("57014"), false},
+		{sql.ErrNoRows, false},
+		{errors.New("seat taken"), false},
+	}
+
+	for _, tt := range tests {
+		if got := transient(tt.err); got != tt.want {
+			t.Errorf("transient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDo(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	errTransient := sqlStateError("40001")
+
+	tests := []struct {
+		name      string
+		failures  []error
+		wantErr   error
+		wantCalls int
+	}{
+		{"succeeds at once", nil, nil, 1},
+		{"transient then success", []error{errTransient}, nil, 2},
+		{"transient every time", []error{errTransient, errTransient, errTransient, errTransient}, errTransient, 3},
+		{"permanent is not retried", []error{errPermanent}, errPermanent, 1},
+		{"permanent after transient", []error{errTransient, errPermanent}, errPermanent, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := RetryConfig{MaxAttempts: 3, Backoff: time.Microsecond, MaxBackoff: time.Microsecond}
+
+			var calls int
+
+			err := c.do(context.Background(), func() error {
+				calls++
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+
+				return nil
+			}, transient)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("do() = %v, want %v", err, tt.wantErr)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("op ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryDoStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := RetryConfig{MaxAttempts: 5, Backoff: time.Hour, MaxBackoff: time.Hour}
+
+	var calls int
+
+	err := c.do(ctx, func() error {
+		calls++
+		cancel()
+
+		return sqlStateError("40P01")
+	}, transient)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("do() = %v, want %v", err, context.Canceled)
+	}
+
+	if calls != 1 {
+		t.Errorf("op ran %d times, want 1", calls)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	c := RetryConfig{Backoff: 50 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	want := []time.Duration{
+		50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond,
+		300 * time.Millisecond, 300 * time.Millisecond,
+	}
+
+	for i, w := range want {
+		if got := c.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	if got := c.backoff(100); got != c.MaxBackoff {
+		t.Errorf("backoff(100) = %v, want %v", got, c.MaxBackoff)
+	}
+}
+
+func TestWithTxRetries(t *testing.T) {
+	retry := RetryConfig{MaxAttempts: 3, Backoff: time.Microsecond, MaxBackoff: time.Microsecond}
+
+	t.Run("transient failure runs fn again in a new transaction", func(t *testing.T) {
+		c := &fakeConn{}
+		s := &sqlStore{db: fakeDB(t, c), retry: retry}
+
+		var attempts int
+
+		err := s.withTx(context.Background(), func(*sql.Tx) error {
+			if attempts++; attempts == 1 {
+				return sqlStateError("40001")
+			}
+
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("withTx() = %v", err)
+		}
+
+		if attempts != 2 {
+			t.Errorf("fn ran %d times, want 2", attempts)
+		}
+
+		begun, commits, rollbacks := c.counts()
+		if begun != 2 || commits != 1 || rollbacks != 1 {
+			t.Errorf("begun %d, committed %d, rolled back %d; want 2, 1, 1", begun, commits, rollbacks)
+		}
+	})
+
+	t.Run("commit refused over a conflict is retried", func(t *testing.T) {
+		c := &fakeConn{commitErr: sqlStateError("40001")}
+		s := &sqlStore{db: fakeDB(t, c), retry: retry}
+
+		if err := s.withTx(context.Background(), func(*sql.Tx) error { return nil }); err != nil {
+			t.Fatalf("withTx() = %v", err)
+		}
+
+		if begun, commits, _ := c.counts(); begun != 2 || commits != 1 {
+			t.Errorf("begun %d, committed %d; want 2, 1", begun, commits)
+		}
+	})
+
+	t.Run("commit lost with the connection is not retried", func(t *testing.T) {
+		c := &fakeConn{commitErr: sqlStateError("08006")}
+		s := &sqlStore{db: fakeDB(t, c), retry: retry}
+
+		err := s.withTx(context.Background(), func(*sql.Tx) error { return nil })
+		if sqlState(err) != "08006" {
+			t.Errorf("withTx() = %v, want the commit's error", err)
+		}
+
+		if begun, _, _ := c.counts(); begun != 1 {
+			t.Errorf("begun %d, want 1", begun)
+		}
+	})
+
+	t.Run("permanent failure is not retried", func(t *testing.T) {
+		c := &fakeConn{}
+		s := &sqlStore{db: fakeDB(t, c), retry: retry}
+
+		err := s.withTx(context.Background(), func(*sql.Tx) error { return ErrNotFound })
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("withTx() = %v, want %v", err, ErrNotFound)
+		}
+
+		if begun, _, rollbacks := c.counts(); begun != 1 || rollbacks != 1 {
+			t.Errorf("begun %d, rolled back %d; want 1, 1", begun, rollbacks)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+func (s *sqlStore) SplitFare(ctx context.Context, id int, portions []models.SplitPortion) ([]models.FareSplit, error) {
+	var splits []models.FareSplit
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		t, err := getTicket(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		if t.Status == models.StatusCancelled {
+			return ErrTicketCancelled
+		}
+
+		var total money.Money
+		for _, p := range portions {
+			total += p.Amount
+		}
+
+		if total != t.Fare {
+			return &SplitTotalError{Fare: t.Fare, Total: total}
+		}
+
+		ids := make([]int, len(portions))
+		for i, p := range portions {
+			ids[i] = p.UserID
+		}
+
+		if err := checkUsers(ctx, tx, ids); err != nil {
+			return err
+		}
+
+		before, err := fareSplits(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		for _, sp := range before {
+			if sp.UserID != t.UserID && sp.Status == models.SplitSettled {
+				return ErrSplitSettled
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM ticket_splits WHERE ticket_id = $1`, id); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+
+		for _, p := range portions {
+			sp := models.FareSplit{UserID: p.UserID, Amount: p.Amount, Status: models.SplitPending}
+			if p.UserID == t.UserID {
+				sp.Status, sp.SettledAt = models.SplitSettled, &now
+			}
+
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO ticket_splits (ticket_id, user_id, amount, status, settled_at) VALUES ($1, $2, $3, $4, $5)`,
+				id, sp.UserID, sp.Amount, sp.Status, sp.SettledAt); err != nil {
+				return err
+			}
+		}
+
+		if splits, err = fareSplits(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, models.AuditUpdate, models.ResourceTicket, id,
+			map[string]interface{}{"splits": before}, map[string]interface{}{"splits": splits})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return splits, nil
+}
+
+// checkUsers returns an *UnknownUsersError naming those of ids that are not
+// users.
+func checkUsers(ctx context.Context, q querier, ids []int) error {
+	rows, err := q.QueryContext(ctx, `SELECT id FROM users WHERE id = ANY($1::integer[])`, seatArray(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	found := make(map[int]bool, len(ids))
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+
+		found[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var missing []int
+
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &UnknownUsersError{UserIDs: missing}
+	}
+
+	return nil
+}
+
+func (s *sqlStore) GetFareSplits(ctx context.Context, id int) ([]models.FareSplit, error) {
+	return fareSplits(ctx, s.db, id)
+}
+
+// fareSplits returns the shares of ticket id's fare, by user.
+func fareSplits(ctx context.Context, q querier, id int) ([]models.FareSplit, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT user_id, amount, status, settled_at FROM ticket_splits WHERE ticket_id = $1 ORDER BY user_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	splits := []models.FareSplit{}
+
+	for rows.Next() {
+		var sp models.FareSplit
+		if err := rows.Scan(&sp.UserID, &sp.Amount, &sp.Status, &sp.SettledAt); err != nil {
+			return nil, err
+		}
+
+		splits = append(splits, sp)
+	}
+
+	return splits, rows.Err()
+}
+
+func (s *sqlStore) SettleFareSplit(ctx context.Context, id, userID int) (models.FareSplit, error) {
+	sp := models.FareSplit{UserID: userID}
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`SELECT amount, status, settled_at FROM ticket_splits WHERE ticket_id = $1 AND user_id = $2 FOR UPDATE`,
+			id, userID).Scan(&sp.Amount, &sp.Status, &sp.SettledAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil || sp.Status == models.SplitSettled {
+			return err
+		}
+
+		before := sp
+		now := time.Now().UTC()
+		sp.Status, sp.SettledAt = models.SplitSettled, &now
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE ticket_splits SET status = $3, settled_at = $4 WHERE ticket_id = $1 AND user_id = $2`,
+			id, userID, sp.Status, sp.SettledAt); err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, models.AuditUpdate, models.ResourceTicket, id,
+			map[string]interface{}{"split": before}, map[string]interface{}{"split": sp})
+	})
+	if err != nil {
+		return models.FareSplit{}, err
+	}
+
+	return sp, nil
+}
@@ -0,0 +1,221 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+type sqlStore struct {
+	db      *sql.DB
+	replica *Replica
+	retry   RetryConfig
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// WithTx runs fn in a transaction on db, committing it if fn returns nil.
+// The transaction is rolled back if fn returns an error, which WithTx then
+// returns, or panics, in which case the panic carries on once it is.
+func WithTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// New returns a Store backed by db, and by replica for the reads that may
+// lag behind it if replica is not nil, limiting the connections to each to
+// pool and retrying transactions that fail transiently as retry allows.
+func New(db *sql.DB, replica *Replica, pool PoolConfig, retry RetryConfig) Store {
+	pool.apply(db)
+
+	if replica != nil {
+		pool.apply(replica.DB)
+	}
+
+	return &sqlStore{db: db, replica: replica, retry: retry}
+}
+
+func (s *sqlStore) GetUsers(ctx context.Context, page Page) ([]models.User, int, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		selectUser+` ORDER BY id LIMIT $1 OFFSET $2`, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		users = append(users, u)
+	}
+
+	return users, total, rows.Err()
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (s *sqlStore) SearchUsers(ctx context.Context, q string, page Page) ([]models.User, int, error) {
+	const where = ` WHERE name ILIKE $1 OR email ILIKE $1`
+
+	prefix := likeEscaper.Replace(q) + "%"
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`+where, prefix).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		selectUser+where+` ORDER BY name, id LIMIT $2 OFFSET $3`,
+		prefix, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		users = append(users, u)
+	}
+
+	return users, total, rows.Err()
+}
+
+func (s *sqlStore) GetUserByID(ctx context.Context, id int) (models.User, error) {
+	u, err := scanUser(s.db.QueryRowContext(ctx, selectUser+` WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrNotFound
+	}
+
+	return u, err
+}
+
+func (s *sqlStore) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	var u models.User
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, name, email, role, COALESCE(phone, ''), password_hash FROM users WHERE email = $1`, email).
+		Scan(&u.ID, &u.Name, &u.Email, &u.Role, &u.Phone, &u.PasswordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrNotFound
+	}
+
+	return u, err
+}
+
+func (s *sqlStore) CreateUser(ctx context.Context, u models.User) (models.User, error) {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO users (name, email, password_hash) VALUES ($1, $2, $3)
+			ON CONFLICT (email) DO NOTHING RETURNING id, role`, u.Name, u.Email, u.PasswordHash).Scan(&u.ID, &u.Role)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrEmailTaken
+		} else if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, models.AuditCreate, models.ResourceUser, u.ID, nil, u)
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return u, nil
+}
+
+func (s *sqlStore) UpdateUser(ctx context.Context, id int, p models.ProfileUpdate) (models.User, error) {
+	var u models.User
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		u, err = scanUser(tx.QueryRowContext(ctx, selectUser+` WHERE id = $1 FOR UPDATE`, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil {
+			return err
+		}
+
+		before := u
+
+		if p.Name != nil {
+			u.Name = *p.Name
+		}
+
+		if p.Email != nil {
+			u.Email = *p.Email
+		}
+
+		if p.Phone != nil {
+			u.Phone = *p.Phone
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE users SET name = $2, email = $3, phone = NULLIF($4, '') WHERE id = $1`,
+			id, u.Name, u.Email, u.Phone)
+		// Email is the only column of users under a unique constraint, which
+		// also settles two updates racing to the same address.
+		if sqlState(err) == "23505" {
+			return ErrEmailTaken
+		} else if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, models.AuditUpdate, models.ResourceUser, id, before, u)
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return u, nil
+}
+
+// selectUser selects the columns scanUser reads; the password hash is left
+// to GetUserByEmail, which logins need it from.
+const selectUser = `SELECT id, name, email, role, COALESCE(phone, '') FROM users`
+
+func scanUser(row rowScanner) (models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Role, &u.Phone)
+
+	return u, err
+}
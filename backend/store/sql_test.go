@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeConn is a database connection that runs no queries and only counts
+// the transactions begun on it and how each ended.
+type fakeConn struct {
+	mu        sync.Mutex
+	begun     int
+	commits   int
+	rollbacks int
+	// commitErr, if set, is returned by the next commit, which is then not
+	// counted.
+	commitErr error
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake connection runs no queries")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.begun++
+
+	return fakeTx{c}, nil
+}
+
+func (c *fakeConn) counts() (begun, commits, rollbacks int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.begun, c.commits, c.rollbacks
+}
+
+type fakeTx struct{ c *fakeConn }
+
+func (t fakeTx) Commit() error {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	if err := t.c.commitErr; err != nil {
+		t.c.commitErr = nil
+
+		return err
+	}
+
+	t.c.commits++
+
+	return nil
+}
+
+func (t fakeTx) Rollback() error {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	t.c.rollbacks++
+
+	return nil
+}
+
+type fakeConnector struct{ c *fakeConn }
+
+func (f fakeConnector) Connect(context.Context) (driver.Conn, error) { return f.c, nil }
+func (f fakeConnector) Driver() driver.Driver                        { return fakeDriver{f.c} }
+
+type fakeDriver struct{ c *fakeConn }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return d.c, nil }
+
+// fakeDB returns a *sql.DB whose every connection is c.
+func fakeDB(t *testing.T, c *fakeConn) *sql.DB {
+	t.Helper()
+
+	db := sql.OpenDB(fakeConnector{c})
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestWithTx(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name          string
+		fn            func(*sql.Tx) error
+		wantErr       error
+		wantPanic     bool
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{
+			name:        "success commits",
+			fn:          func(*sql.Tx) error { return nil },
+			wantCommits: 1,
+		},
+		{
+			name:          "error rolls back",
+			fn:            func(*sql.Tx) error { return errFailed },
+			wantErr:       errFailed,
+			wantRollbacks: 1,
+		},
+		{
+			name:          "panic rolls back and panics again",
+			fn:            func(*sql.Tx) error { panic(errFailed) },
+			wantPanic:     true,
+			wantRollbacks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeConn{}
+			db := fakeDB(t, c)
+
+			var err error
+
+			func() {
+				defer func() {
+					p := recover()
+					if tt.wantPanic && p != errFailed {
+						t.Errorf("recovered %v, want the panic fn raised", p)
+					} else if !tt.wantPanic && p != nil {
+						panic(p)
+					}
+				}()
+
+				err = WithTx(context.Background(), db, tt.fn)
+			}()
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("WithTx() = %v, want %v", err, tt.wantErr)
+			}
+
+			begun, commits, rollbacks := c.counts()
+			if begun != 1 || commits != tt.wantCommits || rollbacks != tt.wantRollbacks {
+				t.Errorf("begun %d, committed %d, rolled back %d; want 1, %d, %d",
+					begun, commits, rollbacks, tt.wantCommits, tt.wantRollbacks)
+			}
+		})
+	}
+}
+
+func TestWithTxCommitError(t *testing.T) {
+	errCommit := errors.New("commit failed")
+	c := &fakeConn{commitErr: errCommit}
+
+	err := WithTx(context.Background(), fakeDB(t, c), func(*sql.Tx) error { return nil })
+	if !errors.Is(err, errCommit) {
+		t.Errorf("WithTx() = %v, want %v", err, errCommit)
+	}
+}
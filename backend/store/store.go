@@ -0,0 +1,466 @@
+// Package store persists buses and tickets.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrBusDeleted is returned when acting on a bus that has been soft-deleted.
+	// It wraps ErrNotFound, since deleted buses are hidden like missing ones.
+	ErrBusDeleted = fmt.Errorf("bus has been deleted: %w", ErrNotFound)
+	// ErrRouteNotFound is returned when assigning a bus to a route that does not exist.
+	ErrRouteNotFound = errors.New("route not found")
+	// ErrBusModelNotFound is returned when creating a bus of a model that does not exist.
+	ErrBusModelNotFound = errors.New("bus model not found")
+	// ErrBusExists is returned when creating a bus whose ID is already taken.
+	ErrBusExists = errors.New("bus already exists")
+	// ErrHoldNotFound is returned when booking with a hold token the user does not hold.
+	ErrHoldNotFound = errors.New("seat hold not found")
+	// ErrHoldExpired is returned when booking with a hold that has run out.
+	ErrHoldExpired = errors.New("seat hold has expired")
+	// ErrEmailTaken is returned when creating a user whose email is already registered.
+	ErrEmailTaken = errors.New("email is already registered")
+	// ErrDiscountUnavailable is returned when booking with a discount code that
+	// has expired or run out of uses since it was checked.
+	ErrDiscountUnavailable = errors.New("discount code is no longer available")
+	// ErrExceedsCapacity is returned when waitlisting for more seats than the bus has.
+	ErrExceedsCapacity = errors.New("more seats requested than the bus has")
+	// ErrTicketCancelled is returned when acting on a ticket that has already been cancelled.
+	ErrTicketCancelled = errors.New("ticket is already cancelled")
+	// ErrTicketNotValidated is returned when boarding a ticket that has not been validated.
+	ErrTicketNotValidated = errors.New("ticket has not been validated")
+	// ErrTicketUsed is returned when cancelling a ticket that has already been validated.
+	ErrTicketUsed = errors.New("ticket has already been used")
+	// ErrSplitSettled is returned when splitting a ticket again after a
+	// co-rider has settled their share.
+	ErrSplitSettled = errors.New("a co-rider has already settled their share of the fare")
+	// ErrTicketSplit is returned when cancelling some seats of a ticket whose
+	// fare is split, or changing it, which would leave the shares adding up
+	// to the wrong fare.
+	ErrTicketSplit = errors.New("ticket's fare is split among its riders")
+	// ErrNotTicketOwner is returned when transferring a ticket that belongs to
+	// someone other than the user giving it away.
+	ErrNotTicketOwner = errors.New("ticket belongs to another user")
+)
+
+// IdempotencyKeyTTL is how long an idempotency key keeps returning the ticket
+// it was first used to book.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// SeatsUnavailableError is returned by CreateTicket when some of the requested
+// seats cannot be booked.
+type SeatsUnavailableError struct {
+	Seats []int
+	// Full is set when the bus has no free seats left at all.
+	Full bool
+}
+
+func (e *SeatsUnavailableError) Error() string {
+	if e.Full {
+		return "bus is fully booked"
+	}
+
+	return fmt.Sprintf("seats %v are not available", e.Seats)
+}
+
+// SeatLimitError is returned when a booking would take its user past the
+// ticket's SeatLimit for the departure.
+type SeatLimitError struct {
+	// Booked is how many seats the user already has booked or held on the
+	// departure.
+	Booked int
+	Wanted int
+	Max    int
+}
+
+func (e *SeatLimitError) Error() string {
+	return fmt.Sprintf("%d seats are already booked or held on this departure; %d more would exceed the limit of %d per user",
+		e.Booked, e.Wanted, e.Max)
+}
+
+// SplitTotalError is returned when the shares of a ticket's fare do not add
+// up to it.
+type SplitTotalError struct {
+	Fare  money.Money
+	Total money.Money
+}
+
+func (e *SplitTotalError) Error() string {
+	return fmt.Sprintf("portions add up to %s but the fare is %s", e.Total.Display(), e.Fare.Display())
+}
+
+// UnknownUsersError is returned when splitting a fare with users who do not
+// exist.
+type UnknownUsersError struct {
+	UserIDs []int
+}
+
+func (e *UnknownUsersError) Error() string {
+	return fmt.Sprintf("users %v not found", e.UserIDs)
+}
+
+// NotEnoughSeatsError is returned when a booking asks for more seats to be
+// picked than are free. Preference is set when only free seats of that type
+// counted, because the booking was strict about it.
+type NotEnoughSeatsError struct {
+	Wanted     int
+	Free       int
+	Preference string
+}
+
+func (e *NotEnoughSeatsError) Error() string {
+	if e.Preference != "" {
+		return fmt.Sprintf("only %d %s seats are free; %d were asked for", e.Free, e.Preference, e.Wanted)
+	}
+
+	return fmt.Sprintf("only %d seats are free; %d were asked for", e.Free, e.Wanted)
+}
+
+// SeatsNotOnTicketError is returned by CancelSeats and BoardSeats when some
+// of the seats asked for are not on the ticket.
+type SeatsNotOnTicketError struct {
+	Seats []int
+}
+
+func (e *SeatsNotOnTicketError) Error() string {
+	return fmt.Sprintf("seats %v are not on the ticket", e.Seats)
+}
+
+// VersionConflictError is returned when updating a bus from a version that
+// is no longer current.
+type VersionConflictError struct {
+	Current int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("bus has been updated since; its current version is %d", e.Current)
+}
+
+// BusFilter narrows GetBuses. Zero fields do not filter.
+type BusFilter struct {
+	// FromStop and ToStop, when both set, keep buses that call at the stop
+	// with ID FromStop and later at ToStop.
+	FromStop int
+	ToStop   int
+	// DepartureAfter and DepartureBefore bound the daily departure time,
+	// inclusively, as "HH:MM".
+	DepartureAfter  string
+	DepartureBefore string
+	// IncludeDeleted keeps soft-deleted buses in the results.
+	IncludeDeleted bool
+	// Amenities keeps buses that have every one of them.
+	Amenities []string
+}
+
+// Fields GetBuses can order by.
+const (
+	// SortDeparture orders by daily departure time.
+	SortDeparture = "departure"
+	// SortFare orders by the seat fare riders pay: the route's fixed fare
+	// where there is one, else the bus's own.
+	SortFare = "fare"
+	// SortOccupancy orders by the share of seats booked for today's run, in
+	// the bus's time zone.
+	SortOccupancy = "occupancy"
+)
+
+// BusSortFields lists the fields accepted in BusOrder.Field.
+var BusSortFields = []string{SortDeparture, SortFare, SortOccupancy}
+
+// BusOrder is the order GetBuses returns buses in. The zero value orders by
+// departure, earliest first.
+type BusOrder struct {
+	Field string
+	Desc  bool
+}
+
+// Which of a user's tickets GetUserTickets returns.
+const (
+	TicketsUpcoming = "upcoming"
+	TicketsPast     = "past"
+	TicketsAll      = "all"
+)
+
+// BulkError reports which entry of a CreateTickets call failed and why.
+type BulkError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("booking %d: %v", e.Index, e.Err)
+}
+
+func (e *BulkError) Unwrap() error { return e.Err }
+
+// Page selects a window of a list query.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// Store is the persistence boundary used by the HTTP handlers.
+type Store interface {
+	// GetBuses returns one page of the buses matching filter, in the given
+	// order, and the total number that match.
+	GetBuses(ctx context.Context, filter BusFilter, order BusOrder, page Page) ([]models.Bus, int, error)
+	// GetBusByID returns ErrBusDeleted, alongside the bus without its stops,
+	// for a soft-deleted bus.
+	GetBusByID(ctx context.Context, id int) (models.Bus, error)
+	// CreateBus inserts a bus and returns it. It returns ErrBusExists if the
+	// ID is taken, and ErrRouteNotFound or ErrBusModelNotFound for an unknown
+	// route or model.
+	CreateBus(ctx context.Context, nb models.NewBus) (models.Bus, error)
+	// GetBusModels returns every bus model, by name.
+	GetBusModels(ctx context.Context) ([]models.BusModel, error)
+	// UpdateSchedule sets a bus's departure time ("HH:MM"), the recurrence
+	// rule for the days it runs, its route and booking cutoff, provided the bus is still at
+	// sched.Version, and bumps its version. When the schedule changes it
+	// also returns the IDs of the bus's booked tickets that have yet to
+	// depart. It returns *VersionConflictError if the bus has moved on, or
+	// ErrRouteNotFound for an unknown route.
+	UpdateSchedule(ctx context.Context, busID int, sched models.Schedule) (models.Bus, []int, error)
+	// SubstituteBus moves the booked tickets of busID travelling in [from,
+	// to), or from onwards if to is zero, onto seats of replacementID laid
+	// out as alike as its model allows. A ticket there is no room for stays
+	// where it is and is reported unmapped. The moved tickets are returned as
+	// they now are. It returns ErrNotFound or ErrBusDeleted if either bus is
+	// unknown or out of service.
+	SubstituteBus(ctx context.Context, busID, replacementID int, from, to time.Time) (models.BusSubstitutionReport, []models.Ticket, error)
+	// DeleteBus soft-deletes a bus, keeping its row and ticket history. It
+	// returns ErrNotFound if there is no bus, or it is already deleted.
+	DeleteBus(ctx context.Context, id int) error
+	// GetRouteFare returns the fixed seat fare of a route, or ErrNotFound if
+	// its buses charge their own.
+	GetRouteFare(ctx context.Context, routeID int) (money.Money, error)
+	// SetRouteFare fixes a route's seat fare, returning ErrRouteNotFound for
+	// an unknown route.
+	SetRouteFare(ctx context.Context, f models.RouteFare) error
+	// GetDiscountCode returns ErrNotFound for an unknown code.
+	GetDiscountCode(ctx context.Context, code string) (models.DiscountCode, error)
+	// GetRouteStops returns the stops of a route in travel order.
+	GetRouteStops(ctx context.Context, routeID int) ([]models.RouteStop, error)
+	// GetStops returns every stop, by name.
+	GetStops(ctx context.Context) ([]models.Stop, error)
+	// FindStop returns the stop whose name matches name once both are
+	// normalized by models.StopKey, or ErrNotFound.
+	FindStop(ctx context.Context, name string) (models.Stop, error)
+	// GetUsers returns one page of users and the total number of users.
+	GetUsers(ctx context.Context, page Page) ([]models.User, int, error)
+	// SearchUsers returns one page of the users whose name or email starts
+	// with q, ignoring case, by name, and how many match in all.
+	SearchUsers(ctx context.Context, q string, page Page) ([]models.User, int, error)
+	GetUserByID(ctx context.Context, id int) (models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (models.User, error)
+	// CreateUser inserts u, returning it with its new ID, or ErrEmailTaken.
+	CreateUser(ctx context.Context, u models.User) (models.User, error)
+	// UpdateUser changes the fields of user id that p gives, returning the
+	// user as updated, ErrNotFound or ErrEmailTaken.
+	UpdateUser(ctx context.Context, id int, p models.ProfileUpdate) (models.User, error)
+	// RecordPosition appends u to its bus's position history.
+	RecordPosition(ctx context.Context, u models.LocationUpdate) error
+	// RecordPositions appends every update in us, all or none.
+	RecordPositions(ctx context.Context, us []models.LocationUpdate) error
+	// GetTrail returns up to limit of a bus's recorded positions since the
+	// given time, oldest first.
+	GetTrail(ctx context.Context, busID int, since time.Time, limit int) ([]models.LocationUpdate, error)
+	// PrunePositions deletes positions recorded before the given time and
+	// reports how many went.
+	PrunePositions(ctx context.Context, before time.Time) (int64, error)
+	// RecordArrival stores a bus reaching a stop, keeping only the first
+	// arrival of each trip at each stop.
+	RecordArrival(ctx context.Context, a models.StopArrival) error
+	// GetArrivals returns the arrivals recorded on a route since the given
+	// time, oldest first, or ErrRouteNotFound for an unknown route.
+	GetArrivals(ctx context.Context, routeID int, since time.Time) ([]models.StopArrival, error)
+	GetTicket(ctx context.Context, id int) (models.Ticket, error)
+	// GetUserTickets returns one page of a user's tickets, whose travel is
+	// upcoming, past or either according to when, and the total number.
+	// Upcoming trips come soonest first, and the others latest first.
+	GetUserTickets(ctx context.Context, userID int, when string, page Page) ([]models.TripTicket, int, error)
+	// GetBookedSeats returns the seats already booked or held on a bus for a
+	// travel date.
+	GetBookedSeats(ctx context.Context, busID int, travelDate time.Time) ([]int, error)
+	// EachTicket calls fn with every ticket for travel on a bus in [from,
+	// to), in travel order, reading them one at a time rather than all at
+	// once. Cancelled tickets have no seat numbers. An error from fn stops
+	// the iteration and is returned.
+	EachTicket(ctx context.Context, busID int, from, to time.Time, fn func(models.Ticket) error) error
+	// GetTripTickets returns every ticket for travel on a bus in [from, to),
+	// in travel order, with StatusBoarded in place of the status of any
+	// ticket that has had a seat boarded. Cancelled tickets have no seat
+	// numbers.
+	GetTripTickets(ctx context.Context, busID int, from, to time.Time) ([]models.Ticket, error)
+	// CountBookedSeats counts the seats booked on a bus for travel in [from, to).
+	CountBookedSeats(ctx context.Context, busID int, from, to time.Time) (int, error)
+	// CountBoardedSeats counts the seats on a bus for travel in [from, to)
+	// whose riders have boarded.
+	CountBoardedSeats(ctx context.Context, busID int, from, to time.Time) (int, error)
+	// CreateTicket inserts t and its seats, returning it with its new ID.
+	// The availability check and the insert share one transaction, so of two
+	// concurrent requests for the same seat only one succeeds; the other gets
+	// a *SeatsUnavailableError. It returns ErrNotFound if the bus is unknown
+	// and ErrBusDeleted if it has been deleted.
+	//
+	// If t carries an IdempotencyKey that the same user booked with in the
+	// last IdempotencyKeyTTL, nothing is inserted: that earlier ticket is
+	// returned and created is false.
+	//
+	// If t carries a HoldToken, that hold is consumed and its seats are
+	// booked; ErrHoldNotFound or ErrHoldExpired is returned if it cannot be.
+	//
+	// If t carries a DiscountCode, one use of it is recorded, or
+	// ErrDiscountUnavailable returned if it has expired or been used up.
+	CreateTicket(ctx context.Context, t models.Ticket) (ticket models.Ticket, created bool, err error)
+	// CreateTickets books every ticket in ts in one transaction: either all
+	// are created or, on the first failure, none are and a *BulkError
+	// identifies the failing entry. Idempotency keys are ignored.
+	CreateTickets(ctx context.Context, ts []models.Ticket) ([]models.Ticket, error)
+	// CreateReturnTrip books outbound and inbound in one transaction, like
+	// CreateTickets, and links each to the other as its ReturnTicketID. A
+	// *BulkError identifies the leg that could not be booked: 0 for
+	// outbound, 1 for inbound.
+	CreateReturnTrip(ctx context.Context, outbound, inbound models.Ticket) (models.Ticket, models.Ticket, error)
+	// CreateJourney books the ticket of every leg of j in one transaction,
+	// like CreateTickets, and groups them under a new journey ID. A
+	// *BulkError identifies the leg that could not be booked.
+	CreateJourney(ctx context.Context, j models.Journey) (models.Journey, error)
+	// GetJourney returns a journey with the tickets of its legs in order.
+	GetJourney(ctx context.Context, id int) (models.Journey, error)
+	// CreateHold reserves h's seats until h.ExpiresAt, failing with a
+	// *SeatsUnavailableError like CreateTicket. Seats under an unexpired
+	// hold count as taken for every other booking and hold.
+	CreateHold(ctx context.Context, h models.SeatHold) (models.SeatHold, error)
+	GetHold(ctx context.Context, token string) (models.SeatHold, error)
+	// DeleteExpiredHolds removes holds that expired by now and returns
+	// them, skipping any a booking is confirming at the same time. Expired
+	// holds already release their seats; this keeps the table small and
+	// tells live seat maps they have gone.
+	DeleteExpiredHolds(ctx context.Context, now time.Time) ([]models.SeatHold, error)
+	// CreatePayment records a pending payment for the seats under p's hold,
+	// which must be p.UserID's and unexpired, and reports whether it did: a
+	// hold whose payment is still pending gets that payment back instead. It
+	// returns ErrHoldNotFound or ErrHoldExpired when the hold cannot be paid
+	// for.
+	CreatePayment(ctx context.Context, p models.Payment) (models.Payment, bool, error)
+	// ApplyPaymentEvent applies a provider event to the pending payment of
+	// e.IntentID and reports whether it changed it. A successful payment
+	// books the held seats; a failed one, or one whose seats can no longer
+	// be booked, releases them. Events already applied, of unknown types or
+	// for settled payments change nothing. It returns ErrNotFound for an
+	// unknown intent.
+	ApplyPaymentEvent(ctx context.Context, e models.PaymentEvent) (models.Payment, bool, error)
+	// ExpirePayments marks pending payments whose holds expired by now as
+	// expired.
+	ExpirePayments(ctx context.Context, now time.Time) (int64, error)
+	// CreateWaitlistEntry queues e for seats on its bus and travel date,
+	// returning it with its ID and position. It returns ErrExceedsCapacity
+	// if e asks for more seats than the bus has.
+	CreateWaitlistEntry(ctx context.Context, e models.WaitlistEntry) (models.WaitlistEntry, error)
+	// GetWaitlistEntry returns an entry, with its position if still waiting.
+	GetWaitlistEntry(ctx context.Context, id int) (models.WaitlistEntry, error)
+	// PromoteWaitlist books free seats for waiting entries with travel still
+	// to come, first come first served per bus and travel date, and returns
+	// the tickets it created.
+	PromoteWaitlist(ctx context.Context) ([]models.Ticket, error)
+	// ValidateTicket marks a booked ticket as validated by the given user and
+	// device, given a code signed at the ticket's current qrVersion. A
+	// ticket that is no longer booked is reported not valid, along with who
+	// first validated it if that is why, and a code of an earlier version
+	// as revoked. Times are in the bus's zone.
+	ValidateTicket(ctx context.Context, id, qrVersion, validatedBy int, device string) (models.ValidationResult, error)
+	// BoardSeats records the riders of a validated ticket's seats, or of
+	// all of them when b names none, as boarded by the given user. Seats
+	// boarded before keep their first boarding. It returns ErrNotFound,
+	// ErrTicketCancelled, ErrTicketNotValidated or *SeatsNotOnTicketError
+	// when it cannot.
+	BoardSeats(ctx context.Context, id, boardedBy int, b models.Boarding) (models.BoardingResult, error)
+	// FlagNoShows records as no-shows the seats of booked and validated
+	// tickets whose riders have not boarded and whose departure was in
+	// [since, before). With release set it also frees the seats. It returns
+	// the no-shows flagged by this call.
+	FlagNoShows(ctx context.Context, since, before time.Time, release bool) ([]models.NoShow, error)
+	// GetNoShows returns the no-shows on a bus for travel in [from, to), by
+	// seat.
+	GetNoShows(ctx context.Context, busID int, from, to time.Time) ([]models.NoShow, error)
+	// GetBookingTimes returns when each ticket for travel on a bus in
+	// [from, to) was booked, oldest first, counting tickets since cancelled.
+	GetBookingTimes(ctx context.Context, busID int, from, to time.Time) ([]time.Time, error)
+	// GetRides returns the boarded seats on a bus for travel in [from, to),
+	// in the order they boarded.
+	GetRides(ctx context.Context, busID int, from, to time.Time) ([]models.Ride, error)
+	// CreateAPIKey inserts k, returning it with its new ID.
+	CreateAPIKey(ctx context.Context, k models.APIKey) (models.APIKey, error)
+	// GetAPIKeyByHash returns the unrevoked key with hash, or ErrNotFound.
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	// RevokeAPIKey revokes key id, which stays revoked when revoked again,
+	// or returns ErrNotFound.
+	RevokeAPIKey(ctx context.Context, id int) error
+	// CreateWebhookSubscription inserts sub, returning it with its new ID.
+	CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error)
+	// DeleteWebhookSubscription unsubscribes subscription id, dropping its
+	// pending deliveries, or returns ErrNotFound.
+	DeleteWebhookSubscription(ctx context.Context, id int) error
+	// EnqueueWebhook queues payload for delivery to every subscription to
+	// event, returning how many there are.
+	EnqueueWebhook(ctx context.Context, event string, payload []byte) (int, error)
+	// ClaimWebhookDeliveries returns up to limit pending deliveries that are
+	// due, and puts off their next attempt by lease so that no other pass
+	// claims them while they are being sent.
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	// RecordWebhookAttempt records an attempt at delivery id that failed
+	// with attemptErr, or succeeded if it is empty. A failed delivery is
+	// tried again at retryAt, or given up on if retryAt is nil.
+	RecordWebhookAttempt(ctx context.Context, id int, attemptErr string, retryAt *time.Time) error
+	// GetAuditLog returns one page of the audit entries filter picks, newest
+	// first, and how many it picks in all.
+	GetAuditLog(ctx context.Context, filter AuditFilter, page Page) ([]models.AuditEntry, int, error)
+	// SplitFare shares the fare of booked ticket id among the users of
+	// portions, replacing any split made before, and returns the shares. It
+	// returns ErrNotFound, ErrTicketCancelled, ErrSplitSettled,
+	// *SplitTotalError or *UnknownUsersError when it cannot.
+	SplitFare(ctx context.Context, id int, portions []models.SplitPortion) ([]models.FareSplit, error)
+	// GetFareSplits returns the shares of a ticket's fare, by user, or none
+	// if it is not split.
+	GetFareSplits(ctx context.Context, id int) ([]models.FareSplit, error)
+	// SettleFareSplit marks userID's share of ticket id's fare settled, once,
+	// and returns it, or ErrNotFound if they have none.
+	SettleFareSplit(ctx context.Context, id, userID int) (models.FareSplit, error)
+	// CancelTicket cancels a booked ticket and releases its seats. It returns
+	// ErrNotFound, ErrTicketCancelled or ErrTicketUsed when it cannot.
+	CancelTicket(ctx context.Context, id int) (models.Ticket, error)
+	// CancelReturnTrip cancels ticket id as CancelTicket does and, in the
+	// same transaction, the other leg of its round trip if that is still
+	// booked. The other leg is returned too, or the zero Ticket if there is
+	// none or it was left alone.
+	CancelReturnTrip(ctx context.Context, id int) (ticket, other models.Ticket, err error)
+	// CancelSeats releases some of a booked ticket's seats, taking their
+	// share of the fare and discount off it, and returns the ticket left
+	// along with the fare released. Cancelling every seat cancels the ticket
+	// as CancelTicket does. It returns *SeatsNotOnTicketError for seats the
+	// ticket does not have, and ErrTicketSplit if only some seats of a
+	// ticket whose fare is split would go.
+	CancelSeats(ctx context.Context, id int, seats []int) (models.Ticket, money.Money, error)
+	// ChangeTicket moves a booked ticket to the bus, travel date and seats
+	// of to, at to's fare and surge multiplier, releasing the seats it had.
+	// The ticket keeps the discount it was booked with, up to the new fare.
+	// It fails like CreateTicket if the new seats cannot be had, leaving the
+	// ticket as it was, and returns ErrTicketCancelled, ErrTicketUsed or
+	// ErrTicketSplit for a ticket that cannot be changed.
+	ChangeTicket(ctx context.Context, id int, to models.Ticket) (models.Ticket, error)
+	// TransferTicket gives a booked ticket of fromUserID to toUserID and
+	// raises its QR version, revoking the codes signed for it so far. It
+	// returns ErrNotTicketOwner if fromUserID no longer owns the ticket, and
+	// ErrTicketCancelled, ErrTicketUsed or ErrTicketSplit for a ticket that
+	// cannot be transferred.
+	TransferTicket(ctx context.Context, id, fromUserID, toUserID int) (models.Ticket, error)
+}
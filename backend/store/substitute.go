@@ -0,0 +1,207 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+func (s *sqlStore) SubstituteBus(ctx context.Context, busID, replacementID int, from, to time.Time) (models.BusSubstitutionReport, []models.Ticket, error) {
+	var (
+		report models.BusSubstitutionReport
+		moved  []models.Ticket
+	)
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		report = models.BusSubstitutionReport{
+			BusID: busID, ReplacementBusID: replacementID,
+			Remapped: []models.SeatRemapping{}, Unmapped: []models.SeatRemapping{},
+		}
+		moved = nil
+
+		// Lowest ID first, as bookAll does, so that two substitutions
+		// between the same buses cannot deadlock.
+		first, second := busID, replacementID
+		if first > second {
+			first, second = second, first
+		}
+
+		capacity := make(map[int]int, 2)
+
+		for _, id := range []int{first, second} {
+			c, err := lockBus(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+
+			capacity[id] = c
+		}
+
+		fromModel, err := getBusModel(ctx, tx, busID)
+		if err != nil {
+			return err
+		}
+
+		toModel, err := getBusModel(ctx, tx, replacementID)
+		if err != nil {
+			return err
+		}
+
+		var until *time.Time
+		if !to.IsZero() {
+			until = &to
+		}
+
+		rows, err := tx.QueryContext(ctx,
+			`SELECT id FROM tickets WHERE bus_id = $1 AND status = $2 AND travel_date >= $3
+				AND ($4::timestamptz IS NULL OR travel_date < $4)
+			ORDER BY travel_date, id FOR UPDATE`,
+			busID, models.StatusBooked, from, until)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var ids []int
+
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+
+			ids = append(ids, id)
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			t, err := getTicket(ctx, tx, id, false)
+			if err != nil {
+				return err
+			}
+
+			remapping := models.SeatRemapping{TicketID: t.ID, UserID: t.UserID, TravelDate: t.TravelDate, FromSeats: t.SeatNumbers}
+
+			// Seats taken by the tickets moved so far are read back here.
+			taken, err := takenSeatSet(ctx, tx, replacementID, t.TravelDate)
+			if err != nil {
+				return err
+			}
+
+			seats, ok := equivalentSeats(t.SeatNumbers, fromModel, capacity[busID], toModel, capacity[replacementID], taken)
+			if !ok {
+				report.Unmapped = append(report.Unmapped, remapping)
+				continue
+			}
+
+			if err := moveTicket(ctx, tx, &t, replacementID, seats); err != nil {
+				return err
+			}
+
+			remapping.ToSeats = seats
+			report.Remapped = append(report.Remapped, remapping)
+			moved = append(moved, t)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.BusSubstitutionReport{}, nil, err
+	}
+
+	return report, moved, nil
+}
+
+// moveTicket puts ticket t on seats of busID within tx, the i-th seat taking
+// the place, and passenger, of the i-th seat it had.
+func moveTicket(ctx context.Context, tx *sql.Tx, t *models.Ticket, busID int, seats []int) error {
+	before := *t
+	before.Passengers = append([]models.Passenger(nil), t.Passengers...)
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tickets SET bus_id = $2 WHERE id = $1`, t.ID, busID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ticket_seats WHERE ticket_id = $1`, t.ID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ticket_passengers WHERE ticket_id = $1`, t.ID); err != nil {
+		return err
+	}
+
+	t.BusID, t.SeatNumbers = busID, seats
+	if err := insertSeats(ctx, tx, *t); err != nil {
+		return err
+	}
+
+	if err := insertPassengers(ctx, tx, t); err != nil {
+		return err
+	}
+
+	after, err := getTicket(ctx, tx, t.ID, false)
+	if err != nil {
+		return err
+	}
+
+	*t = after
+
+	return audit(ctx, tx, models.AuditUpdate, models.ResourceTicket, t.ID, before, after)
+}
+
+// equivalentSeats picks a free seat on a bus of toCapacity seats built to
+// to for each of seats on one of fromCapacity built to from: the seat in the
+// same deck, row and column if there is one and it is free, or else the
+// lowest free seat of the same type, or else the lowest free seat. It
+// reports false if there are not enough free seats; taken is updated with
+// the seats picked either way.
+func equivalentSeats(seats []int, from models.BusModel, fromCapacity int, to models.BusModel, toCapacity int, taken map[int]bool) ([]int, bool) {
+	type place struct{ deck, row, column int }
+
+	at := make(map[place]int, toCapacity)
+
+	for n := 1; n <= toCapacity; n++ {
+		deck, row, column := to.Place(n, toCapacity)
+		at[place{deck, row, column}] = n
+	}
+
+	picked := make([]int, len(seats))
+
+	for i, n := range seats {
+		deck, row, column := from.Place(n, fromCapacity)
+		if m, ok := at[place{deck, row, column}]; ok && !taken[m] {
+			picked[i], taken[m] = m, true
+		}
+	}
+
+	for _, sameType := range []bool{true, false} {
+		for i, n := range seats {
+			if picked[i] != 0 {
+				continue
+			}
+
+			for m := 1; m <= toCapacity; m++ {
+				if taken[m] || sameType && to.SeatType(m, toCapacity) != from.SeatType(n, fromCapacity) {
+					continue
+				}
+
+				picked[i], taken[m] = m, true
+
+				break
+			}
+		}
+	}
+
+	for _, m := range picked {
+		if m == 0 {
+			return nil, false
+		}
+	}
+
+	return picked, true
+}
@@ -0,0 +1,1233 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/requestlog"
+)
+
+// takenSeats selects the seats of a bus that are booked or under an
+// unexpired hold for travel on the same day, in the bus's time zone, as $2.
+const takenSeats = `SELECT s.seat_number FROM ticket_seats s JOIN buses b ON b.id = s.bus_id
+	WHERE s.bus_id = $1 AND (s.travel_date AT TIME ZONE b.timezone)::date = ($2::timestamptz AT TIME ZONE b.timezone)::date
+	UNION SELECT unnest(h.seat_numbers) FROM seat_holds h JOIN buses b ON b.id = h.bus_id
+	WHERE h.bus_id = $1 AND (h.travel_date AT TIME ZONE b.timezone)::date = ($2::timestamptz AT TIME ZONE b.timezone)::date
+		AND h.expires_at > now()`
+
+func (s *sqlStore) GetTicket(ctx context.Context, id int) (models.Ticket, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return models.Ticket{}, err
+	}
+	defer tx.Rollback()
+
+	return getTicket(ctx, tx, id, false)
+}
+
+func (s *sqlStore) GetBookedSeats(ctx context.Context, busID int, travelDate time.Time) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, takenSeats+` ORDER BY seat_number`, busID, travelDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seats []int
+
+	for rows.Next() {
+		var n int
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+
+		seats = append(seats, n)
+	}
+
+	return seats, rows.Err()
+}
+
+func (s *sqlStore) CountBookedSeats(ctx context.Context, busID int, from, to time.Time) (int, error) {
+	var n int
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM ticket_seats WHERE bus_id = $1 AND travel_date >= $2 AND travel_date < $3`,
+		busID, from, to).Scan(&n)
+
+	return n, err
+}
+
+func (s *sqlStore) CountBoardedSeats(ctx context.Context, busID int, from, to time.Time) (int, error) {
+	var n int
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM ticket_seats
+		WHERE bus_id = $1 AND travel_date >= $2 AND travel_date < $3 AND boarded_at IS NOT NULL`,
+		busID, from, to).Scan(&n)
+
+	return n, err
+}
+
+func (s *sqlStore) CreateTicket(ctx context.Context, t models.Ticket) (models.Ticket, bool, error) {
+	var (
+		booked  models.Ticket
+		created bool
+	)
+
+	// withTx may run the closure again after a conflict, so each attempt
+	// starts over from t and only the one that commits is kept.
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		t := t
+
+		capacity, err := lockBus(ctx, tx, t.BusID)
+		if err != nil {
+			return err
+		}
+
+		if t.IdempotencyKey != "" {
+			earlier, found, err := idempotentTicket(ctx, tx, t.UserID, t.IdempotencyKey)
+			if err != nil {
+				return err
+			} else if found {
+				booked, created = earlier, false
+				return nil
+			}
+		}
+
+		if t.HoldToken != "" {
+			if err := consumeHold(ctx, tx, t); err != nil {
+				return err
+			}
+		}
+
+		if len(t.SeatNumbers) == 0 {
+			if err := assignSeats(ctx, tx, &t, capacity); err != nil {
+				return err
+			}
+		}
+
+		if err := checkSeats(ctx, tx, t, capacity); err != nil {
+			return err
+		}
+
+		if err := checkSeatLimit(ctx, tx, t); err != nil {
+			return err
+		}
+
+		inserted, err := insertTicket(ctx, tx, t)
+		if err != nil {
+			return err
+		}
+
+		booked, created = inserted, true
+
+		return nil
+	})
+	if err != nil {
+		return models.Ticket{}, false, err
+	}
+
+	return booked, created, nil
+}
+
+func (s *sqlStore) CreateTickets(ctx context.Context, ts []models.Ticket) ([]models.Ticket, error) {
+	var created []models.Ticket
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		created, err = bookAll(ctx, tx, ts)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *sqlStore) CreateReturnTrip(ctx context.Context, outbound, inbound models.Ticket) (models.Ticket, models.Ticket, error) {
+	var created []models.Ticket
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		if created, err = bookAll(ctx, tx, []models.Ticket{outbound, inbound}); err != nil {
+			return err
+		}
+
+		out, in := &created[0], &created[1]
+		out.ReturnTicketID, in.ReturnTicketID = &in.ID, &out.ID
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE tickets SET return_ticket_id = CASE id WHEN $1 THEN $2 ELSE $1 END WHERE id IN ($1, $2)`,
+			out.ID, in.ID); err != nil {
+			return err
+		}
+
+		for _, t := range created {
+			if err := audit(ctx, tx, models.AuditUpdate, models.ResourceTicket, t.ID,
+				map[string]interface{}{"return_ticket_id": nil},
+				map[string]interface{}{"return_ticket_id": *t.ReturnTicketID}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Ticket{}, models.Ticket{}, err
+	}
+
+	return created[0], created[1], nil
+}
+
+// bookAll inserts every ticket in ts within tx, or returns a *BulkError for
+// the first that cannot be booked.
+func bookAll(ctx context.Context, tx *sql.Tx, ts []models.Ticket) ([]models.Ticket, error) {
+	// Lock every bus up front, in ID order, so two bulk bookings over the
+	// same buses cannot deadlock each other.
+	var busIDs []int
+	for _, t := range ts {
+		busIDs = append(busIDs, t.BusID)
+	}
+
+	sort.Ints(busIDs)
+
+	capacity := make(map[int]int)
+
+	for _, id := range busIDs {
+		if _, locked := capacity[id]; locked {
+			continue
+		}
+
+		c, err := lockBus(ctx, tx, id)
+		if err != nil {
+			for i, t := range ts {
+				if t.BusID == id {
+					return nil, &BulkError{Index: i, Err: err}
+				}
+			}
+		}
+
+		capacity[id] = c
+	}
+
+	created := make([]models.Ticket, 0, len(ts))
+
+	// Each check sees the seats inserted for earlier entries, so entries that
+	// clash with each other are caught too.
+	for i, t := range ts {
+		if len(t.SeatNumbers) == 0 {
+			if err := assignSeats(ctx, tx, &t, capacity[t.BusID]); err != nil {
+				return nil, &BulkError{Index: i, Err: err}
+			}
+		}
+
+		if err := checkSeats(ctx, tx, t, capacity[t.BusID]); err != nil {
+			return nil, &BulkError{Index: i, Err: err}
+		}
+
+		if err := checkSeatLimit(ctx, tx, t); err != nil {
+			return nil, &BulkError{Index: i, Err: err}
+		}
+
+		t, err := insertTicket(ctx, tx, t)
+		if err != nil {
+			return nil, &BulkError{Index: i, Err: err}
+		}
+
+		created = append(created, t)
+	}
+
+	return created, nil
+}
+
+// baseFare is the fare before surge of a ticket whose surged fare was original.
+func baseFare(original money.Money, multiplier float64) money.Money {
+	if multiplier == 0 {
+		return original
+	}
+
+	return original.Times(1 / multiplier)
+}
+
+// insertTicket writes t and its seats within tx and returns it with its ID.
+func insertTicket(ctx context.Context, tx *sql.Tx, t models.Ticket) (models.Ticket, error) {
+	if t.Status == "" {
+		t.Status = models.StatusBooked
+	}
+
+	if t.SurgeMultiplier == 0 {
+		t.SurgeMultiplier = 1
+	}
+
+	var key, code *string
+	if t.IdempotencyKey != "" {
+		key = &t.IdempotencyKey
+	}
+
+	if t.DiscountCode != "" {
+		if err := redeemDiscount(ctx, tx, t.DiscountCode); err != nil {
+			return models.Ticket{}, err
+		}
+
+		code = &t.DiscountCode
+	}
+
+	err := tx.QueryRowContext(ctx,
+		`INSERT INTO tickets (user_id, bus_id, travel_date, status, fare, discount, discount_code, idempotency_key,
+			surge_multiplier)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		t.UserID, t.BusID, t.TravelDate, t.Status, t.Fare, t.Discount, code, key, t.SurgeMultiplier).Scan(&t.ID)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	t.OriginalFare = t.Fare + t.Discount
+	t.BaseFare = baseFare(t.OriginalFare, t.SurgeMultiplier)
+
+	if err := insertSeats(ctx, tx, t); err != nil {
+		return models.Ticket{}, err
+	}
+
+	if err := insertPassengers(ctx, tx, &t); err != nil {
+		return models.Ticket{}, err
+	}
+
+	t = t.InLocalTime()
+	if err := audit(ctx, tx, models.AuditCreate, models.ResourceTicket, t.ID, nil, t); err != nil {
+		return models.Ticket{}, err
+	}
+
+	return t, nil
+}
+
+// insertSeats reserves the seats of ticket t on its bus and travel date.
+func insertSeats(ctx context.Context, tx *sql.Tx, t models.Ticket) error {
+	for _, seat := range t.SeatNumbers {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO ticket_seats (ticket_id, bus_id, travel_date, seat_number) VALUES ($1, $2, $3, $4)`,
+			t.ID, t.BusID, t.TravelDate, seat); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertPassengers records the passengers of ticket t, if it names them,
+// giving the i-th passenger the i-th of its seats.
+func insertPassengers(ctx context.Context, tx *sql.Tx, t *models.Ticket) error {
+	for i := range t.Passengers {
+		p := &t.Passengers[i]
+		p.SeatNumber = t.SeatNumbers[i]
+
+		var gender *string
+		if p.Gender != "" {
+			gender = &p.Gender
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO ticket_passengers (ticket_id, seat_number, name, age, gender) VALUES ($1, $2, $3, $4, $5)`,
+			t.ID, p.SeatNumber, p.Name, p.Age, gender); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lockBus locks the bus row for the rest of tx, serialising bookings on the
+// same bus, and returns its capacity.
+func lockBus(ctx context.Context, tx *sql.Tx, busID int) (int, error) {
+	var (
+		capacity int
+		deleted  bool
+	)
+
+	err := tx.QueryRowContext(ctx,
+		`SELECT capacity, deleted_at IS NOT NULL FROM buses WHERE id = $1 FOR UPDATE`, busID).Scan(&capacity, &deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	} else if err == nil && deleted {
+		return 0, ErrBusDeleted
+	}
+
+	return capacity, err
+}
+
+// idempotentTicket finds the ticket userID booked with key within
+// IdempotencyKeyTTL. An expired use of the key is cleared so the key can be
+// bound to the ticket about to be created.
+func idempotentTicket(ctx context.Context, tx *sql.Tx, userID int, key string) (models.Ticket, bool, error) {
+	var id int
+
+	err := tx.QueryRowContext(ctx,
+		`SELECT id FROM tickets WHERE user_id = $1 AND idempotency_key = $2 AND created_at > $3`,
+		userID, key, time.Now().Add(-IdempotencyKeyTTL)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE tickets SET idempotency_key = NULL WHERE user_id = $1 AND idempotency_key = $2`, userID, key)
+
+		return models.Ticket{}, false, err
+	} else if err != nil {
+		return models.Ticket{}, false, err
+	}
+
+	requestlog.Logger(ctx).InfoContext(ctx, "replaying idempotent booking",
+		slog.Int("ticket_id", id), slog.Int("user_id", userID))
+
+	t, err := getTicket(ctx, tx, id, false)
+
+	return t, err == nil, err
+}
+
+// assignSeats picks t.SeatCount free seats for a ticket booked without seat
+// numbers, lowest first, preferring seats of t.SeatPreference. The bus must
+// already be locked by tx.
+func assignSeats(ctx context.Context, tx *sql.Tx, t *models.Ticket, capacity int) error {
+	model, err := getBusModel(ctx, tx, t.BusID)
+	if err != nil {
+		return err
+	}
+
+	free, err := freeSeats(ctx, tx, t.BusID, t.TravelDate, capacity)
+	if err != nil {
+		return err
+	}
+
+	if len(free) == 0 {
+		return &SeatsUnavailableError{Full: true}
+	}
+
+	pref := t.SeatPreference
+	if pref == models.SeatAny {
+		pref = ""
+	}
+
+	var matching, others []int
+
+	for _, n := range free {
+		if pref == "" || model.SeatType(n, capacity) == pref {
+			matching = append(matching, n)
+		} else {
+			others = append(others, n)
+		}
+	}
+
+	switch {
+	case len(matching) >= t.SeatCount:
+		t.SeatNumbers = matching[:t.SeatCount]
+	case t.StrictSeats && pref != "":
+		return &NotEnoughSeatsError{Wanted: t.SeatCount, Free: len(matching), Preference: pref}
+	case len(free) < t.SeatCount:
+		return &NotEnoughSeatsError{Wanted: t.SeatCount, Free: len(free)}
+	default:
+		t.SeatNumbers = append(matching, others[:t.SeatCount-len(matching)]...)
+		sort.Ints(t.SeatNumbers)
+		t.SeatWarning = fmt.Sprintf("only %d of the %d seats could be %s seats", len(matching), t.SeatCount, pref)
+	}
+
+	return nil
+}
+
+// checkSeats verifies every seat in t is on a bus of the given capacity and
+// still free. The bus must already be locked by tx.
+func checkSeats(ctx context.Context, tx *sql.Tx, t models.Ticket, capacity int) error {
+	taken, err := takenSeatSet(ctx, tx, t.BusID, t.TravelDate)
+	if err != nil {
+		return err
+	}
+
+	var unavailable []int
+
+	for _, seat := range t.SeatNumbers {
+		if seat < 1 || seat > capacity || taken[seat] {
+			unavailable = append(unavailable, seat)
+		}
+	}
+
+	if len(unavailable) > 0 {
+		return &SeatsUnavailableError{Seats: unavailable, Full: len(taken) >= capacity}
+	}
+
+	return nil
+}
+
+// checkSeatLimit returns a *SeatLimitError if booking or holding t would
+// take its user past t.SeatLimit for the departure, counting the seats they
+// have booked and those under their unexpired holds. The bus row locked by
+// lockBus keeps concurrent bookings from both passing the check.
+func checkSeatLimit(ctx context.Context, tx *sql.Tx, t models.Ticket) error {
+	if t.SeatLimit <= 0 {
+		return nil
+	}
+
+	var booked int
+
+	err := tx.QueryRowContext(ctx,
+		`SELECT (SELECT COUNT(*) FROM ticket_seats s JOIN tickets t ON t.id = s.ticket_id JOIN buses b ON b.id = s.bus_id
+			WHERE t.user_id = $1 AND s.bus_id = $2
+				AND (s.travel_date AT TIME ZONE b.timezone)::date = ($3::timestamptz AT TIME ZONE b.timezone)::date)
+		+ (SELECT COALESCE(SUM(cardinality(h.seat_numbers)), 0) FROM seat_holds h JOIN buses b ON b.id = h.bus_id
+			WHERE h.user_id = $1 AND h.bus_id = $2 AND h.expires_at > now()
+				AND (h.travel_date AT TIME ZONE b.timezone)::date = ($3::timestamptz AT TIME ZONE b.timezone)::date)`,
+		t.UserID, t.BusID, t.TravelDate).Scan(&booked)
+	if err != nil {
+		return err
+	}
+
+	if booked+len(t.SeatNumbers) > t.SeatLimit {
+		return &SeatLimitError{Booked: booked, Wanted: len(t.SeatNumbers), Max: t.SeatLimit}
+	}
+
+	return nil
+}
+
+// takenSeatSet reads the takenSeats of a bus and travel date within tx.
+func takenSeatSet(ctx context.Context, tx *sql.Tx, busID int, travelDate time.Time) (map[int]bool, error) {
+	rows, err := tx.QueryContext(ctx, takenSeats, busID, travelDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taken := make(map[int]bool)
+
+	for rows.Next() {
+		var seat int
+		if err := rows.Scan(&seat); err != nil {
+			return nil, err
+		}
+
+		taken[seat] = true
+	}
+
+	return taken, rows.Err()
+}
+
+func (s *sqlStore) ValidateTicket(ctx context.Context, id, qrVersion, validatedBy int, device string) (models.ValidationResult, error) {
+	r := models.ValidationResult{TicketID: id}
+
+	var (
+		at       time.Time
+		timezone string
+	)
+
+	// Only one of two concurrent scans can move the ticket out of booked.
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`UPDATE tickets t SET status = $1, validated_at = now(), validated_by = $2, validated_device = NULLIF($3, '')
+			FROM buses b WHERE b.id = t.bus_id AND t.id = $4 AND t.status = $5 AND t.qr_version = $6
+			RETURNING t.validated_at, b.timezone`,
+			models.StatusValidated, validatedBy, device, id, models.StatusBooked, qrVersion).Scan(&at, &timezone)
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, models.AuditValidate, models.ResourceTicket, id,
+			map[string]interface{}{"status": models.StatusBooked},
+			map[string]interface{}{
+				"status": models.StatusValidated, "validated_at": at, "validated_by": validatedBy, "validated_device": device,
+			})
+	})
+	if err == nil {
+		r.Valid, r.Status = true, models.StatusValidated
+		r.ValidatedBy, r.DeviceID = &validatedBy, device
+		r.ValidatedAt = localTime(at, timezone)
+
+		return r, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return models.ValidationResult{}, err
+	}
+
+	var (
+		validatedAt *time.Time
+		by          *int
+		current     int
+	)
+
+	err = s.db.QueryRowContext(ctx,
+		`SELECT t.status, t.validated_at, t.validated_by, COALESCE(t.validated_device, ''), b.timezone, t.qr_version
+		FROM tickets t JOIN buses b ON b.id = t.bus_id WHERE t.id = $1`, id).
+		Scan(&r.Status, &validatedAt, &by, &r.DeviceID, &timezone, &current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ValidationResult{}, ErrNotFound
+	} else if err != nil {
+		return models.ValidationResult{}, err
+	}
+
+	// Whoever holds a revoked code is not told about the ticket's use.
+	if current != qrVersion {
+		return models.ValidationResult{TicketID: id, Status: r.Status, Revoked: true}, nil
+	}
+
+	if r.Status == models.StatusValidated {
+		r.AlreadyValidated = true
+		r.ValidatedBy = by
+
+		if validatedAt != nil {
+			r.ValidatedAt = localTime(*validatedAt, timezone)
+		}
+	}
+
+	return r, nil
+}
+
+func (s *sqlStore) BoardSeats(ctx context.Context, id, boardedBy int, b models.Boarding) (models.BoardingResult, error) {
+	var r models.BoardingResult
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		r = models.BoardingResult{TicketID: id, Boarded: []int{}, AlreadyBoarded: []int{}, Seats: []models.BoardedSeat{}}
+
+		t, err := getTicket(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		switch t.Status {
+		case models.StatusCancelled:
+			return ErrTicketCancelled
+		case models.StatusBooked:
+			return ErrTicketNotValidated
+		}
+
+		seats := b.SeatNumbers
+		if len(seats) == 0 {
+			seats = t.SeatNumbers
+		}
+
+		held := make(map[int]bool, len(t.SeatNumbers))
+		for _, n := range t.SeatNumbers {
+			held[n] = true
+		}
+
+		var missing []int
+
+		for _, n := range seats {
+			if !held[n] {
+				missing = append(missing, n)
+			}
+		}
+
+		if len(missing) > 0 {
+			return &SeatsNotOnTicketError{Seats: missing}
+		}
+
+		args := []interface{}{id, b.BoardedAt, boardedBy, b.Lat, b.Lng}
+		for _, n := range seats {
+			args = append(args, n)
+		}
+
+		// Only seats not yet boarded are updated, so repeating a boarding
+		// changes nothing.
+		rows, err := tx.QueryContext(ctx,
+			`UPDATE ticket_seats SET boarded_at = $2, boarded_by = $3, boarded_lat = $4, boarded_lng = $5
+			WHERE ticket_id = $1 AND boarded_at IS NULL AND seat_number IN (`+placeholders(6, len(seats))+`)
+			RETURNING seat_number`, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		boarded := make(map[int]bool, len(seats))
+
+		for rows.Next() {
+			var n int
+			if err := rows.Scan(&n); err != nil {
+				return err
+			}
+
+			boarded[n] = true
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, n := range seats {
+			if boarded[n] {
+				r.Boarded = append(r.Boarded, n)
+			} else {
+				r.AlreadyBoarded = append(r.AlreadyBoarded, n)
+			}
+		}
+
+		sort.Ints(r.Boarded)
+		sort.Ints(r.AlreadyBoarded)
+
+		// A rider who turns up late is no longer a no-show.
+		if len(r.Boarded) > 0 {
+			args := []interface{}{id}
+			for _, n := range r.Boarded {
+				args = append(args, n)
+			}
+
+			if _, err := tx.ExecContext(ctx,
+				`DELETE FROM no_shows WHERE ticket_id = $1 AND NOT released AND seat_number IN (`+placeholders(2, len(r.Boarded))+`)`,
+				args...); err != nil {
+				return err
+			}
+		}
+
+		r.Seats, err = boardedSeats(ctx, tx, id, t.Timezone)
+		if err != nil || len(r.Boarded) == 0 {
+			return err
+		}
+
+		var before, after []int
+
+		for _, bs := range r.Seats {
+			if !boarded[bs.SeatNumber] {
+				before = append(before, bs.SeatNumber)
+			}
+
+			after = append(after, bs.SeatNumber)
+		}
+
+		return audit(ctx, tx, models.AuditBoard, models.ResourceTicket, id,
+			map[string]interface{}{"boarded_seats": before}, map[string]interface{}{"boarded_seats": after})
+	})
+	if err != nil {
+		return models.BoardingResult{}, err
+	}
+
+	return r, nil
+}
+
+// boardedSeats returns the boarded seats of ticket id, with their boarding
+// times in timezone.
+func boardedSeats(ctx context.Context, q querier, id int, timezone string) ([]models.BoardedSeat, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT seat_number, boarded_at, boarded_by, boarded_lat, boarded_lng FROM ticket_seats
+		WHERE ticket_id = $1 AND boarded_at IS NOT NULL ORDER BY seat_number`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seats := []models.BoardedSeat{}
+
+	for rows.Next() {
+		var bs models.BoardedSeat
+		if err := rows.Scan(&bs.SeatNumber, &bs.BoardedAt, &bs.BoardedBy, &bs.Lat, &bs.Lng); err != nil {
+			return nil, err
+		}
+
+		bs.BoardedAt = *localTime(bs.BoardedAt, timezone)
+		seats = append(seats, bs)
+	}
+
+	return seats, rows.Err()
+}
+
+// localTime returns t in the named time zone, or unchanged if the zone is
+// unknown.
+func localTime(t time.Time, timezone string) *time.Time {
+	if loc, err := time.LoadLocation(timezone); err == nil {
+		t = t.In(loc)
+	}
+
+	return &t
+}
+
+func (s *sqlStore) CancelTicket(ctx context.Context, id int) (models.Ticket, error) {
+	var t models.Ticket
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		t, err = getTicket(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		switch t.Status {
+		case models.StatusCancelled:
+			return ErrTicketCancelled
+		case models.StatusValidated:
+			return ErrTicketUsed
+		}
+
+		return cancelTicket(ctx, tx, &t)
+	})
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	return t, nil
+}
+
+func (s *sqlStore) CancelReturnTrip(ctx context.Context, id int) (models.Ticket, models.Ticket, error) {
+	var t, other models.Ticket
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		// Both legs are locked in ID order, so that cancelling the trip from
+		// either end at once cannot deadlock.
+		if _, err := tx.ExecContext(ctx,
+			`SELECT id FROM tickets WHERE id = $1 OR id = (SELECT return_ticket_id FROM tickets WHERE id = $1)
+			ORDER BY id FOR UPDATE`, id); err != nil {
+			return err
+		}
+
+		var err error
+
+		t, err = getTicket(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+
+		switch t.Status {
+		case models.StatusCancelled:
+			return ErrTicketCancelled
+		case models.StatusValidated:
+			return ErrTicketUsed
+		}
+
+		if err := cancelTicket(ctx, tx, &t); err != nil {
+			return err
+		}
+
+		other = models.Ticket{}
+		if t.ReturnTicketID == nil {
+			return nil
+		}
+
+		if other, err = getTicket(ctx, tx, *t.ReturnTicketID, false); err != nil {
+			return err
+		}
+
+		// A leg already travelled or given up is left as it is.
+		if other.Status != models.StatusBooked {
+			other = models.Ticket{}
+			return nil
+		}
+
+		return cancelTicket(ctx, tx, &other)
+	})
+	if err != nil {
+		return models.Ticket{}, models.Ticket{}, err
+	}
+
+	return t, other, nil
+}
+
+func (s *sqlStore) CancelSeats(ctx context.Context, id int, seats []int) (models.Ticket, money.Money, error) {
+	var (
+		t        models.Ticket
+		released money.Money
+	)
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		t, err = getTicket(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		switch t.Status {
+		case models.StatusCancelled:
+			return ErrTicketCancelled
+		case models.StatusValidated:
+			return ErrTicketUsed
+		}
+
+		before := t
+		before.SeatNumbers = append([]int(nil), t.SeatNumbers...)
+
+		held := make(map[int]bool, len(t.SeatNumbers))
+		for _, n := range t.SeatNumbers {
+			held[n] = true
+		}
+
+		cancelled := make(map[int]bool, len(seats))
+
+		var missing []int
+
+		for _, n := range seats {
+			if !held[n] {
+				missing = append(missing, n)
+			}
+
+			cancelled[n] = true
+		}
+
+		if len(missing) > 0 {
+			return &SeatsNotOnTicketError{Seats: missing}
+		}
+
+		if len(cancelled) == len(t.SeatNumbers) {
+			if err := cancelTicket(ctx, tx, &t); err != nil {
+				return err
+			}
+
+			released = t.Fare
+
+			return nil
+		}
+
+		if err := checkUnsplit(ctx, tx, id); err != nil {
+			return err
+		}
+
+		// Seats share the fare and discount evenly; the ticket keeps whatever
+		// rounding leaves over.
+		share := float64(len(cancelled)) / float64(len(t.SeatNumbers))
+		released = t.Fare.Times(share)
+		discount := t.Discount.Times(share)
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE tickets SET fare = fare - $1, discount = discount - $2 WHERE id = $3`, released, discount, id); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM ticket_seats WHERE ticket_id = $1 AND seat_number = ANY($2::integer[])`, id, seatArray(seats)); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM ticket_passengers WHERE ticket_id = $1 AND seat_number = ANY($2::integer[])`,
+			id, seatArray(seats)); err != nil {
+			return err
+		}
+
+		kept := t.SeatNumbers[:0]
+		for _, n := range t.SeatNumbers {
+			if !cancelled[n] {
+				kept = append(kept, n)
+			}
+		}
+
+		t.SeatNumbers = kept
+
+		var riding []models.Passenger
+
+		for _, p := range t.Passengers {
+			if !cancelled[p.SeatNumber] {
+				riding = append(riding, p)
+			}
+		}
+
+		t.Passengers = riding
+		t.Fare -= released
+		t.Discount -= discount
+		t.OriginalFare = t.Fare + t.Discount
+		t.BaseFare = baseFare(t.OriginalFare, t.SurgeMultiplier)
+
+		if err := audit(ctx, tx, models.AuditCancel, models.ResourceTicket, id, before, t); err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Ticket{}, 0, err
+	}
+
+	return t, released, nil
+}
+
+// checkUnsplit returns ErrTicketSplit if the fare of ticket id is split
+// among its riders.
+func checkUnsplit(ctx context.Context, tx *sql.Tx, id int) error {
+	var split bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM ticket_splits WHERE ticket_id = $1)`, id).Scan(&split); err != nil {
+		return err
+	} else if split {
+		return ErrTicketSplit
+	}
+
+	return nil
+}
+
+func (s *sqlStore) ChangeTicket(ctx context.Context, id int, to models.Ticket) (models.Ticket, error) {
+	var t models.Ticket
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		t, err = getTicket(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		switch t.Status {
+		case models.StatusCancelled:
+			return ErrTicketCancelled
+		case models.StatusValidated:
+			return ErrTicketUsed
+		}
+
+		if err := checkUnsplit(ctx, tx, id); err != nil {
+			return err
+		}
+
+		capacity, err := lockBus(ctx, tx, to.BusID)
+		if err != nil {
+			return err
+		}
+
+		// The old seats go first, so that a move within the same departure
+		// may keep some of them.
+		if _, err := tx.ExecContext(ctx, `DELETE FROM ticket_seats WHERE ticket_id = $1`, id); err != nil {
+			return err
+		}
+
+		if err := checkSeats(ctx, tx, to, capacity); err != nil {
+			return err
+		}
+
+		if err := checkSeatLimit(ctx, tx, to); err != nil {
+			return err
+		}
+
+		before := t
+
+		t.BusID, t.TravelDate, t.Timezone, t.SeatNumbers = to.BusID, to.TravelDate, to.Timezone, to.SeatNumbers
+		t.Passengers = append([]models.Passenger(nil), to.Passengers...)
+		t.SurgeMultiplier = to.SurgeMultiplier
+		t.Discount = t.Discount.Min(to.Fare)
+		t.Fare = to.Fare - t.Discount
+		t.OriginalFare = to.Fare
+		t.BaseFare = baseFare(t.OriginalFare, t.SurgeMultiplier)
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE tickets SET bus_id = $2, travel_date = $3, fare = $4, discount = $5, surge_multiplier = $6
+			WHERE id = $1`,
+			id, t.BusID, t.TravelDate, t.Fare, t.Discount, t.SurgeMultiplier); err != nil {
+			return err
+		}
+
+		if err := insertSeats(ctx, tx, t); err != nil {
+			return err
+		}
+
+		// Passengers keep their order, and so move seat for seat.
+		if _, err := tx.ExecContext(ctx, `DELETE FROM ticket_passengers WHERE ticket_id = $1`, id); err != nil {
+			return err
+		}
+
+		if err := insertPassengers(ctx, tx, &t); err != nil {
+			return err
+		}
+
+		t = t.InLocalTime()
+
+		return audit(ctx, tx, models.AuditUpdate, models.ResourceTicket, id, before, t)
+	})
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	return t, nil
+}
+
+func (s *sqlStore) TransferTicket(ctx context.Context, id, fromUserID, toUserID int) (models.Ticket, error) {
+	var t models.Ticket
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+
+		t, err = getTicket(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		// A concurrent transfer may have given the ticket away since the
+		// caller last read it.
+		if t.UserID != fromUserID {
+			return ErrNotTicketOwner
+		}
+
+		switch t.Status {
+		case models.StatusCancelled:
+			return ErrTicketCancelled
+		case models.StatusValidated:
+			return ErrTicketUsed
+		}
+
+		if err := checkUnsplit(ctx, tx, id); err != nil {
+			return err
+		}
+
+		before := map[string]interface{}{"user_id": t.UserID, "qr_version": t.QRVersion}
+
+		t.UserID = toUserID
+		t.QRVersion++
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE tickets SET user_id = $2, qr_version = $3 WHERE id = $1`, id, t.UserID, t.QRVersion); err != nil {
+			return err
+		}
+
+		t = t.InLocalTime()
+
+		return audit(ctx, tx, models.AuditTransfer, models.ResourceTicket, id, before,
+			map[string]interface{}{"user_id": t.UserID, "qr_version": t.QRVersion})
+	})
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	return t, nil
+}
+
+// cancelTicket marks t cancelled within tx and releases all its seats.
+func cancelTicket(ctx context.Context, tx *sql.Tx, t *models.Ticket) error {
+	now := time.Now().UTC()
+	before := *t
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE tickets SET status = $1, cancelled_at = $2 WHERE id = $3`, models.StatusCancelled, now, t.ID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ticket_seats WHERE ticket_id = $1`, t.ID); err != nil {
+		return err
+	}
+
+	t.Status = models.StatusCancelled
+	t.CancelledAt = &now
+
+	// The audited ticket is left without the seats that were released.
+	after := *t
+	after.SeatNumbers = nil
+
+	return audit(ctx, tx, models.AuditCancel, models.ResourceTicket, t.ID, before, after)
+}
+
+// getTicket loads a ticket and its seats within tx, optionally locking the
+// ticket row until tx ends.
+func getTicket(ctx context.Context, tx *sql.Tx, id int, forUpdate bool) (models.Ticket, error) {
+	query := `SELECT t.id, t.user_id, t.bus_id, t.travel_date, b.timezone, t.status, t.fare, t.discount,
+			COALESCE(t.discount_code, ''), t.cancelled_at, t.surge_multiplier, t.return_ticket_id, t.qr_version
+		FROM tickets t JOIN buses b ON b.id = t.bus_id WHERE t.id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE OF t`
+	}
+
+	var t models.Ticket
+
+	err := tx.QueryRowContext(ctx, query, id).
+		Scan(&t.ID, &t.UserID, &t.BusID, &t.TravelDate, &t.Timezone, &t.Status, &t.Fare, &t.Discount, &t.DiscountCode,
+			&t.CancelledAt, &t.SurgeMultiplier, &t.ReturnTicketID, &t.QRVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Ticket{}, ErrNotFound
+	} else if err != nil {
+		return models.Ticket{}, err
+	}
+
+	t.OriginalFare = t.Fare + t.Discount
+	t.BaseFare = baseFare(t.OriginalFare, t.SurgeMultiplier)
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT seat_number FROM ticket_seats WHERE ticket_id = $1 ORDER BY seat_number`, id)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seat int
+		if err := rows.Scan(&seat); err != nil {
+			return models.Ticket{}, err
+		}
+
+		t.SeatNumbers = append(t.SeatNumbers, seat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.Ticket{}, err
+	}
+
+	t.Passengers, err = getPassengers(ctx, tx, id)
+	if err != nil {
+		return models.Ticket{}, err
+	}
+
+	return t.InLocalTime(), nil
+}
+
+// getPassengers loads the passengers named on ticket id, by seat.
+func getPassengers(ctx context.Context, tx *sql.Tx, id int) ([]models.Passenger, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT seat_number, name, age, COALESCE(gender, '') FROM ticket_passengers WHERE ticket_id = $1
+		ORDER BY seat_number`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var passengers []models.Passenger
+
+	for rows.Next() {
+		var p models.Passenger
+		if err := rows.Scan(&p.SeatNumber, &p.Name, &p.Age, &p.Gender); err != nil {
+			return nil, err
+		}
+
+		passengers = append(passengers, p)
+	}
+
+	return passengers, rows.Err()
+}
+
+func (s *sqlStore) GetBookingTimes(ctx context.Context, busID int, from, to time.Time) ([]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT created_at FROM tickets WHERE bus_id = $1 AND travel_date >= $2 AND travel_date < $3 ORDER BY created_at`,
+		busID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	times := []time.Time{}
+
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+
+		times = append(times, t)
+	}
+
+	return times, rows.Err()
+}
+
+func (s *sqlStore) GetRides(ctx context.Context, busID int, from, to time.Time) ([]models.Ride, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT s.ticket_id, s.seat_number, s.boarded_at, s.boarded_by, s.boarded_lat, s.boarded_lng,
+			COALESCE(l.from_stop, ''), COALESCE(l.to_stop, '')
+		FROM ticket_seats s LEFT JOIN journey_legs l ON l.ticket_id = s.ticket_id
+		WHERE s.bus_id = $1 AND s.travel_date >= $2 AND s.travel_date < $3 AND s.boarded_at IS NOT NULL
+		ORDER BY s.boarded_at, s.ticket_id, s.seat_number`,
+		busID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rides := []models.Ride{}
+
+	for rows.Next() {
+		var r models.Ride
+		if err := rows.Scan(&r.TicketID, &r.SeatNumber, &r.BoardedAt, &r.BoardedBy, &r.Lat, &r.Lng,
+			&r.FromStop, &r.ToStop); err != nil {
+			return nil, err
+		}
+
+		rides = append(rides, r)
+	}
+
+	return rides, rows.Err()
+}
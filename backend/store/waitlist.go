@@ -0,0 +1,233 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/money"
+)
+
+// waitlistPosition counts the waiting entries queued for the same bus and
+// travel date as entry e up to and including it.
+const waitlistPosition = `SELECT COUNT(*) FROM waitlist_entries w
+	WHERE w.bus_id = e.bus_id AND w.travel_date = e.travel_date AND w.status = 'waiting' AND w.id <= e.id`
+
+func (s *sqlStore) CreateWaitlistEntry(ctx context.Context, e models.WaitlistEntry) (models.WaitlistEntry, error) {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		capacity, err := lockBus(ctx, tx, e.BusID)
+		if err != nil {
+			return err
+		}
+
+		if e.Seats > capacity {
+			return ErrExceedsCapacity
+		}
+
+		e.Status = models.WaitlistWaiting
+
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO waitlist_entries (user_id, bus_id, travel_date, seats) VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at`, e.UserID, e.BusID, e.TravelDate, e.Seats).Scan(&e.ID, &e.JoinedAt)
+		if err != nil {
+			return err
+		}
+
+		if err := audit(ctx, tx, models.AuditCreate, models.ResourceWaitlistEntry, e.ID, nil, e); err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx,
+			`SELECT (`+waitlistPosition+`) FROM waitlist_entries e WHERE e.id = $1`, e.ID).Scan(&e.Position)
+	})
+	if err != nil {
+		return models.WaitlistEntry{}, err
+	}
+
+	return e, nil
+}
+
+func (s *sqlStore) GetWaitlistEntry(ctx context.Context, id int) (models.WaitlistEntry, error) {
+	var e models.WaitlistEntry
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT e.id, e.user_id, e.bus_id, e.seats, e.travel_date, e.status, e.ticket_id, e.created_at,
+			CASE WHEN e.status = 'waiting' THEN (`+waitlistPosition+`) ELSE 0 END
+		FROM waitlist_entries e WHERE e.id = $1`, id).
+		Scan(&e.ID, &e.UserID, &e.BusID, &e.Seats, &e.TravelDate, &e.Status, &e.TicketID, &e.JoinedAt, &e.Position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.WaitlistEntry{}, ErrNotFound
+	}
+
+	return e, err
+}
+
+func (s *sqlStore) PromoteWaitlist(ctx context.Context) ([]models.Ticket, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT DISTINCT bus_id, travel_date FROM waitlist_entries
+		WHERE status = 'waiting' AND travel_date > now() ORDER BY bus_id, travel_date`)
+	if err != nil {
+		return nil, err
+	}
+
+	type queue struct {
+		busID  int
+		travel time.Time
+	}
+
+	var queues []queue
+
+	for rows.Next() {
+		var q queue
+		if err := rows.Scan(&q.busID, &q.travel); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		queues = append(queues, q)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var promoted []models.Ticket
+
+	for _, q := range queues {
+		ts, err := s.promoteQueue(ctx, q.busID, q.travel)
+		if errors.Is(err, ErrNotFound) {
+			// The bus has been deleted; its queue can never be served.
+			continue
+		} else if err != nil {
+			return promoted, err
+		}
+
+		promoted = append(promoted, ts...)
+	}
+
+	return promoted, nil
+}
+
+// promoteQueue books the waiting entries for one bus and travel date, in
+// order, until the head of the queue asks for more seats than are free.
+// Later entries never overtake it, even if they would fit.
+func (s *sqlStore) promoteQueue(ctx context.Context, busID int, travel time.Time) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		capacity, err := lockBus(ctx, tx, busID)
+		if err != nil {
+			return err
+		}
+
+		var (
+			seatFare money.Money
+			timezone string
+		)
+
+		err = tx.QueryRowContext(ctx, `SELECT COALESCE(f.seat_fare, b.seat_fare), b.timezone
+			FROM buses b LEFT JOIN route_fares f ON f.route_id = b.route_id WHERE b.id = $1`, busID).Scan(&seatFare, &timezone)
+		if err != nil {
+			return err
+		}
+
+		free, err := freeSeats(ctx, tx, busID, travel, capacity)
+		if err != nil || len(free) == 0 {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx,
+			`SELECT id, user_id, seats FROM waitlist_entries
+			WHERE bus_id = $1 AND travel_date = $2 AND status = 'waiting' ORDER BY id`, busID, travel)
+		if err != nil {
+			return err
+		}
+
+		var entries []models.WaitlistEntry
+
+		for rows.Next() {
+			e := models.WaitlistEntry{BusID: busID, TravelDate: travel}
+			if err := rows.Scan(&e.ID, &e.UserID, &e.Seats); err != nil {
+				rows.Close()
+				return err
+			}
+
+			entries = append(entries, e)
+		}
+
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		var promoted []models.Ticket
+
+		for _, e := range entries {
+			if e.Seats > len(free) {
+				break
+			}
+
+			t, err := insertTicket(ctx, tx, models.Ticket{
+				UserID:      e.UserID,
+				BusID:       busID,
+				SeatNumbers: free[:e.Seats],
+				TravelDate:  travel,
+				Timezone:    timezone,
+				Fare:        seatFare * money.Money(e.Seats),
+			})
+			if err != nil {
+				return err
+			}
+
+			free = free[e.Seats:]
+
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE waitlist_entries SET status = $1, ticket_id = $2 WHERE id = $3`,
+				models.WaitlistPromoted, t.ID, e.ID); err != nil {
+				return err
+			}
+
+			if err := audit(ctx, tx, models.AuditUpdate, models.ResourceWaitlistEntry, e.ID,
+				map[string]interface{}{"status": models.WaitlistWaiting, "ticket_id": nil},
+				map[string]interface{}{"status": models.WaitlistPromoted, "ticket_id": t.ID}); err != nil {
+				return err
+			}
+
+			promoted = append(promoted, t)
+		}
+
+		tickets = promoted
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tickets, nil
+}
+
+// freeSeats returns the seats of a bus of the given capacity that are neither
+// booked nor held for a travel date, lowest first. The bus must already be
+// locked by tx.
+func freeSeats(ctx context.Context, tx *sql.Tx, busID int, travel time.Time, capacity int) ([]int, error) {
+	taken, err := takenSeatSet(ctx, tx, busID, travel)
+	if err != nil {
+		return nil, err
+	}
+
+	var free []int
+
+	for seat := 1; seat <= capacity; seat++ {
+		if !taken[seat] {
+			free = append(free, seat)
+		}
+	}
+
+	return free, nil
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+func (s *sqlStore) CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error) {
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO webhook_subscriptions (url, events, secret, created_by) VALUES ($1, $2::text[], $3, $4)
+			RETURNING id, created_at`,
+			sub.URL, "{"+strings.Join(sub.Events, ",")+"}", sub.Secret, sub.CreatedBy).Scan(&sub.ID, &sub.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		// The secret is kept out of the audit log.
+		audited := sub
+		audited.Secret = ""
+
+		return audit(ctx, tx, models.AuditCreate, models.ResourceWebhookSubscription, sub.ID, nil, audited)
+	})
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	return sub, nil
+}
+
+func (s *sqlStore) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var deletedAt time.Time
+
+		err := tx.QueryRowContext(ctx,
+			`UPDATE webhook_subscriptions SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL
+			RETURNING deleted_at`, id).Scan(&deletedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		} else if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE webhook_deliveries SET status = 'failed', last_error = 'unsubscribed'
+			WHERE subscription_id = $1 AND status = 'pending'`, id)
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, models.AuditDelete, models.ResourceWebhookSubscription, id,
+			map[string]interface{}{"deleted_at": nil}, map[string]interface{}{"deleted_at": deletedAt})
+	})
+}
+
+func (s *sqlStore) EnqueueWebhook(ctx context.Context, event string, payload []byte) (int, error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (subscription_id, event, payload)
+		SELECT id, $1, $2 FROM webhook_subscriptions WHERE $1 = ANY (events) AND deleted_at IS NULL`,
+		event, payload)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
+
+func (s *sqlStore) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`UPDATE webhook_deliveries d SET next_attempt_at = $2
+		FROM webhook_subscriptions w
+		WHERE w.id = d.subscription_id AND d.id IN (
+			SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING d.id, d.subscription_id, w.url, w.secret, d.event, d.payload, d.attempts`,
+		limit, time.Now().Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.URL, &d.Secret, &d.Event, &d.Payload, &d.Attempts); err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (s *sqlStore) RecordWebhookAttempt(ctx context.Context, id int, attemptErr string, retryAt *time.Time) error {
+	var err error
+
+	switch {
+	case attemptErr == "":
+		_, err = s.db.ExecContext(ctx,
+			`UPDATE webhook_deliveries SET status = 'delivered', attempts = attempts + 1, delivered_at = now()
+			WHERE id = $1`, id)
+	case retryAt != nil:
+		// A delivery unsubscribed from while it was being sent stays failed.
+		_, err = s.db.ExecContext(ctx,
+			`UPDATE webhook_deliveries SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3
+			WHERE id = $1 AND status = 'pending'`, id, attemptErr, *retryAt)
+	default:
+		_, err = s.db.ExecContext(ctx,
+			`UPDATE webhook_deliveries SET status = 'failed', attempts = attempts + 1, last_error = $2
+			WHERE id = $1`, id, attemptErr)
+	}
+
+	return err
+}
@@ -0,0 +1,143 @@
+// Package ticketqr signs the payloads printed as QR codes on tickets and
+// verifies them when a conductor scans one.
+package ticketqr
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+// prefix marks, and versions, the payload format. Codes printed before
+// tickets' codes could be revoked carry passengersPrefix, and those printed
+// before passengers were named legacyPrefix; both still scan.
+const (
+	prefix           = "BT3"
+	passengersPrefix = "BT2"
+	legacyPrefix     = "BT1"
+)
+
+// pngSize is the width and height of generated QR codes in pixels.
+const pngSize = 256
+
+// ErrInvalidPayload is returned for payloads that are malformed or whose
+// signature does not match.
+var ErrInvalidPayload = errors.New("invalid ticket payload")
+
+// Signer creates and checks payloads of the form
+// "BT3.<ticket>.<version>.<passengers>.<nonce>.<sig>", where version is the
+// ticket's QR version, passengers is the ticket's passengers as
+// base64url-encoded JSON and sig is an HMAC-SHA256 over the rest under the
+// signing key. The older "BT2.<ticket>.<passengers>.<nonce>.<sig>" and
+// "BT1.<ticket>.<nonce>.<sig>" forms are verified too, as version 0.
+type Signer struct {
+	key []byte
+}
+
+// Payload is what a verified code says about its ticket. Version is the
+// ticket's QR version when the code was signed; a code is only good while
+// the ticket is still at it.
+type Payload struct {
+	TicketID   int
+	Version    int
+	Passengers []models.Passenger
+}
+
+// NewSigner returns a Signer using key.
+func NewSigner(key string) *Signer {
+	return &Signer{key: []byte(key)}
+}
+
+// Sign returns a payload for ticketID at QR version naming passengers, with
+// a fresh random nonce.
+func (s *Signer) Sign(ticketID, version int, passengers []models.Passenger) (string, error) {
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	if passengers == nil {
+		passengers = []models.Passenger{}
+	}
+
+	riders, err := json.Marshal(passengers)
+	if err != nil {
+		return "", err
+	}
+
+	body := prefix + "." + strconv.Itoa(ticketID) + "." + strconv.Itoa(version) + "." +
+		base64.RawURLEncoding.EncodeToString(riders) + "." + hex.EncodeToString(nonce)
+
+	return body + "." + s.mac(body), nil
+}
+
+// Verify checks payload's signature and returns what it carries.
+func (s *Signer) Verify(payload string) (Payload, error) {
+	parts := strings.Split(payload, ".")
+
+	switch {
+	case len(parts) == 6 && parts[0] == prefix:
+	case len(parts) == 5 && parts[0] == passengersPrefix:
+	case len(parts) == 4 && parts[0] == legacyPrefix:
+	default:
+		return Payload{}, ErrInvalidPayload
+	}
+
+	last := len(parts) - 1
+
+	body := strings.Join(parts[:last], ".")
+	if !hmac.Equal([]byte(parts[last]), []byte(s.mac(body))) {
+		return Payload{}, ErrInvalidPayload
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil || id < 1 {
+		return Payload{}, ErrInvalidPayload
+	}
+
+	p := Payload{TicketID: id}
+
+	riders := ""
+
+	switch parts[0] {
+	case prefix:
+		if p.Version, err = strconv.Atoi(parts[2]); err != nil || p.Version < 0 {
+			return Payload{}, ErrInvalidPayload
+		}
+
+		riders = parts[3]
+	case passengersPrefix:
+		riders = parts[2]
+	}
+
+	if riders != "" {
+		b, err := base64.RawURLEncoding.DecodeString(riders)
+		if err != nil || json.Unmarshal(b, &p.Passengers) != nil {
+			return Payload{}, ErrInvalidPayload
+		}
+	}
+
+	return p, nil
+}
+
+func (s *Signer) mac(body string) string {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(body))
+
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// PNG renders payload as a QR code image.
+func PNG(payload string) ([]byte, error) {
+	return qrcode.Encode(payload, qrcode.Medium, pngSize)
+}
@@ -0,0 +1,133 @@
+// Package timeout bounds how long a request may take to be answered.
+package timeout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+)
+
+// Middleware cancels each request's context once it has run for d, or for
+// the duration overrides gives its "METHOD /path" route; a duration of 0
+// lets the request run unbounded. A request whose response has not started
+// by then gets a 503, and anything its handler writes afterwards is
+// discarded. WebSocket upgrades are left alone, as they are meant to stay
+// open.
+func Middleware(d time.Duration, overrides map[string]time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, ok := overrides[r.Method+" "+r.URL.Path]
+			if !ok {
+				limit = d
+			}
+
+			if limit <= 0 || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), limit)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+			case <-ctx.Done():
+				tw.expire(errors.Is(ctx.Err(), context.DeadlineExceeded), limit)
+			}
+		})
+	}
+}
+
+// timeoutWriter passes a handler's response through until the request
+// expires, then drops it. Headers are kept apart from the underlying
+// writer's so the handler can go on setting them after it has expired.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	expired     bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.header }
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.writeHeader(code)
+}
+
+func (tw *timeoutWriter) writeHeader(code int) {
+	if tw.expired || tw.wroteHeader {
+		return
+	}
+
+	tw.wroteHeader = true
+
+	for k, v := range tw.header {
+		tw.w.Header()[k] = v
+	}
+
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expired {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	tw.writeHeader(http.StatusOK)
+
+	return tw.w.Write(b)
+}
+
+// Flush keeps streaming responses, such as exports, streaming.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if f, ok := tw.w.(http.Flusher); ok && !tw.expired {
+		f.Flush()
+	}
+}
+
+// expire stops the handler writing any more. If it timed out before
+// starting its response, the client is told so with a 503.
+func (tw *timeoutWriter) expire(timedOut bool, limit time.Duration) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.expired = true
+
+	if !timedOut || tw.wroteHeader {
+		return
+	}
+
+	apierror.Write(tw.w, apierror.New(apierror.ErrUnavailable, "timeout", "request timed out after %s", limit))
+}
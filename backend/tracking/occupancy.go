@@ -0,0 +1,120 @@
+package tracking
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// Occupancy recounts departures as their bookings and holds change and
+// publishes the counts, and the seats taken on departures being watched, to
+// a Hub, which passes on only what changed.
+type Occupancy struct {
+	store  store.Store
+	hub    *Hub
+	logger *slog.Logger
+}
+
+// NewOccupancy returns an Occupancy publishing to hub and logging to logger.
+func NewOccupancy(st store.Store, hub *Hub, logger *slog.Logger) *Occupancy {
+	return &Occupancy{store: st, hub: hub, logger: logger}
+}
+
+// departure is a bus's run on a date, as YYYY-MM-DD in its time zone.
+type departure struct {
+	busID int
+	date  string
+}
+
+// Refresh recounts the departure each of tickets travels on: its bus's run
+// on the day its travel date falls on in the bus's time zone. Failures are
+// logged rather than returned, since the change being reported has already
+// been made. A nil Occupancy ignores it.
+func (o *Occupancy) Refresh(ctx context.Context, tickets ...models.Ticket) {
+	if o == nil {
+		return
+	}
+
+	seen := make(map[departure]bool)
+
+	for _, t := range tickets {
+		o.recount(ctx, t.BusID, t.TravelDate, seen)
+	}
+}
+
+// RefreshHolds is Refresh for the departures of seat holds that have been
+// taken or have run out.
+func (o *Occupancy) RefreshHolds(ctx context.Context, holds ...models.SeatHold) {
+	if o == nil {
+		return
+	}
+
+	seen := make(map[departure]bool)
+
+	for _, h := range holds {
+		o.recount(ctx, h.BusID, h.TravelDate, seen)
+	}
+}
+
+func (o *Occupancy) recount(ctx context.Context, busID int, travel time.Time, seen map[departure]bool) {
+	if err := o.refresh(ctx, busID, travel, seen); err != nil && ctx.Err() == nil {
+		o.logger.ErrorContext(ctx, "recounting occupancy failed",
+			slog.Int("bus_id", busID), slog.String("error", err.Error()))
+	}
+}
+
+func (o *Occupancy) refresh(ctx context.Context, busID int, travel time.Time, seen map[departure]bool) error {
+	bus, err := o.store.GetBusByID(ctx, busID)
+	if errors.Is(err, store.ErrBusDeleted) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	y, m, d := travel.In(bus.Location()).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, bus.Location())
+
+	dep := departure{busID: busID, date: day.Format("2006-01-02")}
+	if seen[dep] {
+		return nil
+	}
+
+	seen[dep] = true
+
+	booked, err := o.store.CountBookedSeats(ctx, busID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	boarded, err := o.store.CountBoardedSeats(ctx, busID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	o.hub.PublishOccupancy(models.OccupancyUpdate{
+		BusID:        busID,
+		Date:         dep.date,
+		Capacity:     bus.Capacity,
+		BookedSeats:  booked,
+		BoardedSeats: boarded,
+		Occupancy:    models.OccupancyPercent(booked, bus.Capacity),
+		At:           time.Now().UTC(),
+	})
+
+	if !o.hub.WatchingSeats(busID, dep.date) {
+		return nil
+	}
+
+	taken, err := o.store.GetBookedSeats(ctx, busID, day)
+	if err != nil {
+		return err
+	}
+
+	o.hub.PublishSeats(busID, dep.date, taken)
+
+	return nil
+}
@@ -0,0 +1,365 @@
+// Package tracking fans live bus positions, occupancy and seat availability
+// out to whoever is watching them.
+package tracking
+
+import (
+	"sync"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+)
+
+// subscriberBuffer is how many updates a slow subscriber may fall behind
+// before further updates to it are dropped.
+const subscriberBuffer = 8
+
+// allBuses is the subscription key for subscribers to every bus.
+const allBuses = -1
+
+// occupancyRetention is how long after its date a departure's last
+// occupancy is remembered for working out changes.
+const occupancyRetention = 48 * time.Hour
+
+// Hub keeps the latest position per bus, and when it arrived, and
+// broadcasts new ones to every subscriber of that bus, and likewise the
+// occupancy of each departure and the seats taken on those being watched.
+// It is safe for concurrent use. Location reads are served from it rather
+// than the store.
+type Hub struct {
+	mu     sync.RWMutex
+	latest map[int]models.LiveLocation
+	subs   map[int]map[chan models.LocationUpdate]struct{}
+
+	occupancy map[int]map[string]models.OccupancyUpdate
+	occSubs   map[int]map[chan models.OccupancyUpdate]struct{}
+
+	seats    map[departure][]int
+	seatSubs map[departure]map[chan models.SeatUpdate]struct{}
+}
+
+// NewHub returns an empty Hub.
+func NewHub() *Hub {
+	return &Hub{
+		latest:    make(map[int]models.LiveLocation),
+		subs:      make(map[int]map[chan models.LocationUpdate]struct{}),
+		occupancy: make(map[int]map[string]models.OccupancyUpdate),
+		occSubs:   make(map[int]map[chan models.OccupancyUpdate]struct{}),
+		seats:     make(map[departure][]int),
+		seatSubs:  make(map[departure]map[chan models.SeatUpdate]struct{}),
+	}
+}
+
+// Publish records u as the latest position of its bus and sends it to the
+// bus's subscribers. Subscribers that are not keeping up miss the update
+// rather than blocking the publisher. An update older than the bus's latest
+// arrived out of order and is dropped; Publish reports whether u was kept.
+func (h *Hub) Publish(u models.LocationUpdate) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if last, ok := h.latest[u.BusID]; ok && u.Timestamp.Before(last.Timestamp) {
+		return false
+	}
+
+	h.latest[u.BusID] = models.LiveLocation{LocationUpdate: u, LastSeen: time.Now().UTC()}
+
+	for _, key := range []int{u.BusID, allBuses} {
+		for ch := range h.subs[key] {
+			select {
+			case ch <- u:
+			default:
+			}
+		}
+	}
+
+	return true
+}
+
+// Latest returns the most recent position published for busID.
+func (h *Hub) Latest(busID int) (models.LocationUpdate, bool) {
+	l, ok := h.Live(busID)
+	return l.LocationUpdate, ok
+}
+
+// Live returns the most recent position published for busID along with
+// when it was published. Signal is left for the caller to judge.
+func (h *Hub) Live(busID int) (models.LiveLocation, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	l, ok := h.latest[busID]
+
+	return l, ok
+}
+
+// LiveAll returns the most recent position of every bus that has reported
+// one, as Live does.
+func (h *Hub) LiveAll() []models.LiveLocation {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	all := make([]models.LiveLocation, 0, len(h.latest))
+	for _, l := range h.latest {
+		all = append(all, l)
+	}
+
+	return all
+}
+
+// Subscribe returns a channel of future updates for busID and a function
+// that ends the subscription and closes the channel.
+func (h *Hub) Subscribe(busID int) (<-chan models.LocationUpdate, func()) {
+	return h.subscribe(busID, subscriberBuffer)
+}
+
+// SubscribeAll is like Subscribe but receives the updates of every bus.
+func (h *Hub) SubscribeAll() (<-chan models.LocationUpdate, func()) {
+	return h.subscribe(allBuses, 16*subscriberBuffer)
+}
+
+func (h *Hub) subscribe(busID, buffer int) (<-chan models.LocationUpdate, func()) {
+	ch := make(chan models.LocationUpdate, buffer)
+
+	h.mu.Lock()
+	if h.subs[busID] == nil {
+		h.subs[busID] = make(map[chan models.LocationUpdate]struct{})
+	}
+
+	h.subs[busID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			delete(h.subs[busID], ch)
+
+			if len(h.subs[busID]) == 0 {
+				delete(h.subs, busID)
+			}
+
+			close(ch)
+		})
+	}
+}
+
+// PublishOccupancy sends o to the occupancy subscribers of its bus, filling
+// in how its counts changed since the last update for the same departure.
+// An update that changes nothing is dropped; PublishOccupancy reports
+// whether o was sent.
+func (h *Hub) PublishOccupancy(o models.OccupancyUpdate) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	departures := h.occupancy[o.BusID]
+	if departures == nil {
+		departures = make(map[string]models.OccupancyUpdate)
+		h.occupancy[o.BusID] = departures
+	}
+
+	if last, ok := departures[o.Date]; ok {
+		o.BookedChange = o.BookedSeats - last.BookedSeats
+		o.BoardedChange = o.BoardedSeats - last.BoardedSeats
+
+		if o.BookedChange == 0 && o.BoardedChange == 0 && o.Capacity == last.Capacity {
+			return false
+		}
+	}
+
+	departures[o.Date] = o
+
+	// Forget departures long gone, which no update will change again.
+	for date := range departures {
+		if day, err := time.Parse("2006-01-02", date); err == nil && time.Since(day) > occupancyRetention {
+			delete(departures, date)
+		}
+	}
+
+	for ch := range h.occSubs[o.BusID] {
+		select {
+		case ch <- o:
+		default:
+		}
+	}
+
+	return true
+}
+
+// SubscribeOccupancy returns a channel of future occupancy updates for the
+// departures of busID and a function that ends the subscription and closes
+// the channel.
+func (h *Hub) SubscribeOccupancy(busID int) (<-chan models.OccupancyUpdate, func()) {
+	ch := make(chan models.OccupancyUpdate, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.occSubs[busID] == nil {
+		h.occSubs[busID] = make(map[chan models.OccupancyUpdate]struct{})
+	}
+
+	h.occSubs[busID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			delete(h.occSubs[busID], ch)
+
+			if len(h.occSubs[busID]) == 0 {
+				delete(h.occSubs, busID)
+			}
+
+			close(ch)
+		})
+	}
+}
+
+// WatchingSeats reports whether anyone is subscribed to the seats of busID's
+// departure on date, so that publishers can skip working them out.
+func (h *Hub) WatchingSeats(busID int, date string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.seatSubs[departure{busID: busID, date: date}]) > 0
+}
+
+// PublishSeats records taken as the seats booked or held on busID's
+// departure on date and sends those that changed to its subscribers. Only
+// watched departures are followed, and an update that changes nothing is
+// dropped; PublishSeats reports whether one was sent. A subscriber that is
+// not keeping up is dropped and its channel closed, since a seat map that
+// missed an update would stay wrong.
+func (h *Hub) PublishSeats(busID int, date string, taken []int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	dep := departure{busID: busID, date: date}
+
+	subs := h.seatSubs[dep]
+	if len(subs) == 0 {
+		return false
+	}
+
+	last, ok := h.seats[dep]
+	h.seats[dep] = taken
+
+	// Until a subscriber has seeded the departure there is nothing to
+	// compare with; SeedSeats hands it taken as its snapshot instead.
+	if !ok {
+		return false
+	}
+
+	u := models.SeatUpdate{
+		BusID: busID,
+		Date:  date,
+		Taken: seatsNotIn(taken, last),
+		Freed: seatsNotIn(last, taken),
+		At:    time.Now().UTC(),
+	}
+
+	if len(u.Taken) == 0 && len(u.Freed) == 0 {
+		return false
+	}
+
+	for ch := range subs {
+		select {
+		case ch <- u:
+		default:
+			delete(subs, ch)
+			close(ch)
+		}
+	}
+
+	h.forgetSeats(dep)
+
+	return true
+}
+
+// SubscribeSeats returns a channel of future changes to the seats of
+// busID's departure on date and a function that ends the subscription and
+// closes the channel, unless PublishSeats already has. The subscriber then
+// reads the departure's seats and passes them to SeedSeats for its
+// snapshot.
+func (h *Hub) SubscribeSeats(busID int, date string) (<-chan models.SeatUpdate, func()) {
+	dep := departure{busID: busID, date: date}
+	ch := make(chan models.SeatUpdate, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.seatSubs[dep] == nil {
+		h.seatSubs[dep] = make(map[chan models.SeatUpdate]struct{})
+	}
+
+	h.seatSubs[dep][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			if _, ok := h.seatSubs[dep][ch]; ok {
+				delete(h.seatSubs[dep], ch)
+				close(ch)
+			}
+
+			h.forgetSeats(dep)
+		})
+	}
+}
+
+// SeedSeats returns the seats a new subscriber to busID's departure on date
+// should start from: those already followed for the departure, or taken,
+// read after subscribing, if it has not been published since. Updates sent
+// afterwards are relative to what it returns. An update that arrived while
+// it was reading is already part of it and applies again harmlessly.
+func (h *Hub) SeedSeats(busID int, date string, taken []int) []int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	dep := departure{busID: busID, date: date}
+
+	if last, ok := h.seats[dep]; ok {
+		return last
+	}
+
+	if len(h.seatSubs[dep]) > 0 {
+		h.seats[dep] = taken
+	}
+
+	return taken
+}
+
+// forgetSeats stops following dep once nobody is subscribed to it. h.mu
+// must be held.
+func (h *Hub) forgetSeats(dep departure) {
+	if len(h.seatSubs[dep]) == 0 {
+		delete(h.seatSubs, dep)
+		delete(h.seats, dep)
+	}
+}
+
+// seatsNotIn returns the seats of a that are not in b, in a's order.
+func seatsNotIn(a, b []int) []int {
+	in := make(map[int]bool, len(b))
+	for _, n := range b {
+		in[n] = true
+	}
+
+	out := []int{}
+
+	for _, n := range a {
+		if !in[n] {
+			out = append(out, n)
+		}
+	}
+
+	return out
+}
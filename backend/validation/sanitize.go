@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// noTrim is the sanitize tag value that keeps a string field's surrounding
+// whitespace, for passwords and the like.
+const noTrim = "notrim"
+
+// sanitize trims the surrounding whitespace off every string reachable from
+// v, which must be a pointer for the trimmed strings to stick, and returns
+// an error for each one that holds control characters. Fields tagged
+// sanitize:"notrim" are checked but not trimmed.
+func sanitize(v interface{}) []FieldError {
+	var errs []FieldError
+
+	walk(reflect.ValueOf(v), "", true, &errs)
+
+	return errs
+}
+
+func walk(v reflect.Value, path string, trim bool, errs *[]FieldError) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walk(v.Elem(), path, trim, errs)
+		}
+	case reflect.Struct:
+		t := v.Type()
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+
+			switch {
+			case name == "-":
+				continue
+			case f.Anonymous && name == "":
+				walk(v.Field(i), path, trim, errs)
+				continue
+			case name == "":
+				name = f.Name
+			}
+
+			if path != "" {
+				name = path + "." + name
+			}
+
+			walk(v.Field(i), name, f.Tag.Get("sanitize") != noTrim, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		// Raw bytes are left as they are.
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i), trim, errs)
+		}
+	case reflect.String:
+		s := v.String()
+
+		if strings.IndexFunc(s, unicode.IsControl) >= 0 {
+			format := "must not contain control characters"
+			*errs = append(*errs, FieldError{Field: path, Message: format, format: format})
+
+			return
+		}
+
+		if trimmed := strings.TrimSpace(s); trim && trimmed != s && v.CanSet() {
+			v.SetString(trimmed)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package validation checks request bodies against their validate struct
+// tags and reports every invalid field in one response.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/apierror"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/i18n"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/schedule"
+)
+
+// FieldError describes one invalid field, named by its JSON path.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+
+	// format and args built Message, for Localize to build it again.
+	format string
+	args   []interface{}
+}
+
+// Errors is the body of a 400 caused by invalid fields.
+type Errors struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error is returned by Struct when validation fails. It is an
+// apierror.ErrValidation and satisfies gofr's contracts for handler errors,
+// so handlers can return it directly, alongside Body.
+type Error struct {
+	Body Errors
+	lang string
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, 0, len(e.Body.Errors))
+	for _, f := range e.Body.Errors {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+
+	return i18n.Sprintf(e.lang, "invalid request: %s", strings.Join(msgs, "; "))
+}
+
+func (e *Error) Unwrap() error { return apierror.ErrValidation }
+
+func (e *Error) StatusCode() int { return http.StatusBadRequest }
+
+func (e *Error) Response() map[string]interface{} {
+	return map[string]interface{}{"code": apierror.Code(e)}
+}
+
+// Prefix returns a copy of e with prefix prepended to every field, for
+// reporting errors in one element of an array body.
+func (e *Error) Prefix(prefix string) *Error {
+	out := &Error{Body: Errors{Errors: make([]FieldError, len(e.Body.Errors))}, lang: e.lang}
+
+	for i, f := range e.Body.Errors {
+		f.Field = prefix + f.Field
+		out.Body.Errors[i] = f
+	}
+
+	return out
+}
+
+// Localize returns a copy of e with its messages in lang, where the i18n
+// catalog has them.
+func (e *Error) Localize(lang string) *Error {
+	out := &Error{Body: Errors{Errors: make([]FieldError, len(e.Body.Errors))}, lang: lang}
+
+	for i, f := range e.Body.Errors {
+		if f.format != "" {
+			f.Message = i18n.Sprintf(lang, f.format, f.args...)
+		}
+
+		out.Body.Errors[i] = f
+	}
+
+	return out
+}
+
+var validate = newValidator()
+
+// phonePattern matches an E.164 phone number: a +, a country code and at
+// most 15 digits in all.
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by the names clients send.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	_ = v.RegisterValidation("rfc3339", func(fl validator.FieldLevel) bool {
+		_, err := time.Parse(time.RFC3339, fl.Field().String())
+		return err == nil
+	})
+
+	// Travel dates may leave out the UTC offset, to be read in a time zone.
+	_ = v.RegisterValidation("traveldate", func(fl validator.FieldLevel) bool {
+		_, err := models.ParseTravelDate(fl.Field().String(), time.UTC)
+		return err == nil
+	})
+
+	// Phone numbers are in E.164 form; "" is allowed so a pointer field can
+	// remove one.
+	_ = v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return fl.Field().String() == "" || phonePattern.MatchString(fl.Field().String())
+	})
+
+	_ = v.RegisterValidation("rundays", func(fl validator.FieldLevel) bool {
+		_, err := schedule.ParseDays(fl.Field().String())
+		return err == nil
+	})
+
+	return v
+}
+
+// Struct sanitizes v, trimming the whitespace around its strings, and
+// validates it, returning nil or an *Error listing every invalid field. A
+// string holding control characters is invalid whatever its tags say.
+func Struct(v interface{}) *Error {
+	if errs := sanitize(v); len(errs) > 0 {
+		return &Error{Body: Errors{Errors: errs}}
+	}
+
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return &Error{Body: Errors{Errors: []FieldError{{Field: "", Message: err.Error()}}}}
+	}
+
+	out := &Error{Body: Errors{Errors: make([]FieldError, 0, len(fieldErrs))}}
+
+	for _, fe := range fieldErrs {
+		format, args := message(fe)
+		out.Body.Errors = append(out.Body.Errors, FieldError{
+			Field: fieldPath(fe), Message: fmt.Sprintf(format, args...), format: format, args: args,
+		})
+	}
+
+	return out
+}
+
+// fieldPath drops the struct type that validator puts at the front of the namespace.
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+
+	return path
+}
+
+// message returns the format and arguments of the message reporting fe.
+func message(fe validator.FieldError) (string, []interface{}) {
+	countable := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map || fe.Kind() == reflect.Array
+	text := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required", "required_with", "required_without", "required_without_all":
+		return "is required", nil
+	case "excluded_with":
+		return "must be omitted when %s is given", []interface{}{fieldNames(fe.Param())}
+	case "excluded_without":
+		return "must be omitted unless %s is given", []interface{}{fieldNames(fe.Param())}
+	case "min", "gte":
+		switch {
+		case countable && fe.Param() == "1":
+			return "must not be empty", nil
+		case countable:
+			return "must contain at least %s items", []interface{}{fe.Param()}
+		case text:
+			return "must be at least %s characters", []interface{}{fe.Param()}
+		}
+
+		return "must be at least %s", []interface{}{fe.Param()}
+	case "max", "lte":
+		switch {
+		case countable:
+			return "must contain at most %s items", []interface{}{fe.Param()}
+		case text:
+			return "must be at most %s characters", []interface{}{fe.Param()}
+		}
+
+		return "must be at most %s", []interface{}{fe.Param()}
+	case "gt":
+		return "must be greater than %s", []interface{}{fe.Param()}
+	case "oneof":
+		return "must be one of: %s", []interface{}{strings.ReplaceAll(fe.Param(), " ", ", ")}
+	case "notblank":
+		return "must not be empty", nil
+	case "unique":
+		return "must not contain duplicates", nil
+	case "email":
+		return "must be a valid email address", nil
+	case "http_url":
+		return "must be an http or https URL", nil
+	case "datetime":
+		return "must be formatted as %s", []interface{}{fe.Param()}
+	case "rfc3339":
+		return "must be an RFC3339 timestamp (e.g. 2024-05-01T09:30:00Z)", nil
+	case "traveldate":
+		return "must be a date and time such as 2024-05-01T09:30:00, with or without a UTC offset", nil
+	case "timezone":
+		return "must be an IANA time zone such as Asia/Kolkata", nil
+	case "phone":
+		return "must be a phone number such as +919876543210", nil
+	case "rundays":
+		return "must be daily, weekdays, weekends or a list of days such as mon,wed,fri", nil
+	}
+
+	return "failed the %q check", []interface{}{fe.Tag()}
+}
+
+// fieldNames renders a tag's space-separated Go field names as JSON names
+// joined by "or".
+func fieldNames(param string) string {
+	names := strings.Fields(param)
+	for i, n := range names {
+		names[i] = snakeCase(n)
+	}
+
+	return strings.Join(names, " or ")
+}
+
+// snakeCase turns a Go field name such as HoldToken into its JSON name.
+func snakeCase(name string) string {
+	var b strings.Builder
+
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+
+			r = unicode.ToLower(r)
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,80 @@
+// Package waitlist promotes waitlisted riders to booked tickets as seats
+// free up.
+package waitlist
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/notify"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/tracking"
+)
+
+// Promoter runs store.PromoteWaitlist every interval, and sooner whenever it
+// is kicked, confirming each promotion to its rider like any other booking.
+type Promoter struct {
+	store     store.Store
+	notifier  notify.Notifier
+	occupancy *tracking.Occupancy
+	logger    *slog.Logger
+	interval  time.Duration
+	kick      chan struct{}
+}
+
+// NewPromoter returns a Promoter that notifies riders through n, reports the
+// seats it books to occ, which may be nil, and logs to logger.
+func NewPromoter(st store.Store, n notify.Notifier, occ *tracking.Occupancy, logger *slog.Logger, interval time.Duration) *Promoter {
+	if n == nil {
+		n = notify.Nop{}
+	}
+
+	return &Promoter{store: st, notifier: n, occupancy: occ, logger: logger, interval: interval, kick: make(chan struct{}, 1)}
+}
+
+// Kick asks for a promotion pass as soon as possible, such as after a
+// cancellation has released seats. It never blocks, and a nil Promoter
+// ignores it.
+func (p *Promoter) Kick() {
+	if p == nil {
+		return
+	}
+
+	select {
+	case p.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Run promotes waitlisted riders until ctx is cancelled.
+func (p *Promoter) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.promote(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.kick:
+		}
+	}
+}
+
+func (p *Promoter) promote(ctx context.Context) {
+	tickets, err := p.store.PromoteWaitlist(ctx)
+	if err != nil && ctx.Err() == nil {
+		p.logger.ErrorContext(ctx, "promoting waitlist failed", slog.String("error", err.Error()))
+	}
+
+	for _, t := range tickets {
+		p.logger.InfoContext(ctx, "waitlist entry promoted",
+			slog.Int("ticket_id", t.ID), slog.Int("bus_id", t.BusID), slog.Int("user_id", t.UserID))
+	}
+
+	p.occupancy.Refresh(ctx, tickets...)
+	notify.Async(p.logger, p.notifier, tickets...)
+}
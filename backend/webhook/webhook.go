@@ -0,0 +1,225 @@
+// Package webhook delivers booking events to the URLs third parties have
+// subscribed, signed with each subscription's secret so they can tell the
+// events came from us.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/models"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/payment"
+	"github.com/SreDeva/Bus_tracking_ticket_booking/backend/store"
+)
+
+// SignatureHeader carries a delivery's signature in the scheme of
+// payment.SignatureHeader: "t=<unix time>,v1=<hex>", where the hex is an
+// HMAC-SHA256 of "<unix time>.<body>" under the subscription's secret.
+const SignatureHeader = "Webhook-Signature"
+
+// EventHeader names the event a delivery carries.
+const EventHeader = "Webhook-Event"
+
+// batchSize is how many deliveries one pass claims.
+const batchSize = 50
+
+// Config sets how deliveries are made and retried.
+type Config struct {
+	// MaxAttempts is how many times a delivery is tried before it is
+	// recorded as failed.
+	MaxAttempts int
+	// Backoff is how long to wait before the first retry; each later retry
+	// waits twice as long as the one before, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout bounds each attempt.
+	Timeout time.Duration
+	// Interval is how often to look for deliveries that are due.
+	Interval time.Duration
+}
+
+// Dispatcher queues events for their subscribers and sends them every
+// interval, and sooner whenever one is published.
+type Dispatcher struct {
+	store  store.Store
+	client *http.Client
+	logger *slog.Logger
+	cfg    Config
+	kick   chan struct{}
+}
+
+// New returns a Dispatcher that logs to logger.
+func New(st store.Store, logger *slog.Logger, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		store:  st,
+		client: &http.Client{Timeout: cfg.Timeout},
+		logger: logger,
+		cfg:    cfg,
+		kick:   make(chan struct{}, 1),
+	}
+}
+
+// NewSecret returns a fresh secret to sign a subscription's deliveries with.
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Publish queues an event of the given type about data for every
+// subscriber to it. Failing to queue it is logged rather than returned, so
+// that it never fails the request that caused it. A nil Dispatcher
+// publishes nothing.
+func (d *Dispatcher) Publish(ctx context.Context, event string, data interface{}) {
+	if d == nil {
+		return
+	}
+
+	n, err := d.publish(ctx, event, data)
+	if err != nil {
+		d.logger.ErrorContext(ctx, "queueing webhook event failed", slog.String("event", event), slog.String("error", err.Error()))
+		return
+	}
+
+	if n > 0 {
+		select {
+		case d.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (d *Dispatcher) publish(ctx context.Context, event string, data interface{}) (int, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return 0, err
+	}
+
+	payload, err := json.Marshal(models.WebhookEvent{
+		ID: "evt_" + hex.EncodeToString(id), Type: event, CreatedAt: time.Now().UTC(), Data: data,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return d.store.EnqueueWebhook(ctx, event, payload)
+}
+
+// Run sends due deliveries until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.deliver(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.kick:
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context) {
+	// A claimed delivery is not claimed again until the lease runs out,
+	// which outlasts a whole batch of attempts that all time out.
+	lease := d.cfg.Timeout*batchSize + time.Minute
+
+	deliveries, err := d.store.ClaimWebhookDeliveries(ctx, batchSize, lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.ErrorContext(ctx, "claiming webhook deliveries failed", slog.String("error", err.Error()))
+		}
+
+		return
+	}
+
+	for _, w := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		d.attempt(ctx, w)
+	}
+}
+
+// attempt sends w once and records how it went.
+func (d *Dispatcher) attempt(ctx context.Context, w models.WebhookDelivery) {
+	var (
+		attemptErr string
+		retryAt    *time.Time
+	)
+
+	if err := d.send(ctx, w); err != nil {
+		attemptErr = err.Error()
+
+		logger := d.logger.With(slog.Int("delivery_id", w.ID), slog.Int("subscription_id", w.SubscriptionID),
+			slog.String("event", w.Event), slog.Int("attempt", w.Attempts+1), slog.String("error", attemptErr))
+
+		if w.Attempts+1 < d.cfg.MaxAttempts {
+			at := time.Now().Add(d.backoff(w.Attempts + 1))
+			retryAt = &at
+
+			logger.WarnContext(ctx, "webhook delivery failed; retrying", slog.Time("retry_at", at))
+		} else {
+			logger.ErrorContext(ctx, "webhook delivery failed; giving up")
+		}
+	}
+
+	if err := d.store.RecordWebhookAttempt(ctx, w.ID, attemptErr, retryAt); err != nil && ctx.Err() == nil {
+		d.logger.ErrorContext(ctx, "recording webhook attempt failed",
+			slog.Int("delivery_id", w.ID), slog.String("error", err.Error()))
+	}
+}
+
+// backoff returns how long to wait after the given number of failed
+// attempts.
+func (d *Dispatcher) backoff(failures int) time.Duration {
+	wait := d.cfg.Backoff
+	for i := 1; i < failures && wait < d.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > d.cfg.MaxBackoff {
+		wait = d.cfg.MaxBackoff
+	}
+
+	return wait
+}
+
+// send posts w's payload, signed, to its subscription. Any non-2xx response
+// is an error.
+func (d *Dispatcher) send(ctx context.Context, w models.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(w.Payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, w.Event)
+	req.Header.Set(SignatureHeader, payment.NewVerifier(w.Secret, 0).Sign(w.Payload, time.Now()))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("subscriber responded %s", resp.Status)
+	}
+
+	return nil
+}